@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"sso_3.0/internal/app"
 	configParser "sso_3.0/internal/config"
+	"syscall"
 )
 
 func main() {
@@ -22,8 +25,23 @@ func main() {
 		panic(fmt.Errorf("errors: %e", err))
 	}
 
+	// stop on ctrl+c and on the SIGTERM of docker
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	//run the purgers, the key rotation and the recurrence, reminder, webhook and outbox apps in the background
+	app.RunBackground(ctx)
+
+	//serve the grpc api as json over http
+	go app.Gateway.MustRun()
+
 	//start grpc server
-	app.GrpcServer.MustRun()
+	go app.GrpcServer.MustRun()
+
+	<-ctx.Done()
+	log.Info("Shutting down")
+
+	app.Stop()
 }
 
 func getLogger() *slog.Logger {
@@ -0,0 +1,64 @@
+package gatewayServer
+
+import (
+	"context"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"math"
+	"net/http"
+	api "sso_3.0/proto/gen"
+	"sso_3.0/proto/openapi"
+	"strconv"
+)
+
+// openApiPath is where the generated openapi document is served
+const openApiPath = "/openapi.json"
+
+// New creates the handler which serves every method of the AuthApi and the TaskApi as json over http
+// the routes are generated from the google.api.http options of api.proto, the requests are sent to the grpc server over conn
+// so they pass the same interceptors as the grpc clients, the Authorization header is sent as the authorization metadata
+func New(conn *grpc.ClientConn, log *slog.Logger) (http.Handler, error) {
+	mux := runtime.NewServeMux(runtime.WithErrorHandler(errorHandler))
+
+	// the handlers use the context of each request, the one given here is not kept
+	if err := api.RegisterAuthApiHandler(context.Background(), mux, conn); err != nil {
+		return nil, err
+	}
+
+	if err := api.RegisterTaskApiHandler(context.Background(), mux, conn); err != nil {
+		return nil, err
+	}
+
+	handler := http.NewServeMux()
+	handler.HandleFunc(openApiPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openapi.Document)
+	})
+	handler.Handle("/", mux)
+
+	log.Info("Gateway handlers registered")
+
+	return handler, nil
+}
+
+// errorHandler writes the status as json with the http status of its code
+// a RetryInfo detail like the one of the login lockout is also sent as the Retry-After header
+func errorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if st, ok := status.FromError(err); ok {
+		for _, detail := range st.Details() {
+			if retryInfo, ok := detail.(*errdetails.RetryInfo); ok && retryInfo.GetRetryDelay() != nil {
+				seconds := math.Ceil(retryInfo.GetRetryDelay().AsDuration().Seconds())
+				w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+			}
+		}
+	}
+
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
@@ -0,0 +1,216 @@
+package gatewayServer
+
+import (
+	"context"
+	"encoding/json"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	api "sso_3.0/proto/gen"
+	"strings"
+	"testing"
+	"time"
+)
+
+// taskServer records the requests of the gateway
+type taskServer struct {
+	api.UnimplementedTaskApiServer
+	authorization []string
+	addComment    *api.AddCommentRequest
+}
+
+func (s *taskServer) AddComment(ctx context.Context, req *api.AddCommentRequest) (*api.AddCommentResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.authorization = md.Get("authorization")
+	s.addComment = req
+
+	return &api.AddCommentResponse{Comment: &api.Comment{Id: 3, TaskId: req.GetTaskId(), Body: req.GetBody()}}, nil
+}
+
+func (s *taskServer) DeleteTask(ctx context.Context, req *api.DeleteTaskRequest) (*api.DeleteTaskResponse, error) {
+	return nil, status.Error(codes.NotFound, "task does not exist")
+}
+
+// authServer locks every login like the lockout does
+type authServer struct {
+	api.UnimplementedAuthApiServer
+}
+
+func (s *authServer) Login(ctx context.Context, req *api.LoginRequest) (*api.LoginResponse, error) {
+	st, err := status.New(codes.ResourceExhausted, "too many failed logins").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(90500 * time.Millisecond)})
+	if err != nil {
+		return nil, err
+	}
+	return nil, st.Err()
+}
+
+// newTestGateway serves the gateway over httptest, its requests go to the servers over an in-process connection
+func newTestGateway(t *testing.T, tasks *taskServer) *httptest.Server {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	api.RegisterAuthApiServer(grpcServer, &authServer{})
+	api.RegisterTaskApiServer(grpcServer, tasks)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	handler, err := New(conn, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestGatewaySendsTheRequest(t *testing.T) {
+	tasks := &taskServer{}
+	server := newTestGateway(t, tasks)
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/tasks/42/comments", strings.NewReader(`{"body": "looks good"}`))
+	req.Header.Set("Authorization", "Bearer tsk_key")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("status = %d %s, want 200", res.StatusCode, body)
+	}
+
+	if len(tasks.authorization) != 1 || tasks.authorization[0] != "Bearer tsk_key" {
+		t.Errorf("authorization metadata = %v, want the Authorization header", tasks.authorization)
+	}
+
+	if tasks.addComment.GetTaskId() != 42 || tasks.addComment.GetBody() != "looks good" {
+		t.Errorf("request = %v, want the task id of the path and the body", tasks.addComment)
+	}
+
+	var out struct {
+		Comment struct {
+			TaskId string `json:"taskId"`
+			Body   string `json:"body"`
+		} `json:"comment"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Comment.TaskId != "42" || out.Comment.Body != "looks good" {
+		t.Errorf("response = %+v, want the comment", out)
+	}
+}
+
+func TestGatewayMapsTheErrors(t *testing.T) {
+	server := newTestGateway(t, &taskServer{})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		statusCode int
+		retryAfter string
+	}{
+		{name: "not found", method: http.MethodDelete, path: "/v1/tasks/7", statusCode: http.StatusNotFound},
+		{name: "locked login", method: http.MethodPost, path: "/v1/auth/login", body: `{"email": "a@b.c", "password": "x"}`, statusCode: http.StatusTooManyRequests, retryAfter: "91"},
+		{name: "unimplemented", method: http.MethodGet, path: "/v1/labels", statusCode: http.StatusNotImplemented},
+		{name: "invalid path param", method: http.MethodDelete, path: "/v1/tasks/abc", statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tt.statusCode {
+				t.Errorf("status = %d, want %d", res.StatusCode, tt.statusCode)
+			}
+
+			if got := res.Header.Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.retryAfter)
+			}
+
+			var out struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			}
+			if err = json.NewDecoder(res.Body).Decode(&out); err != nil || out.Message == "" {
+				t.Errorf("body = %+v, %v, want the status as json", out, err)
+			}
+		})
+	}
+}
+
+func TestOpenApiHasEveryMethod(t *testing.T) {
+	server := newTestGateway(t, &taskServer{})
+
+	res, err := http.Get(server.URL + openApiPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationId string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	operations := make(map[string]bool)
+	for _, verbs := range doc.Paths {
+		for _, op := range verbs {
+			operations[op.OperationId] = true
+		}
+	}
+
+	for _, desc := range []grpc.ServiceDesc{api.AuthApi_ServiceDesc, api.TaskApi_ServiceDesc} {
+		service := desc.ServiceName[strings.LastIndex(desc.ServiceName, ".")+1:]
+
+		var names []string
+		for _, method := range desc.Methods {
+			names = append(names, method.MethodName)
+		}
+		for _, stream := range desc.Streams {
+			names = append(names, stream.StreamName)
+		}
+
+		for _, name := range names {
+			if !operations[service+"_"+name] {
+				t.Errorf("openapi document has no operation %s_%s", service, name)
+			}
+		}
+	}
+}
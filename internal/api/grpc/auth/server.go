@@ -3,13 +3,19 @@ package authServer
 import (
 	"context"
 	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"log/slog"
+	userModel "sso_3.0/internal/domain/user"
 	appErrors "sso_3.0/internal/errors"
 	authService "sso_3.0/internal/services/auth"
+	protoApiKey "sso_3.0/internal/utilities/getProto/apikey"
 	api "sso_3.0/proto/gen"
+	"time"
 )
 
 type serverApi struct {
@@ -25,28 +31,374 @@ func RegisterServer(grpcServer *grpc.Server, authService *authService.Service, l
 func (s *serverApi) Register(ctx context.Context, req *api.RegisterRequest) (*api.RegisterResponse, error) {
 	email := req.GetEmail()
 	pwd := req.GetPassword()
-	token, err := s.authService.Register(ctx, email, pwd)
+	tokens, err := s.authService.Register(ctx, email, pwd)
 
 	if err != nil {
 		if errors.Is(appErrors.ErrUserExists, err) {
 			return nil, status.Errorf(codes.AlreadyExists, err.Error())
 		}
+		if errors.Is(appErrors.ErrInvalidEmail, err) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "Internal Server Error")
 	}
 
-	return &api.RegisterResponse{Token: token}, nil
+	return &api.RegisterResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    timestamppb.New(tokens.ExpiresAt),
+	}, nil
 }
 func (s *serverApi) Login(ctx context.Context, req *api.LoginRequest) (*api.LoginResponse, error) {
 	email := req.GetEmail()
 	pwd := req.GetPassword()
-	token, err := s.authService.Login(ctx, email, pwd)
+	tokens, challenge, err := s.authService.Login(ctx, email, pwd)
 
 	if err != nil {
+		var retryErr *appErrors.RetryAfterError
+		if errors.As(err, &retryErr) {
+			return nil, retryAfterError(retryErr)
+		}
 		if errors.Is(appErrors.ErrInvalidCredentials, err) {
 			return nil, status.Errorf(codes.InvalidArgument, err.Error())
 		}
 		return nil, status.Errorf(codes.Internal, "Internal Server Error")
 	}
 
-	return &api.LoginResponse{Token: token}, nil
+	if challenge != nil {
+		return &api.LoginResponse{
+			ChallengeToken:     challenge.Token,
+			ChallengeExpiresAt: timestamppb.New(challenge.ExpiresAt),
+		}, nil
+	}
+
+	return &api.LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    timestamppb.New(tokens.ExpiresAt),
+	}, nil
+}
+
+func (s *serverApi) RefreshToken(ctx context.Context, req *api.RefreshTokenRequest) (*api.RefreshTokenResponse, error) {
+	refreshToken := req.GetRefreshToken()
+
+	if refreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, appErrors.NoArguments.Error())
+	}
+
+	tokens, err := s.authService.RefreshToken(ctx, refreshToken)
+
+	if err != nil {
+		if errors.Is(appErrors.ErrRefreshTokenInvalid, err) || errors.Is(appErrors.ErrRefreshTokenReused, err) || errors.Is(appErrors.ErrUserNotExists, err) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.RefreshTokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    timestamppb.New(tokens.ExpiresAt),
+	}, nil
+}
+
+func (s *serverApi) Logout(ctx context.Context, req *api.LogoutRequest) (*api.LogoutResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	err := s.authService.Logout(ctx, req.GetRefreshToken(), currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.ErrRefreshTokenInvalid, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.LogoutResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) ChangePassword(ctx context.Context, req *api.ChangePasswordRequest) (*api.ChangePasswordResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	tokens, err := s.authService.ChangePassword(ctx, req.GetOldPassword(), req.GetNewPassword(), currentUser)
+
+	if err != nil {
+		var retryErr *appErrors.RetryAfterError
+		if errors.As(err, &retryErr) {
+			return nil, retryAfterError(retryErr)
+		}
+		if errors.Is(appErrors.ErrInvalidCredentials, err) || errors.Is(appErrors.NoArguments, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.ChangePasswordResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    timestamppb.New(tokens.ExpiresAt),
+	}, nil
+}
+
+func (s *serverApi) RequestPasswordReset(ctx context.Context, req *api.RequestPasswordResetRequest) (*api.RequestPasswordResetResponse, error) {
+	email := req.GetEmail()
+
+	if email == "" {
+		return nil, status.Error(codes.InvalidArgument, appErrors.NoArguments.Error())
+	}
+
+	err := s.authService.RequestPasswordReset(ctx, email)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.RequestPasswordResetResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) ConfirmPasswordReset(ctx context.Context, req *api.ConfirmPasswordResetRequest) (*api.ConfirmPasswordResetResponse, error) {
+	err := s.authService.ConfirmPasswordReset(ctx, req.GetToken(), req.GetNewPassword())
+
+	if err != nil {
+		if errors.Is(appErrors.ErrResetTokenInvalid, err) || errors.Is(appErrors.NoArguments, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.ConfirmPasswordResetResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) VerifyEmail(ctx context.Context, req *api.VerifyEmailRequest) (*api.VerifyEmailResponse, error) {
+	err := s.authService.VerifyEmail(ctx, req.GetEmail(), req.GetCode())
+
+	if err != nil {
+		if errors.Is(appErrors.ErrVerificationCodeInvalid, err) || errors.Is(appErrors.NoArguments, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.VerifyEmailResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) ResendVerification(ctx context.Context, req *api.ResendVerificationRequest) (*api.ResendVerificationResponse, error) {
+	email := req.GetEmail()
+
+	if email == "" {
+		return nil, status.Error(codes.InvalidArgument, appErrors.NoArguments.Error())
+	}
+
+	err := s.authService.ResendVerification(ctx, email)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.ResendVerificationResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) UnlockAccount(ctx context.Context, req *api.UnlockAccountRequest) (*api.UnlockAccountResponse, error) {
+	err := s.authService.UnlockAccount(ctx, req.GetEmail(), req.GetIp())
+
+	if err != nil {
+		if errors.Is(appErrors.NoArguments, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.UnlockAccountResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) LoginVerifyTotp(ctx context.Context, req *api.LoginVerifyTotpRequest) (*api.LoginVerifyTotpResponse, error) {
+	tokens, err := s.authService.LoginVerifyTotp(ctx, req.GetChallengeToken(), req.GetCode())
+
+	if err != nil {
+		var retryErr *appErrors.RetryAfterError
+		if errors.As(err, &retryErr) {
+			return nil, retryAfterError(retryErr)
+		}
+		if errors.Is(appErrors.NoArguments, err) || errors.Is(appErrors.ErrTotpCodeInvalid, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(appErrors.ErrChallengeInvalid, err) || errors.Is(appErrors.ErrTotpNotEnabled, err) || errors.Is(appErrors.ErrUserNotExists, err) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.LoginVerifyTotpResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    timestamppb.New(tokens.ExpiresAt),
+	}, nil
+}
+
+func (s *serverApi) EnrollTotp(ctx context.Context, req *api.EnrollTotpRequest) (*api.EnrollTotpResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	enrollment, err := s.authService.EnrollTotp(ctx, currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.ErrTotpEnabled, err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.EnrollTotpResponse{Secret: enrollment.Secret, Uri: enrollment.URI}, nil
+}
+
+func (s *serverApi) ConfirmTotp(ctx context.Context, req *api.ConfirmTotpRequest) (*api.ConfirmTotpResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	recoveryCodes, err := s.authService.ConfirmTotp(ctx, req.GetCode(), currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.NoArguments, err) || errors.Is(appErrors.ErrTotpCodeInvalid, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(appErrors.ErrTotpEnabled, err) || errors.Is(appErrors.ErrTotpNotEnabled, err) || errors.Is(appErrors.ErrTotpAttemptsExceeded, err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.ConfirmTotpResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *serverApi) DisableTotp(ctx context.Context, req *api.DisableTotpRequest) (*api.DisableTotpResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	err := s.authService.DisableTotp(ctx, req.GetCode(), currentUser)
+
+	if err != nil {
+		var retryErr *appErrors.RetryAfterError
+		if errors.As(err, &retryErr) {
+			return nil, retryAfterError(retryErr)
+		}
+		if errors.Is(appErrors.NoArguments, err) || errors.Is(appErrors.ErrTotpCodeInvalid, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(appErrors.ErrTotpNotEnabled, err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.DisableTotpResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) CreateApiKey(ctx context.Context, req *api.CreateApiKeyRequest) (*api.CreateApiKeyResponse, error) {
+	var expiresAt time.Time
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	if req.GetExpiresAt() != nil {
+		expiresAt = req.GetExpiresAt().AsTime()
+	}
+
+	apiKey, key, err := s.authService.CreateApiKey(ctx, req.GetName(), req.GetScopes(), expiresAt, currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.NoArguments, err) || errors.Is(appErrors.ErrInvalidScope, err) || errors.Is(appErrors.ErrInvalidExpiry, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.CreateApiKeyResponse{ApiKey: protoApiKey.GetProtoApiKey(apiKey), Key: key}, nil
+}
+
+func (s *serverApi) ListApiKeys(ctx context.Context, req *api.ListApiKeysRequest) (*api.ListApiKeysResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	apiKeys, err := s.authService.ListApiKeys(ctx, currentUser)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.ListApiKeysResponse{ApiKeys: protoApiKey.GetProtoApiKeys(apiKeys)}, nil
+}
+
+func (s *serverApi) RevokeApiKey(ctx context.Context, req *api.RevokeApiKeyRequest) (*api.RevokeApiKeyResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	err := s.authService.RevokeApiKey(ctx, int(req.GetId()), currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.NoArguments, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(appErrors.ErrApiKeyNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.RevokeApiKeyResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) GetJwks(ctx context.Context, req *api.GetJwksRequest) (*api.GetJwksResponse, error) {
+	var keys []*api.Jwk
+
+	jwks, err := s.authService.GetJwks(ctx)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	for _, jwk := range jwks {
+		keys = append(keys, &api.Jwk{Kty: jwk.Kty, Kid: jwk.Kid, Use: jwk.Use, Alg: jwk.Alg, N: jwk.N, E: jwk.E})
+	}
+
+	return &api.GetJwksResponse{Keys: keys}, nil
+}
+
+func (s *serverApi) GrantRole(ctx context.Context, req *api.GrantRoleRequest) (*api.GrantRoleResponse, error) {
+	err := s.authService.GrantRole(ctx, req.GetEmail(), userModel.Role(req.GetRole()))
+
+	if err != nil {
+		if errors.Is(appErrors.NoArguments, err) || errors.Is(appErrors.ErrInvalidRole, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(appErrors.ErrUserNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.GrantRoleResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) RevokeRole(ctx context.Context, req *api.RevokeRoleRequest) (*api.RevokeRoleResponse, error) {
+	err := s.authService.RevokeRole(ctx, req.GetEmail(), userModel.Role(req.GetRole()))
+
+	if err != nil {
+		if errors.Is(appErrors.NoArguments, err) || errors.Is(appErrors.ErrInvalidRole, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(appErrors.ErrUserNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(appErrors.ErrRoleNotGranted, err) || errors.Is(appErrors.ErrLastAdmin, err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.RevokeRoleResponse{Status: "Success"}, nil
+}
+
+// retryAfterError is a ResourceExhausted status with the retry delay as RetryInfo detail
+func retryAfterError(err *appErrors.RetryAfterError) error {
+	st := status.New(codes.ResourceExhausted, err.Error())
+
+	detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(err.RetryAfter)})
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
 }
@@ -0,0 +1,91 @@
+package taskServer
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	appErrors "sso_3.0/internal/errors"
+	protoComment "sso_3.0/internal/utilities/getProto/comment"
+	api "sso_3.0/proto/gen"
+)
+
+func (s *serverApi) AddComment(ctx context.Context, req *api.AddCommentRequest) (*api.AddCommentResponse, error) {
+	taskId := req.GetTaskId()
+	body := req.GetBody()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	comment, err := s.taskService.AddComment(ctx, int(taskId), body, currentUser)
+
+	if err != nil {
+		return nil, getCommentError(err)
+	}
+
+	return &api.AddCommentResponse{
+		Comment: protoComment.GetProtoComment(comment),
+	}, nil
+}
+
+func (s *serverApi) EditComment(ctx context.Context, req *api.EditCommentRequest) (*api.EditCommentResponse, error) {
+	commentId := req.GetCommentId()
+	body := req.GetBody()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	comment, err := s.taskService.EditComment(ctx, int(commentId), body, currentUser)
+
+	if err != nil {
+		return nil, getCommentError(err)
+	}
+
+	return &api.EditCommentResponse{
+		Comment: protoComment.GetProtoComment(comment),
+	}, nil
+}
+
+func (s *serverApi) DeleteComment(ctx context.Context, req *api.DeleteCommentRequest) (*api.DeleteCommentResponse, error) {
+	commentId := req.GetCommentId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	err := s.taskService.DeleteComment(ctx, int(commentId), currentUser)
+
+	if err != nil {
+		return nil, getCommentError(err)
+	}
+
+	return &api.DeleteCommentResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) ListComments(ctx context.Context, req *api.ListCommentsRequest) (*api.ListCommentsResponse, error) {
+	taskId := req.GetTaskId()
+	pageSize := req.GetPageSize()
+	pageToken := req.GetPageToken()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	comments, nextPageToken, err := s.taskService.ListComments(ctx, int(taskId), int(pageSize), pageToken, currentUser)
+
+	if err != nil {
+		return nil, getCommentError(err)
+	}
+
+	return &api.ListCommentsResponse{
+		Comments:      protoComment.GetProtoComments(comments),
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+// getCommentError converts the errors of the comment methods to grpc errors
+func getCommentError(err error) error {
+	if errors.Is(appErrors.ErrTaskNotExists, err) || errors.Is(appErrors.ErrCommentNotExists, err) || errors.Is(appErrors.NothingToDelete, err) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrNoPermission, err) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrEmptyComment, err) || errors.Is(appErrors.ErrCommentTooLong, err) || errors.Is(appErrors.ErrInvalidPageToken, err) || errors.Is(appErrors.ErrInvalidPageSize, err) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return status.Error(codes.Internal, appErrors.Internal.Error())
+}
@@ -0,0 +1,110 @@
+package taskServer
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	appErrors "sso_3.0/internal/errors"
+	protoLabel "sso_3.0/internal/utilities/getProto/label"
+	protoTasks "sso_3.0/internal/utilities/getProto/task"
+	api "sso_3.0/proto/gen"
+)
+
+func (s *serverApi) CreateLabel(ctx context.Context, req *api.CreateLabelRequest) (*api.CreateLabelResponse, error) {
+	name := req.GetName()
+	color := req.GetColor()
+
+	label, err := s.taskService.CreateLabel(ctx, name, color)
+	if err != nil {
+		return nil, getLabelError(err)
+	}
+
+	return &api.CreateLabelResponse{Label: protoLabel.GetLabel(label)}, nil
+}
+
+func (s *serverApi) UpdateLabel(ctx context.Context, req *api.UpdateLabelRequest) (*api.UpdateLabelResponse, error) {
+	labelId := req.GetLabelId()
+	name := req.GetName()
+	color := req.GetColor()
+
+	if name == "" && color == "" {
+		return nil, status.Error(codes.InvalidArgument, appErrors.NoArguments.Error())
+	}
+
+	label, err := s.taskService.UpdateLabel(ctx, name, color, int(labelId))
+	if err != nil {
+		return nil, getLabelError(err)
+	}
+
+	return &api.UpdateLabelResponse{Label: protoLabel.GetLabel(label)}, nil
+}
+
+func (s *serverApi) DeleteLabel(ctx context.Context, req *api.DeleteLabelRequest) (*api.DeleteLabelResponse, error) {
+	labelId := req.GetLabelId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	err := s.taskService.DeleteLabel(ctx, int(labelId), currentUser)
+	if err != nil {
+		return nil, getLabelError(err)
+	}
+
+	return &api.DeleteLabelResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) GetAllLabels(ctx context.Context, req *api.GetAllLabelsRequest) (*api.GetAllLabelsResponse, error) {
+	labels, err := s.taskService.GetAllLabels(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.GetAllLabelsResponse{Labels: protoLabel.GetLabels(labels)}, nil
+}
+
+func (s *serverApi) AddLabel(ctx context.Context, req *api.AddLabelRequest) (*api.AddLabelResponse, error) {
+	taskId := req.GetTaskId()
+	labelId := req.GetLabelId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	task, err := s.taskService.AddLabel(ctx, int(taskId), int(labelId), currentUser)
+	if err != nil {
+		return nil, getLabelError(err)
+	}
+
+	return &api.AddLabelResponse{Task: protoTasks.GetProtoTask(task)}, nil
+}
+
+func (s *serverApi) RemoveLabel(ctx context.Context, req *api.RemoveLabelRequest) (*api.RemoveLabelResponse, error) {
+	taskId := req.GetTaskId()
+	labelId := req.GetLabelId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	task, err := s.taskService.RemoveLabel(ctx, int(taskId), int(labelId), currentUser)
+	if err != nil {
+		return nil, getLabelError(err)
+	}
+
+	return &api.RemoveLabelResponse{Task: protoTasks.GetProtoTask(task)}, nil
+}
+
+// getLabelError converts the errors of the label methods to grpc errors
+func getLabelError(err error) error {
+	if errors.Is(appErrors.ErrLabelNotExists, err) || errors.Is(appErrors.ErrTaskNotExists, err) ||
+		errors.Is(appErrors.NothingToDelete, err) || errors.Is(appErrors.TaskNotLabeled, err) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrLabelExists, err) || errors.Is(appErrors.TaskAlreadyLabeled, err) {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrInvalidLabelColor, err) || errors.Is(appErrors.NoArguments, err) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrNoPermission, err) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return status.Error(codes.Internal, appErrors.Internal.Error())
+}
@@ -0,0 +1,169 @@
+package taskServer
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+	protoProject "sso_3.0/internal/utilities/getProto/project"
+	api "sso_3.0/proto/gen"
+)
+
+func (s *serverApi) CreateProject(ctx context.Context, req *api.CreateProjectRequest) (*api.CreateProjectResponse, error) {
+	name := req.GetName()
+	description := req.GetDescription()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	project, err := s.taskService.CreateProject(ctx, name, description, currentUser)
+	if err != nil {
+		return nil, getProjectError(err)
+	}
+
+	return &api.CreateProjectResponse{Project: protoProject.GetProject(project)}, nil
+}
+
+func (s *serverApi) GetProject(ctx context.Context, req *api.GetProjectRequest) (*api.GetProjectResponse, error) {
+	projectId := req.GetProjectId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	project, err := s.taskService.GetProject(ctx, int(projectId), currentUser)
+	if err != nil {
+		return nil, getProjectError(err)
+	}
+
+	return &api.GetProjectResponse{Project: protoProject.GetProject(project)}, nil
+}
+
+func (s *serverApi) GetAllProjects(ctx context.Context, req *api.GetAllProjectsRequest) (*api.GetAllProjectsResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	projects, err := s.taskService.GetAllProjects(ctx, currentUser)
+	if err != nil {
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.GetAllProjectsResponse{Projects: protoProject.GetProjects(projects)}, nil
+}
+
+func (s *serverApi) UpdateProject(ctx context.Context, req *api.UpdateProjectRequest) (*api.UpdateProjectResponse, error) {
+	projectId := req.GetProjectId()
+	name := req.GetName()
+	description := req.GetDescription()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	if name == "" && description == "" {
+		return nil, status.Error(codes.InvalidArgument, appErrors.NoArguments.Error())
+	}
+
+	project, err := s.taskService.UpdateProject(ctx, name, description, int(projectId), currentUser)
+	if err != nil {
+		return nil, getProjectError(err)
+	}
+
+	return &api.UpdateProjectResponse{Project: protoProject.GetProject(project)}, nil
+}
+
+func (s *serverApi) DeleteProject(ctx context.Context, req *api.DeleteProjectRequest) (*api.DeleteProjectResponse, error) {
+	projectId := req.GetProjectId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	err := s.taskService.DeleteProject(ctx, int(projectId), currentUser)
+	if err != nil {
+		return nil, getProjectError(err)
+	}
+
+	return &api.DeleteProjectResponse{Status: "Success"}, nil
+}
+
+// getProjectError converts the errors of the project methods to grpc errors
+func getProjectError(err error) error {
+	if errors.Is(appErrors.ErrProjectNotExists, err) || errors.Is(appErrors.NothingToDelete, err) || errors.Is(appErrors.ErrNotProjectMember, err) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrAlreadyProjectMember, err) {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrProjectNotEmpty, err) || errors.Is(appErrors.ErrLastProjectOwner, err) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	if errors.Is(appErrors.NoArguments, err) || errors.Is(appErrors.ErrInvalidProjectRole, err) || errors.Is(appErrors.ErrInviteInvalid, err) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrNoPermission, err) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return status.Error(codes.Internal, appErrors.Internal.Error())
+}
+
+func (s *serverApi) InviteMember(ctx context.Context, req *api.InviteMemberRequest) (*api.InviteMemberResponse, error) {
+	projectId := req.GetProjectId()
+	email := req.GetEmail()
+	role := getProjectRole(req.GetRole())
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	invite, err := s.taskService.InviteMember(ctx, int(projectId), email, role, currentUser)
+	if err != nil {
+		return nil, getProjectError(err)
+	}
+
+	return &api.InviteMemberResponse{Token: invite.Token, ExpiresAt: timestamppb.New(invite.ExpiresAt)}, nil
+}
+
+func (s *serverApi) AcceptInvite(ctx context.Context, req *api.AcceptInviteRequest) (*api.AcceptInviteResponse, error) {
+	token := req.GetToken()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	member, err := s.taskService.AcceptInvite(ctx, token, currentUser)
+	if err != nil {
+		return nil, getProjectError(err)
+	}
+
+	return &api.AcceptInviteResponse{Member: protoProject.GetProjectMember(member)}, nil
+}
+
+func (s *serverApi) RemoveMember(ctx context.Context, req *api.RemoveMemberRequest) (*api.RemoveMemberResponse, error) {
+	projectId := req.GetProjectId()
+	userId := req.GetUserId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	err := s.taskService.RemoveMember(ctx, int(projectId), userId, currentUser)
+	if err != nil {
+		return nil, getProjectError(err)
+	}
+
+	return &api.RemoveMemberResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) ListMembers(ctx context.Context, req *api.ListMembersRequest) (*api.ListMembersResponse, error) {
+	projectId := req.GetProjectId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	members, err := s.taskService.ListMembers(ctx, int(projectId), currentUser)
+	if err != nil {
+		return nil, getProjectError(err)
+	}
+
+	return &api.ListMembersResponse{Members: protoProject.GetProjectMembers(members)}, nil
+}
+
+// getProjectRole converts the proto project role to the model one, unspecified is not valid
+func getProjectRole(role api.ProjectRole) models.ProjectRole {
+	switch role {
+	case api.ProjectRole_PROJECT_ROLE_VIEWER:
+		return models.ProjectViewer
+	case api.ProjectRole_PROJECT_ROLE_EDITOR:
+		return models.ProjectEditor
+	case api.ProjectRole_PROJECT_ROLE_OWNER:
+		return models.ProjectOwner
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,36 @@
+package taskServer
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	appErrors "sso_3.0/internal/errors"
+	protoReminder "sso_3.0/internal/utilities/getProto/reminder"
+	api "sso_3.0/proto/gen"
+)
+
+func (s *serverApi) GetReminderSettings(ctx context.Context, req *api.GetReminderSettingsRequest) (*api.GetReminderSettingsResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	settings, err := s.taskService.GetReminderSettings(ctx, currentUser)
+	if err != nil {
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.GetReminderSettingsResponse{Settings: protoReminder.GetReminderSettings(settings)}, nil
+}
+
+func (s *serverApi) SetReminderSettings(ctx context.Context, req *api.SetReminderSettingsRequest) (*api.SetReminderSettingsResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	settings, err := s.taskService.SetReminderSettings(ctx, protoReminder.GetReminderSettingsModel(req.GetSettings()), currentUser)
+	if err != nil {
+		if errors.Is(appErrors.ErrInvalidLeadTime, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.SetReminderSettingsResponse{Settings: protoReminder.GetReminderSettings(settings)}, nil
+}
@@ -12,9 +12,11 @@ import (
 	appErrors "sso_3.0/internal/errors"
 	authService "sso_3.0/internal/services/auth"
 	"sso_3.0/internal/services/tasks"
+	protoDependency "sso_3.0/internal/utilities/getProto/dependency"
 	protoStatus "sso_3.0/internal/utilities/getProto/status"
 	protoTasks "sso_3.0/internal/utilities/getProto/task"
 	api "sso_3.0/proto/gen"
+	"time"
 )
 
 type serverApi struct {
@@ -32,13 +34,25 @@ func (s *serverApi) CreateTask(ctx context.Context, req *api.CreateTaskRequest)
 	title := req.GetTitle()
 	description := req.GetDescription()
 	statusId := req.GetStatusId()
+	parentId := req.GetParentId()
+	projectId := req.GetProjectId()
+	priority := models.Priority(req.GetPriority())
 	due := req.GetDue().AsTime()
+	recurrence := req.GetRecurrence()
 	user := s.authService.GetUserFromCTX(ctx)
-	task, err := s.taskService.CreateTask(ctx, title, description, user.Id, int(statusId), due)
+	task, err := s.taskService.CreateTask(ctx, title, description, user, int(projectId), int(statusId), int(parentId), priority, due, recurrence)
 
 	if err != nil {
-		if errors.Is(appErrors.ErrStatusUndefined, err) {
-			return nil, status.Errorf(codes.NotFound, err.Error())
+		if errors.Is(appErrors.ErrStatusUndefined, err) || errors.Is(appErrors.ErrParentTaskNotExists, err) || errors.Is(appErrors.ErrProjectNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(appErrors.ErrInvalidPriority, err) || errors.Is(appErrors.ErrProjectRequired, err) ||
+			errors.Is(appErrors.ErrStatusNotInProject, err) || errors.Is(appErrors.ErrParentNotInProject, err) ||
+			errors.Is(appErrors.ErrInvalidRecurrence, err) || errors.Is(appErrors.ErrRecurrenceNeedsDue, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 		return nil, status.Errorf(codes.Internal, appErrors.Internal.Error())
 	}
@@ -54,16 +68,27 @@ func (s *serverApi) CreateTask(ctx context.Context, req *api.CreateTaskRequest)
 		Completed:   taskProto.Completed,
 		Status:      taskProto.Status,
 		Assignees:   taskProto.Assignees,
+		ParentId:    taskProto.ParentId,
+		Labels:      taskProto.Labels,
+		Priority:    taskProto.Priority,
+		ProjectId:   taskProto.ProjectId,
+		Recurrence:  taskProto.Recurrence,
+		SeriesId:    taskProto.SeriesId,
 	}, nil
 }
 func (s *serverApi) DeleteTask(ctx context.Context, req *api.DeleteTaskRequest) (*api.DeleteTaskResponse, error) {
 	taskId := req.GetTaskId()
+	policy := getSubtaskPolicy(req.GetSubtasks())
 	currentUser := s.authService.GetUserFromCTX(ctx)
-	err := s.taskService.DeleteTask(ctx, int(taskId), currentUser)
+	err := s.taskService.DeleteTask(ctx, int(taskId), policy, currentUser)
 
 	if err != nil {
-		if errors.Is(appErrors.NothingToDelete, err) {
-			return nil, status.Errorf(codes.NotFound, err.Error())
+		if errors.Is(appErrors.NothingToDelete, err) || errors.Is(appErrors.ErrTaskNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+
+		if errors.Is(appErrors.ErrTaskHasSubtasks, err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
 		}
 
 		if errors.Is(appErrors.ErrNoPermission, err) {
@@ -74,19 +99,66 @@ func (s *serverApi) DeleteTask(ctx context.Context, req *api.DeleteTaskRequest)
 
 	return &api.DeleteTaskResponse{Status: "Success"}, nil
 }
+func (s *serverApi) ListDeletedTasks(ctx context.Context, req *api.ListDeletedTasksRequest) (*api.ListDeletedTasksResponse, error) {
+	user := s.authService.GetUserFromCTX(ctx)
+
+	tasks, nextPageToken, err := s.taskService.ListDeletedTasks(ctx, user, int(req.GetPageSize()), req.GetPageToken())
+
+	if err != nil {
+		if errors.Is(appErrors.ErrInvalidPageToken, err) || errors.Is(appErrors.ErrInvalidPageSize, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.ListDeletedTasksResponse{
+		Tasks:         protoTasks.GetProtoTasks(tasks),
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (s *serverApi) RestoreTask(ctx context.Context, req *api.RestoreTaskRequest) (*api.RestoreTaskResponse, error) {
+	taskId := req.GetTaskId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	task, err := s.taskService.RestoreTask(ctx, int(taskId), currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.ErrTaskNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(appErrors.ErrParentTaskDeleted, err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.RestoreTaskResponse{Task: protoTasks.GetProtoTask(task)}, nil
+}
+
 func (s *serverApi) UpdateTask(ctx context.Context, req *api.UpdateTaskRequest) (*api.UpdateTaskResponse, error) {
 	title := req.GetTitle()
 	description := req.GetDescription()
 	statusId := req.GetStatusId()
-	due := req.GetDue()
 	completed := req.GetCompleted()
+	cascade := req.GetCascade()
+	priority := getPriority(req.Priority)
 	id := req.GetTaskId()
 
+	// the due is not updated if it is not set
+	var due time.Time
+	if req.GetDue() != nil {
+		due = req.GetDue().AsTime()
+	}
+
 	//get user from ctx -> from JWT
 	user := s.authService.GetUserFromCTX(ctx)
 
 	//update task
-	task, err := s.taskService.UpdateTask(ctx, title, description, due.AsTime(), int(statusId), int(id), completed, user)
+	task, err := s.taskService.UpdateTask(ctx, title, description, due, int(statusId), int(id), completed, priority, req.Recurrence, cascade, user)
 
 	// handle errors
 	if err != nil {
@@ -98,6 +170,15 @@ func (s *serverApi) UpdateTask(ctx context.Context, req *api.UpdateTaskRequest)
 		if errors.Is(appErrors.ErrNoPermission, err) {
 			return nil, status.Errorf(codes.PermissionDenied, err.Error())
 		}
+
+		if errors.Is(appErrors.ErrTaskHasOpenSubtasks, err) || errors.Is(appErrors.ErrTaskBlocked, err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+
+		if errors.Is(appErrors.ErrInvalidPriority, err) || errors.Is(appErrors.ErrStatusNotInProject, err) ||
+			errors.Is(appErrors.ErrInvalidRecurrence, err) || errors.Is(appErrors.ErrRecurrenceNeedsDue, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, appErrors.Internal.Error())
 	}
 
@@ -110,27 +191,46 @@ func (s *serverApi) UpdateTask(ctx context.Context, req *api.UpdateTaskRequest)
 		Due:         taskProto.Due,
 		Completed:   taskProto.Completed,
 		Status:      taskProto.Status,
+		Assignees:   taskProto.Assignees,
+		ParentId:    taskProto.ParentId,
+		Labels:      taskProto.Labels,
+		Priority:    taskProto.Priority,
+		ProjectId:   taskProto.ProjectId,
+		Recurrence:  taskProto.Recurrence,
+		SeriesId:    taskProto.SeriesId,
 	}, nil
 }
 func (s *serverApi) CreateStatus(ctx context.Context, req *api.CreateStatusRequest) (*api.CreateStatusResponse, error) {
 	title := req.GetTitle()
 	description := req.GetDescription()
-	statusRes, err := s.taskService.CreateStatus(ctx, title, description)
+	projectId := req.GetProjectId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+	statusRes, err := s.taskService.CreateStatus(ctx, title, description, int(projectId), currentUser)
 	if err != nil {
+		if errors.Is(appErrors.ErrProjectNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, appErrors.Internal.Error())
 	}
 
-	return &api.CreateStatusResponse{Description: statusRes.Description, Title: statusRes.Title, Id: int64(statusRes.Id)}, err
+	return &api.CreateStatusResponse{Description: statusRes.Description, Title: statusRes.Title, Id: int64(statusRes.Id), ProjectId: int64(statusRes.ProjectId)}, err
 }
 func (s *serverApi) DeleteStatus(ctx context.Context, req *api.DeleteStatusRequest) (*api.DeleteStatusResponse, error) {
 	statusId := req.GetStatusId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
 
-	err := s.taskService.DeleteStatus(ctx, int(statusId))
+	err := s.taskService.DeleteStatus(ctx, int(statusId), currentUser)
 	if err != nil {
 		fmt.Println(err)
-		if errors.Is(appErrors.NothingToDelete, err) {
+		if errors.Is(appErrors.NothingToDelete, err) || errors.Is(appErrors.ErrStatusUndefined, err) {
 			return nil, status.Errorf(codes.NotFound, err.Error())
 		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, appErrors.Internal.Error())
 	}
 
@@ -146,12 +246,16 @@ func (s *serverApi) UpdateStatus(ctx context.Context, req *api.UpdateStatusReque
 		return nil, status.Errorf(codes.InvalidArgument, appErrors.NoArguments.Error())
 	}
 
-	statusRes, err := s.taskService.UpdateStatus(ctx, title, description, int(statusId))
+	currentUser := s.authService.GetUserFromCTX(ctx)
+	statusRes, err := s.taskService.UpdateStatus(ctx, title, description, int(statusId), currentUser)
 
 	if err != nil {
 		if errors.Is(appErrors.ErrStatusUndefined, err) {
 			return nil, status.Error(codes.NotFound, err.Error())
 		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 
 		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
 	}
@@ -160,6 +264,7 @@ func (s *serverApi) UpdateStatus(ctx context.Context, req *api.UpdateStatusReque
 		Id:          int64(statusRes.Id),
 		Title:       statusRes.Title,
 		Description: statusRes.Description,
+		ProjectId:   int64(statusRes.ProjectId),
 	}, nil
 
 }
@@ -168,32 +273,255 @@ func (s *serverApi) GetTasksByFilter(ctx context.Context, req *api.GetTasksByFil
 	user := s.authService.GetUserFromCTX(ctx)
 	var tasks []*api.Task
 
-	filters := &models.TaskFilters{
-		Completed:    req.GetCompleted(),
-		UnCompleted:  req.GetUnCompleted(),
-		CreatedByMe:  req.GetCreatedByMe(),
-		AssignedToMe: req.GetAssignedToMe(),
-		AssigneeId:   req.GetAssigneeId(),
-		StatusId:     int(req.GetStatusId()),
-	}
-	fmt.Println(filters)
-	tasksRes, err := s.taskService.GetCreatedTasksByFilter(ctx, user.Id, filters)
+	filters := getTaskFilters(req)
+	tasksRes, nextPageToken, err := s.taskService.GetCreatedTasksByFilter(ctx, user, filters)
 
 	if err != nil {
 		fmt.Println(err)
 		if errors.Is(appErrors.ErrStatusUndefined, err) {
 			return nil, status.Error(codes.NotFound, err.Error())
 		}
+		if errors.Is(appErrors.ErrInvalidPageToken, err) || errors.Is(appErrors.ErrInvalidPageSize, err) || errors.Is(appErrors.ErrInvalidPriority, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
 	}
 
 	tasks = protoTasks.GetProtoTasks(tasksRes)
 
 	return &api.GetTasksByFilterResponse{
-		Tasks: tasks,
+		Tasks:         tasks,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (s *serverApi) SearchTasks(ctx context.Context, req *api.SearchTasksRequest) (*api.SearchTasksResponse, error) {
+	user := s.authService.GetUserFromCTX(ctx)
+
+	filters := getTaskFilters(req.GetFilters())
+	filters.PageSize = int(req.GetPageSize())
+	filters.PageToken = req.GetPageToken()
+
+	results, nextPageToken, err := s.taskService.SearchTasks(ctx, user, req.GetQuery(), filters)
+
+	if err != nil {
+		if errors.Is(appErrors.ErrEmptySearchQuery, err) || errors.Is(appErrors.ErrInvalidPageToken, err) || errors.Is(appErrors.ErrInvalidPageSize, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.SearchTasksResponse{
+		Results:       protoTasks.GetProtoSearchResults(results),
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (s *serverApi) WatchTasks(req *api.GetTasksByFilterRequest, stream api.TaskApi_WatchTasksServer) error {
+	ctx := stream.Context()
+	user := s.authService.GetUserFromCTX(ctx)
+	filters := getTaskFilters(req)
+
+	err := s.taskService.WatchTasks(ctx, user, filters, func(event *models.TaskEvent) error {
+		return stream.Send(protoTasks.GetProtoTaskEvent(event))
+	})
+
+	if err != nil {
+		if errors.Is(appErrors.ErrWatchTooSlow, err) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return nil
+}
+
+func (s *serverApi) GetSubtasks(ctx context.Context, req *api.GetSubtasksRequest) (*api.GetSubtasksResponse, error) {
+	taskId := req.GetTaskId()
+	recursive := req.GetRecursive()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	subtasks, err := s.taskService.GetSubtasks(ctx, int(taskId), recursive, currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.ErrTaskNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.GetSubtasksResponse{
+		Tasks: protoTasks.GetProtoTasks(subtasks),
+	}, nil
+}
+
+func (s *serverApi) GetTaskHistory(ctx context.Context, req *api.GetTaskHistoryRequest) (*api.GetTaskHistoryResponse, error) {
+	taskId := req.GetTaskId()
+	pageSize := req.GetPageSize()
+	pageToken := req.GetPageToken()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	entries, nextPageToken, err := s.taskService.GetTaskHistory(ctx, int(taskId), int(pageSize), pageToken, currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.ErrTaskNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(appErrors.ErrInvalidPageToken, err) || errors.Is(appErrors.ErrInvalidPageSize, err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.GetTaskHistoryResponse{
+		Entries:       protoTasks.GetProtoHistory(entries),
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (s *serverApi) AddDependency(ctx context.Context, req *api.AddDependencyRequest) (*api.AddDependencyResponse, error) {
+	blockerId := req.GetBlockerId()
+	blockedId := req.GetBlockedId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	dependency, err := s.taskService.AddDependency(ctx, int(blockerId), int(blockedId), currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.ErrTaskNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(appErrors.ErrDependencyExists, err) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		if errors.Is(appErrors.ErrDependencyCycle, err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.AddDependencyResponse{
+		Dependency: protoDependency.GetProtoDependency(dependency),
+	}, nil
+}
+
+func (s *serverApi) RemoveDependency(ctx context.Context, req *api.RemoveDependencyRequest) (*api.RemoveDependencyResponse, error) {
+	blockerId := req.GetBlockerId()
+	blockedId := req.GetBlockedId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	err := s.taskService.RemoveDependency(ctx, int(blockerId), int(blockedId), currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.NothingToDelete, err) || errors.Is(appErrors.ErrTaskNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.RemoveDependencyResponse{Status: "Success"}, nil
+}
+
+func (s *serverApi) GetDependencyGraph(ctx context.Context, req *api.GetDependencyGraphRequest) (*api.GetDependencyGraphResponse, error) {
+	taskId := req.GetTaskId()
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	graph, err := s.taskService.GetDependencyGraph(ctx, int(taskId), currentUser)
+
+	if err != nil {
+		if errors.Is(appErrors.ErrTaskNotExists, err) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
+	}
+
+	return &api.GetDependencyGraphResponse{
+		Tasks:        protoTasks.GetProtoTasks(graph.Tasks),
+		Dependencies: protoDependency.GetProtoDependencies(graph.Dependencies),
 	}, nil
 }
 
+// getSubtaskPolicy converts the proto subtask policy to the model one
+func getSubtaskPolicy(policy api.SubtaskPolicy) models.SubtaskPolicy {
+	switch policy {
+	case api.SubtaskPolicy_SUBTASKS_DELETE:
+		return models.DeleteSubtasks
+	case api.SubtaskPolicy_SUBTASKS_DETACH:
+		return models.DetachSubtasks
+	default:
+		return models.RejectSubtasks
+	}
+}
+
+// getTaskFilters converts the proto filters to the model ones
+func getTaskFilters(req *api.GetTasksByFilterRequest) *models.TaskFilters {
+	return &models.TaskFilters{
+		ProjectId:    int(req.GetProjectId()),
+		Completed:    req.GetCompleted(),
+		UnCompleted:  req.GetUnCompleted(),
+		CreatedByMe:  req.GetCreatedByMe(),
+		AssignedToMe: req.GetAssignedToMe(),
+		AssigneeId:   req.GetAssigneeId(),
+		StatusId:     int(req.GetStatusId()),
+		PageSize:     int(req.GetPageSize()),
+		PageToken:    req.GetPageToken(),
+		AnyLabelIds:  toInts(req.GetAnyLabelIds()),
+		AllLabelIds:  toInts(req.GetAllLabelIds()),
+		MinPriority:  getPriority(req.MinPriority),
+		MaxPriority:  getPriority(req.MaxPriority),
+		SortBy:       getSortField(req.GetSortBy()),
+		SortDesc:     req.GetSortDesc(),
+	}
+}
+
+// getPriority converts an optional proto priority, nil if it is not set
+func getPriority(priority *api.TaskPriority) *models.Priority {
+	if priority == nil {
+		return nil
+	}
+
+	value := models.Priority(*priority)
+	return &value
+}
+
+func toInts(ids []int64) []int {
+	var value []int
+	for _, id := range ids {
+		value = append(value, int(id))
+	}
+	return value
+}
+
+// getSortField converts the proto sort field to the model one
+func getSortField(sortBy api.TaskSortField) models.TaskSortField {
+	switch sortBy {
+	case api.TaskSortField_TASK_SORT_DUE:
+		return models.SortByDue
+	case api.TaskSortField_TASK_SORT_TITLE:
+		return models.SortByTitle
+	case api.TaskSortField_TASK_SORT_STATUS:
+		return models.SortByStatus
+	case api.TaskSortField_TASK_SORT_PRIORITY:
+		return models.SortByPriority
+	default:
+		return models.SortById
+	}
+}
+
 func (s *serverApi) AssignTask(ctx context.Context, req *api.AssignTaskRequest) (*api.AssignTaskResponse, error) {
 	description := req.GetDescription()
 	userId := req.GetUserId()
@@ -212,6 +540,9 @@ func (s *serverApi) AssignTask(ctx context.Context, req *api.AssignTaskRequest)
 		if errors.Is(appErrors.TaskAlreadyAssigned, err) {
 			return nil, status.Error(codes.AlreadyExists, err.Error())
 		}
+		if errors.Is(appErrors.ErrNotProjectMember, err) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
 		if errors.Is(appErrors.ErrNoPermission, err) {
 			return nil, status.Error(codes.PermissionDenied, err.Error())
 		}
@@ -252,9 +583,13 @@ func (s *serverApi) UnAssignTask(ctx context.Context, req *api.UnAssignTaskReque
 }
 
 func (s *serverApi) GetAllStatuses(ctx context.Context, req *api.GetAllStatusesRequest) (*api.GetAllStatusesResponse, error) {
-	statuses, err := s.taskService.GetAllStatuses(ctx)
+	currentUser := s.authService.GetUserFromCTX(ctx)
+	statuses, err := s.taskService.GetAllStatuses(ctx, int(req.GetProjectId()), currentUser)
 
 	if err != nil {
+		if errors.Is(appErrors.ErrNoPermission, err) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 		return nil, status.Error(codes.Internal, appErrors.Internal.Error())
 	}
 
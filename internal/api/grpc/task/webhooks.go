@@ -0,0 +1,60 @@
+package taskServer
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	appErrors "sso_3.0/internal/errors"
+	protoWebhook "sso_3.0/internal/utilities/getProto/webhook"
+	api "sso_3.0/proto/gen"
+)
+
+func (s *serverApi) CreateWebhook(ctx context.Context, req *api.CreateWebhookRequest) (*api.CreateWebhookResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	webhook, secret, err := s.taskService.CreateWebhook(ctx, int(req.GetProjectId()), req.GetUrl(), req.GetEventTypes(), currentUser)
+	if err != nil {
+		return nil, getWebhookError(err)
+	}
+
+	return &api.CreateWebhookResponse{Webhook: protoWebhook.GetWebhook(webhook), Secret: secret}, nil
+}
+
+func (s *serverApi) ListWebhooks(ctx context.Context, req *api.ListWebhooksRequest) (*api.ListWebhooksResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	webhooks, err := s.taskService.ListWebhooks(ctx, int(req.GetProjectId()), currentUser)
+	if err != nil {
+		return nil, getWebhookError(err)
+	}
+
+	return &api.ListWebhooksResponse{Webhooks: protoWebhook.GetWebhooks(webhooks)}, nil
+}
+
+func (s *serverApi) DeleteWebhook(ctx context.Context, req *api.DeleteWebhookRequest) (*api.DeleteWebhookResponse, error) {
+	currentUser := s.authService.GetUserFromCTX(ctx)
+
+	if err := s.taskService.DeleteWebhook(ctx, int(req.GetWebhookId()), currentUser); err != nil {
+		return nil, getWebhookError(err)
+	}
+
+	return &api.DeleteWebhookResponse{Status: "Success"}, nil
+}
+
+// getWebhookError converts the errors of the webhook methods to grpc errors
+func getWebhookError(err error) error {
+	if errors.Is(appErrors.ErrWebhookNotExists, err) || errors.Is(appErrors.ErrProjectNotExists, err) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrInvalidWebhookURL, err) || errors.Is(appErrors.ErrInvalidWebhookEvent, err) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if errors.Is(appErrors.ErrNoPermission, err) || errors.Is(appErrors.ErrNotProjectMember, err) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return status.Error(codes.Internal, appErrors.Internal.Error())
+}
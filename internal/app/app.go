@@ -1,16 +1,44 @@
 package app
 
 import (
+	"context"
 	"log/slog"
+	"sso_3.0/internal/app/gateway"
 	"sso_3.0/internal/app/grpc"
+	"sso_3.0/internal/app/outbox"
+	"sso_3.0/internal/app/purger"
+	"sso_3.0/internal/app/recurrence"
+	"sso_3.0/internal/app/reminder"
+	"sso_3.0/internal/app/rotation"
+	"sso_3.0/internal/app/webhook"
 	configParser "sso_3.0/internal/config"
+	"sso_3.0/internal/pkg/eventbus"
+	"sso_3.0/internal/pkg/notifier"
+	"sso_3.0/internal/pkg/sink"
 	"sso_3.0/internal/services/auth"
+	"sso_3.0/internal/services/keys"
 	"sso_3.0/internal/services/tasks"
 	"sso_3.0/internal/storage/postgres"
+	"time"
+)
+
+const (
+	// eventsBuffer is the count of task events a watcher can fall behind before it is dropped
+	eventsBuffer = 64
+	// shutdownTimeout is how long each server waits for the running requests on shutdown
+	shutdownTimeout = 15 * time.Second
 )
 
 type App struct {
 	GrpcServer *grpc.App
+	Gateway    *gateway.App
+	// Purgers delete the rows which are not needed anymore, each in its own loop
+	Purgers    []*purger.App
+	Rotation   *rotation.App
+	Recurrence *recurrence.App
+	Reminder   *reminder.App
+	Webhook    *webhook.App
+	Outbox     *outbox.App
 }
 
 // New It creates new object of App
@@ -22,9 +50,40 @@ func New(cfg *configParser.Config, log *slog.Logger) (*App, error) {
 		return nil, err
 	}
 
+	userNotifier, err := notifier.New(&notifier.Options{
+		Kind:         cfg.Notifier,
+		File:         cfg.NotifierFile,
+		WebhookURL:   cfg.NotifierWebhookURL,
+		SMTPAddr:     cfg.SMTPAddr,
+		SMTPFrom:     cfg.SMTPFrom,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+	}, log)
+
+	if err != nil {
+		return nil, err
+	}
+
+	eventSinks, err := sink.New(cfg.OutboxSinks, log)
+
+	if err != nil {
+		return nil, err
+	}
+
 	//crate services
-	taskService := tasks.New(log, storage)
-	authService := authService.New(log, storage)
+	taskService := tasks.New(log, storage, eventbus.New(eventsBuffer), userNotifier, eventSinks, cfg)
+	keyService := keys.New(log, storage, cfg)
+
+	// the first replica creates the first signing key
+	if _, err = keyService.Rotate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	authService := authService.New(log, storage, userNotifier, keyService, cfg)
+
+	if err = authService.GrantConfiguredAdmins(context.Background()); err != nil {
+		return nil, err
+	}
 
 	grpcServer, err := grpc.New(log, cfg, authService, taskService)
 
@@ -32,7 +91,80 @@ func New(cfg *configParser.Config, log *slog.Logger) (*App, error) {
 		return nil, err
 	}
 
+	gatewayServer, err := gateway.New(log, cfg)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return &App{
-		grpcServer,
+		GrpcServer: grpcServer,
+		Gateway:    gatewayServer,
+		Purgers: []*purger.App{
+			purger.New(log, "deleted tasks", cfg.PurgeInterval, func(ctx context.Context) (int, error) {
+				return taskService.PurgeDeletedTasks(ctx, cfg.TrashRetention)
+			}),
+			purger.New(log, "expired sessions", cfg.PurgeInterval, authService.PurgeExpiredSessions),
+			purger.New(log, "expired password resets", cfg.PurgeInterval, authService.PurgeExpiredPasswordResets),
+			purger.New(log, "expired verification codes", cfg.PurgeInterval, authService.PurgeExpiredVerifications),
+			purger.New(log, "old failed logins", cfg.PurgeInterval, authService.PurgeFailedLogins),
+			purger.New(log, "expired login challenges", cfg.PurgeInterval, authService.PurgeExpiredChallenges),
+			purger.New(log, "expired invites", cfg.PurgeInterval, taskService.PurgeExpiredInvites),
+			purger.New(log, "webhook deliveries", cfg.PurgeInterval, func(ctx context.Context) (int, error) {
+				return taskService.PurgeWebhookDeliveries(ctx, cfg.WebhookRetention)
+			}),
+			purger.New(log, "published events", cfg.PurgeInterval, func(ctx context.Context) (int, error) {
+				return taskService.PurgeEvents(ctx, cfg.OutboxRetention)
+			}),
+		},
+		Rotation:   rotation.New(log, cfg, keyService),
+		Recurrence: recurrence.New(log, cfg, taskService),
+		Reminder:   reminder.New(log, cfg, taskService),
+		Webhook:    webhook.New(log, cfg, taskService),
+		Outbox:     outbox.New(log, cfg, taskService),
 	}, nil
 }
+
+// RunBackground starts the background apps, they run until the ctx is cancelled or Stop is called
+func (a *App) RunBackground(ctx context.Context) {
+	//purge the trash and the other rows which are not needed anymore in the background
+	for _, p := range a.Purgers {
+		go p.Run(ctx)
+	}
+
+	//rotate the signing key in the background
+	go a.Rotation.Run(ctx)
+
+	//create the next occurrences of the recurring tasks in the background
+	go a.Recurrence.Run(ctx)
+
+	//send the reminders of the tasks which are due soon or overdue in the background
+	go a.Reminder.Run(ctx)
+
+	//send the webhook deliveries in the background
+	go a.Webhook.Run(ctx)
+
+	//publish the domain events of the outbox in the background
+	go a.Outbox.Run(ctx)
+}
+
+// Stop stops the gateway first, as it sends its requests to the grpc server, then the grpc server and the background apps
+// a background app finishes its current run with a cancelled context, so claimed reminders and deliveries are released
+func (a *App) Stop() {
+	gatewayCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	a.Gateway.Stop(gatewayCtx)
+
+	grpcCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	a.GrpcServer.Stop(grpcCtx)
+
+	for _, p := range a.Purgers {
+		p.Stop()
+	}
+	a.Rotation.Stop()
+	a.Recurrence.Stop()
+	a.Reminder.Stop()
+	a.Webhook.Stop()
+	a.Outbox.Stop()
+}
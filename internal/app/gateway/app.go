@@ -0,0 +1,84 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"log/slog"
+	"net/http"
+	gatewayServer "sso_3.0/internal/api/gateway"
+	configParser "sso_3.0/internal/config"
+	"strconv"
+	"time"
+)
+
+// readHeaderTimeout is how long a client can take to send the headers of a request
+const readHeaderTimeout = 10 * time.Second
+
+// App serves the grpc api as json over http, the requests are sent to the grpc server of the same process
+type App struct {
+	port       int
+	httpServer *http.Server
+	log        *slog.Logger
+}
+
+func New(logger *slog.Logger, cfg *configParser.Config) (*App, error) {
+	const op = "app.gateway.New"
+	log := logger.With("op", op)
+
+	port, err := strconv.Atoi(cfg.HttpPort)
+	if err != nil {
+		return nil, err
+	}
+
+	// the connection is opened on the first request, the grpc server does not have to run yet
+	conn, err := grpc.Dial(fmt.Sprintf("localhost:%s", cfg.GrpcPort), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	handler, err := gatewayServer.New(conn, log)
+	if err != nil {
+		return nil, err
+	}
+
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	return &App{port: port, httpServer: httpServer, log: log}, nil
+}
+
+// run starts the http server, it returns on errors and after Stop
+func (a *App) run() error {
+	op := "gateway.app.RUN"
+	log := a.log.With("op", op)
+
+	log.Info("Starting HTTP gateway", "port", a.port)
+
+	if err := a.httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// MustRun Runs the gateway, if there is an errors it panics
+func (a *App) MustRun() {
+	if err := a.run(); err != nil {
+		panic(err)
+	}
+}
+
+// Stop stops accepting requests and waits for the running ones until the ctx is done, then it closes the connections
+// the streams of WatchTasks do not end on their own, so they are closed
+func (a *App) Stop(ctx context.Context) {
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		a.log.Warn("Closing the open requests of the HTTP gateway", "errors", err)
+		a.httpServer.Close()
+	}
+}
@@ -1,6 +1,7 @@
 package grpc
 
 import (
+	"context"
 	"fmt"
 	"google.golang.org/grpc"
 	"log/slog"
@@ -22,7 +23,10 @@ type App struct {
 func New(logger *slog.Logger, cfg *configParser.Config, authService *authService.Service, taskService *tasks.Service) (*App, error) {
 	const op = "app.grpc.New"
 	log := logger.With("op", op)
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(authService.AuthInterceptor))
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(authService.AuthInterceptor, authService.PermissionInterceptor),
+		grpc.ChainStreamInterceptor(authService.AuthStreamInterceptor, authService.PermissionStreamInterceptor),
+	)
 	authServer.RegisterServer(grpcServer, authService, log)
 	taskServer.RegisterServer(grpcServer, authService, taskService, log)
 
@@ -48,14 +52,10 @@ func (s *App) run() error {
 		return err
 	}
 
-	//register grpc server with tcp listener
-	err = s.grpcServer.Serve(l)
-	if err != nil {
-		return err
-	}
-
 	log.Info("Successfully Started GRPC api", "port", s.port)
-	return nil
+
+	//register grpc server with tcp listener, it returns nil after Stop
+	return s.grpcServer.Serve(l)
 }
 
 // MustRun Runs the application, if there is an errors it panics
@@ -64,3 +64,19 @@ func (app *App) MustRun() {
 		panic(err)
 	}
 }
+
+// Stop stops accepting requests and waits for the running ones until the ctx is done, then it closes the connections
+func (app *App) Stop(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		app.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		app.log.Warn("Closing the open requests of the GRPC api", "errors", ctx.Err())
+		app.grpcServer.Stop()
+	}
+}
@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	configParser "sso_3.0/internal/config"
+	"sso_3.0/internal/pkg/ticker"
+	"sso_3.0/internal/services/tasks"
+	"time"
+)
+
+// minTimeout is the shortest time a run can take, a short interval must not cut the publishing of a batch
+const minTimeout = 30 * time.Second
+
+// App is the relay of the outbox, it publishes the domain events to the webhooks and the sinks
+// several replicas can run it, only one of them publishes at a time so the events stay in order
+type App struct {
+	*ticker.Loop
+	log         *slog.Logger
+	taskService *tasks.Service
+	interval    time.Duration
+}
+
+func New(log *slog.Logger, cfg *configParser.Config, taskService *tasks.Service) *App {
+	a := &App{
+		log:         log.With("op", "app.outbox"),
+		taskService: taskService,
+		interval:    cfg.OutboxInterval,
+	}
+	a.Loop = ticker.New(a.interval, max(a.interval, minTimeout), a.relay)
+
+	return a
+}
+
+func (a *App) relay(ctx context.Context) {
+	published, err := a.taskService.RelayEvents(ctx)
+	if err != nil {
+		a.log.Error("Error on publishing the events", "errors", err)
+	}
+
+	if published > 0 {
+		a.log.Debug("Published events", "count", published)
+	}
+}
@@ -0,0 +1,41 @@
+package purger
+
+import (
+	"context"
+	"log/slog"
+	"sso_3.0/internal/pkg/ticker"
+	"time"
+)
+
+// Purge deletes the rows which are not needed anymore and returns their count
+type Purge func(ctx context.Context) (int, error)
+
+// App runs one purge every interval, every kind of rows has its own App
+// so a slow purge like the one of the trash does not hold up the others
+type App struct {
+	*ticker.Loop
+	log   *slog.Logger
+	purge Purge
+}
+
+// New creates the purger of the rows named by what, like "deleted tasks"
+func New(log *slog.Logger, what string, interval time.Duration, purge Purge) *App {
+	a := &App{
+		log:   log.With("op", "app.purger", "what", what),
+		purge: purge,
+	}
+	a.Loop = ticker.New(interval, interval, a.run)
+
+	return a
+}
+
+func (a *App) run(ctx context.Context) {
+	purged, err := a.purge(ctx)
+	if err != nil {
+		a.log.Error("Error on purging", "errors", err)
+	}
+
+	if purged > 0 {
+		a.log.Info("Purged", "count", purged)
+	}
+}
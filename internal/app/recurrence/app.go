@@ -0,0 +1,41 @@
+package recurrence
+
+import (
+	"context"
+	"log/slog"
+	configParser "sso_3.0/internal/config"
+	"sso_3.0/internal/pkg/ticker"
+	"sso_3.0/internal/services/tasks"
+	"time"
+)
+
+// App creates the next occurrences of the recurring tasks which are due or completed
+// several replicas can run it at the same time, every occurrence is created only once
+type App struct {
+	*ticker.Loop
+	log         *slog.Logger
+	taskService *tasks.Service
+	interval    time.Duration
+}
+
+func New(log *slog.Logger, cfg *configParser.Config, taskService *tasks.Service) *App {
+	a := &App{
+		log:         log.With("op", "app.recurrence"),
+		taskService: taskService,
+		interval:    cfg.RecurrenceInterval,
+	}
+	a.Loop = ticker.New(a.interval, a.interval, a.recur)
+
+	return a
+}
+
+func (a *App) recur(ctx context.Context) {
+	created, err := a.taskService.RecurTasks(ctx)
+	if err != nil {
+		a.log.Error("Error on creating the next occurrences", "errors", err)
+	}
+
+	if created > 0 {
+		a.log.Info("Created next occurrences", "count", created)
+	}
+}
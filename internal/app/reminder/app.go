@@ -0,0 +1,41 @@
+package reminder
+
+import (
+	"context"
+	"log/slog"
+	configParser "sso_3.0/internal/config"
+	"sso_3.0/internal/pkg/ticker"
+	"sso_3.0/internal/services/tasks"
+	"time"
+)
+
+// App sends the reminders of the tasks which are due soon and the notifications of the overdue ones
+// several replicas can run it at the same time, every reminder is sent only once
+type App struct {
+	*ticker.Loop
+	log         *slog.Logger
+	taskService *tasks.Service
+	interval    time.Duration
+}
+
+func New(log *slog.Logger, cfg *configParser.Config, taskService *tasks.Service) *App {
+	a := &App{
+		log:         log.With("op", "app.reminder"),
+		taskService: taskService,
+		interval:    cfg.ReminderInterval,
+	}
+	a.Loop = ticker.New(a.interval, a.interval, a.remind)
+
+	return a
+}
+
+func (a *App) remind(ctx context.Context) {
+	sent, err := a.taskService.SendReminders(ctx)
+	if err != nil {
+		a.log.Error("Error on sending reminders", "errors", err)
+	}
+
+	if sent > 0 {
+		a.log.Info("Sent reminders", "count", sent)
+	}
+}
@@ -0,0 +1,36 @@
+package rotation
+
+import (
+	"context"
+	"log/slog"
+	configParser "sso_3.0/internal/config"
+	"sso_3.0/internal/pkg/ticker"
+	"sso_3.0/internal/services/keys"
+	"time"
+)
+
+// App checks the current signing key every interval and creates a new one when it is older than the rotation
+// several replicas can run it at the same time, only one of them creates the new key
+type App struct {
+	*ticker.Loop
+	log        *slog.Logger
+	keyService *keys.Service
+	interval   time.Duration
+}
+
+func New(log *slog.Logger, cfg *configParser.Config, keyService *keys.Service) *App {
+	a := &App{
+		log:        log.With("op", "app.rotation"),
+		keyService: keyService,
+		interval:   cfg.SigningKeyInterval,
+	}
+	a.Loop = ticker.New(a.interval, a.interval, a.rotate)
+
+	return a
+}
+
+func (a *App) rotate(ctx context.Context) {
+	if _, err := a.keyService.Rotate(ctx); err != nil {
+		a.log.Error("Error on rotating the signing key", "errors", err)
+	}
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+	configParser "sso_3.0/internal/config"
+	"sso_3.0/internal/pkg/ticker"
+	"sso_3.0/internal/services/tasks"
+	"time"
+)
+
+// minTimeout is the shortest time a run can take, a slow webhook can take the whole timeout of the sender
+const minTimeout = time.Minute
+
+// App sends the pending webhook deliveries and retries the failed ones
+// several replicas can run it at the same time, a delivery is claimed by one of them
+type App struct {
+	*ticker.Loop
+	log         *slog.Logger
+	taskService *tasks.Service
+	interval    time.Duration
+}
+
+func New(log *slog.Logger, cfg *configParser.Config, taskService *tasks.Service) *App {
+	a := &App{
+		log:         log.With("op", "app.webhook"),
+		taskService: taskService,
+		interval:    cfg.WebhookInterval,
+	}
+	a.Loop = ticker.New(a.interval, max(a.interval, minTimeout), a.deliver)
+
+	return a
+}
+
+func (a *App) deliver(ctx context.Context) {
+	delivered, err := a.taskService.DeliverWebhooks(ctx)
+	if err != nil {
+		a.log.Error("Error on sending the webhook deliveries", "errors", err)
+	}
+
+	if delivered > 0 {
+		a.log.Info("Sent webhook deliveries", "count", delivered)
+	}
+}
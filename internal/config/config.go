@@ -4,14 +4,119 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// defaults of the optional envs
+const (
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultPurgeInterval      = time.Hour
+	defaultAccessTokenTTL     = 15 * time.Minute
+	defaultRefreshTokenTTL    = 30 * 24 * time.Hour
+	defaultPasswordResetTTL   = time.Hour
+	defaultNotifier           = "log"
+	defaultNotifierFile       = "notifications.log"
+	defaultVerificationTTL    = 24 * time.Hour
+	defaultLoginMaxAttempts   = 5
+	defaultLoginMaxIpAttempts = 20
+	defaultLoginLockout       = time.Minute
+	defaultLoginMaxLockout    = time.Hour
+	defaultLoginChallengeTTL  = 5 * time.Minute
+	defaultSigningKeyRotation = 30 * 24 * time.Hour
+	defaultSigningKeyInterval = time.Hour
+	defaultTotpIssuer         = "tasks"
+	defaultProjectInviteTTL   = 7 * 24 * time.Hour
+	defaultRecurrenceInterval = time.Minute
+	defaultReminderInterval   = time.Minute
+	defaultWebhookInterval    = 10 * time.Second
+	defaultWebhookMaxAttempts = 10
+	defaultWebhookRetention   = 30 * 24 * time.Hour
+	defaultOutboxInterval     = time.Second
+	defaultOutboxRetention    = 7 * 24 * time.Hour
+	defaultOutboxMaxAttempts  = 10
+	defaultHttpPort           = "8080"
+)
+
+// defaultReminderLeadTimes remind a day and an hour before the due
+var defaultReminderLeadTimes = []time.Duration{24 * time.Hour, time.Hour}
+
 type Config struct {
-	Env         string
-	DbUrl       string
-	GrpcPort    string
-	TokenSecret string
+	Env      string
+	DbUrl    string
+	GrpcPort string
+	// HttpPort is the port of the json gateway of the grpc api
+	HttpPort string
+	// TrashRetention is how long deleted tasks stay in the trash before they are purged
+	TrashRetention time.Duration
+	// PurgeInterval is how often the trash is checked for tasks to purge
+	PurgeInterval time.Duration
+	// AccessTokenTTL is how long a jwt is valid, it is renewed with the refresh token
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is how long a refresh token can be used
+	RefreshTokenTTL time.Duration
+	// PasswordResetTTL is how long a password reset token can be used
+	PasswordResetTTL time.Duration
+	// Notifier is the kind of notifier delivering messages to the users: log, file, webhook or smtp
+	Notifier string
+	// NotifierFile is the file the file notifier writes to
+	NotifierFile string
+	// NotifierWebhookURL is the url the webhook notifier posts the messages to
+	NotifierWebhookURL string
+	// SMTPAddr is the host:port of the smtp server of the smtp notifier
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPUsername string
+	SMTPPassword string
+	// VerificationTTL is how long an email verification code can be used
+	VerificationTTL time.Duration
+	// RequireVerifiedEmail blocks users with unverified email from the task api
+	RequireVerifiedEmail bool
+	// LoginMaxAttempts is the count of failed logins of an email before it is locked
+	LoginMaxAttempts int
+	// LoginMaxIpAttempts is the count of failed logins from an ip before it is locked
+	LoginMaxIpAttempts int
+	// TrustedProxies are the networks of the proxies in front of the grpc server, like the loopback of the gateway
+	// the x-forwarded-for of their requests is used for the ip of the client
+	TrustedProxies []*net.IPNet
+	// LoginLockout is the first lockout, it doubles with every further failed login up to LoginMaxLockout
+	LoginLockout    time.Duration
+	LoginMaxLockout time.Duration
+	// AdminEmails are the users which get the admin role on start and when they verify their email
+	AdminEmails []string
+	// LoginChallengeTTL is how long the login of users with two factor auth waits for the code
+	LoginChallengeTTL time.Duration
+	// TotpIssuer is the name of the app shown by the authenticator apps
+	TotpIssuer string
+	// SigningKeyRotation is how long a key signs the tokens before a new key replaces it
+	SigningKeyRotation time.Duration
+	// SigningKeyInterval is how often the current signing key is checked for the rotation
+	SigningKeyInterval time.Duration
+	// ProjectInviteTTL is how long an invite to a project can be accepted
+	ProjectInviteTTL time.Duration
+	// RecurrenceInterval is how often the recurring tasks which are due are checked for their next occurrence
+	RecurrenceInterval time.Duration
+	// ReminderInterval is how often the tasks are checked for reminders to send
+	ReminderInterval time.Duration
+	// ReminderLeadTimes are how long before the due the users are reminded, if they did not set their own
+	ReminderLeadTimes []time.Duration
+	// WebhookInterval is how often the pending webhook deliveries are sent
+	WebhookInterval time.Duration
+	// WebhookMaxAttempts is how often a delivery is attempted before it failed
+	WebhookMaxAttempts int
+	// WebhookRetention is how long the sent and failed deliveries are kept with their attempts
+	WebhookRetention time.Duration
+	// OutboxSinks are the kinds of the sinks the domain events are published to, only log so far
+	OutboxSinks []string
+	// OutboxInterval is how often the relay publishes the new domain events
+	OutboxInterval time.Duration
+	// OutboxRetention is how long the published and the failed domain events are kept
+	OutboxRetention time.Duration
+	// OutboxMaxAttempts is how often an event is published before it failed
+	OutboxMaxAttempts int
 }
 
 func MustGetConfig() *Config {
@@ -21,13 +126,83 @@ func MustGetConfig() *Config {
 	dbUrl := getEnv("DB_URL")
 	env := getEnv("ENV")
 	grpcPort := getEnv("GRPC_PORT")
-	tokenSecret := getEnv("GRPC_PORT")
+	httpPort := getEnvDefault("HTTP_PORT", defaultHttpPort)
+	trashRetention := getEnvDuration("TRASH_RETENTION", defaultTrashRetention)
+	purgeInterval := getEnvDuration("PURGE_INTERVAL", defaultPurgeInterval)
+	accessTokenTTL := getEnvDuration("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+	refreshTokenTTL := getEnvDuration("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+	passwordResetTTL := getEnvDuration("PASSWORD_RESET_TTL", defaultPasswordResetTTL)
+	notifier := getEnvDefault("NOTIFIER", defaultNotifier)
+	notifierFile := getEnvDefault("NOTIFIER_FILE", defaultNotifierFile)
+	notifierWebhookURL := getEnvDefault("NOTIFIER_WEBHOOK_URL", "")
+	smtpAddr := getEnvDefault("SMTP_ADDR", "")
+	smtpFrom := getEnvDefault("SMTP_FROM", "")
+	smtpUsername := getEnvDefault("SMTP_USERNAME", "")
+	smtpPassword := getEnvDefault("SMTP_PASSWORD", "")
+	verificationTTL := getEnvDuration("VERIFICATION_TTL", defaultVerificationTTL)
+	requireVerifiedEmail := getEnvBool("REQUIRE_VERIFIED_EMAIL", false)
+	loginMaxAttempts := getEnvInt("LOGIN_MAX_ATTEMPTS", defaultLoginMaxAttempts)
+	loginMaxIpAttempts := getEnvInt("LOGIN_MAX_IP_ATTEMPTS", defaultLoginMaxIpAttempts)
+	loginLockout := getEnvDuration("LOGIN_LOCKOUT", defaultLoginLockout)
+	loginMaxLockout := getEnvDuration("LOGIN_MAX_LOCKOUT", defaultLoginMaxLockout)
+	trustedProxies := getEnvNetworkList("TRUSTED_PROXIES")
+	adminEmails := getEnvList("ADMIN_EMAILS")
+	loginChallengeTTL := getEnvDuration("LOGIN_CHALLENGE_TTL", defaultLoginChallengeTTL)
+	totpIssuer := getEnvDefault("TOTP_ISSUER", defaultTotpIssuer)
+	signingKeyRotation := getEnvDuration("SIGNING_KEY_ROTATION", defaultSigningKeyRotation)
+	signingKeyInterval := getEnvDuration("SIGNING_KEY_INTERVAL", defaultSigningKeyInterval)
+	projectInviteTTL := getEnvDuration("PROJECT_INVITE_TTL", defaultProjectInviteTTL)
+	recurrenceInterval := getEnvDuration("RECURRENCE_INTERVAL", defaultRecurrenceInterval)
+	reminderInterval := getEnvDuration("REMINDER_INTERVAL", defaultReminderInterval)
+	reminderLeadTimes := getEnvDurationList("REMINDER_LEAD_TIMES", defaultReminderLeadTimes)
+	webhookInterval := getEnvDuration("WEBHOOK_INTERVAL", defaultWebhookInterval)
+	webhookMaxAttempts := getEnvInt("WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts)
+	webhookRetention := getEnvDuration("WEBHOOK_RETENTION", defaultWebhookRetention)
+	outboxSinks := getEnvList("OUTBOX_SINKS")
+	outboxInterval := getEnvDuration("OUTBOX_INTERVAL", defaultOutboxInterval)
+	outboxRetention := getEnvDuration("OUTBOX_RETENTION", defaultOutboxRetention)
+	outboxMaxAttempts := getEnvInt("OUTBOX_MAX_ATTEMPTS", defaultOutboxMaxAttempts)
 
 	return &Config{
-		env,
-		dbUrl,
-		grpcPort,
-		tokenSecret,
+		Env:                  env,
+		DbUrl:                dbUrl,
+		GrpcPort:             grpcPort,
+		HttpPort:             httpPort,
+		TrashRetention:       trashRetention,
+		PurgeInterval:        purgeInterval,
+		AccessTokenTTL:       accessTokenTTL,
+		RefreshTokenTTL:      refreshTokenTTL,
+		PasswordResetTTL:     passwordResetTTL,
+		Notifier:             notifier,
+		NotifierFile:         notifierFile,
+		NotifierWebhookURL:   notifierWebhookURL,
+		SMTPAddr:             smtpAddr,
+		SMTPFrom:             smtpFrom,
+		SMTPUsername:         smtpUsername,
+		SMTPPassword:         smtpPassword,
+		VerificationTTL:      verificationTTL,
+		RequireVerifiedEmail: requireVerifiedEmail,
+		LoginMaxAttempts:     loginMaxAttempts,
+		LoginMaxIpAttempts:   loginMaxIpAttempts,
+		LoginLockout:         loginLockout,
+		LoginMaxLockout:      loginMaxLockout,
+		TrustedProxies:       trustedProxies,
+		AdminEmails:          adminEmails,
+		LoginChallengeTTL:    loginChallengeTTL,
+		TotpIssuer:           totpIssuer,
+		SigningKeyRotation:   signingKeyRotation,
+		SigningKeyInterval:   signingKeyInterval,
+		ProjectInviteTTL:     projectInviteTTL,
+		RecurrenceInterval:   recurrenceInterval,
+		ReminderInterval:     reminderInterval,
+		ReminderLeadTimes:    reminderLeadTimes,
+		WebhookInterval:      webhookInterval,
+		WebhookMaxAttempts:   webhookMaxAttempts,
+		WebhookRetention:     webhookRetention,
+		OutboxSinks:          outboxSinks,
+		OutboxInterval:       outboxInterval,
+		OutboxRetention:      outboxRetention,
+		OutboxMaxAttempts:    outboxMaxAttempts,
 	}
 
 }
@@ -40,3 +215,115 @@ func getEnv(key string) string {
 
 	return env
 }
+
+// getEnvDefault gets an optional env, if it is not set the fallback is used
+func getEnvDefault(key, fallback string) string {
+	env := os.Getenv(key)
+	if env == "" {
+		return fallback
+	}
+
+	return env
+}
+
+// getEnvBool parses an optional env like true or false, if it is not set the fallback is used
+func getEnvBool(key string, fallback bool) bool {
+	env := os.Getenv(key)
+	if env == "" {
+		return fallback
+	}
+
+	value, err := strconv.ParseBool(env)
+	if err != nil {
+		panic(fmt.Sprintf("the env %s is not a valid bool", key))
+	}
+
+	return value
+}
+
+// getEnvInt parses an optional positive number, if it is not set the fallback is used
+func getEnvInt(key string, fallback int) int {
+	env := os.Getenv(key)
+	if env == "" {
+		return fallback
+	}
+
+	value, err := strconv.Atoi(env)
+	if err != nil || value <= 0 {
+		panic(fmt.Sprintf("the env %s is not a valid positive number", key))
+	}
+
+	return value
+}
+
+// getEnvList splits an optional comma separated env, empty items are skipped
+func getEnvList(key string) []string {
+	var list []string
+
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// getEnvNetworkList parses an optional comma separated env of ips and networks like 127.0.0.1,10.0.0.0/8
+func getEnvNetworkList(key string) []*net.IPNet {
+	var networks []*net.IPNet
+
+	for _, item := range getEnvList(key) {
+		if ip := net.ParseIP(item); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			panic(fmt.Sprintf("the env %s is not a valid list of ips and networks", key))
+		}
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+// getEnvDurationList parses an optional comma separated env like 24h,1h, if it is not set the fallback is used
+func getEnvDurationList(key string, fallback []time.Duration) []time.Duration {
+	var durations []time.Duration
+
+	items := getEnvList(key)
+	if len(items) == 0 {
+		return fallback
+	}
+
+	for _, item := range items {
+		duration, err := time.ParseDuration(item)
+		if err != nil || duration <= 0 {
+			panic(fmt.Sprintf("the env %s is not a valid list of durations", key))
+		}
+		durations = append(durations, duration)
+	}
+
+	return durations
+}
+
+// getEnvDuration parses an optional env like 720h, if it is not set the fallback is used
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	env := os.Getenv(key)
+	if env == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(env)
+	if err != nil || duration <= 0 {
+		panic(fmt.Sprintf("the env %s is not a valid duration", key))
+	}
+
+	return duration
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 	"sso_3.0/internal/domain/user"
 	"time"
@@ -15,12 +16,205 @@ type Task struct {
 	CreatorId   string
 	Status      *Status
 	Assignees   []*Assignee
+	// ParentId is 0 for top level tasks
+	ParentId int
+	Labels   []*Label
+	Priority Priority
+	// DeletedAt is the time the task was moved to the trash, zero for active tasks
+	DeletedAt time.Time
+	ProjectId int
+	// Recurrence is the RRULE of a recurring task, empty for tasks which do not repeat
+	Recurrence string
+	// RecurrenceStart is the due of the first occurrence, the rule is counted from there
+	RecurrenceStart time.Time
+	// SeriesId is the id of the first occurrence of a recurring task, 0 for tasks which never repeated
+	SeriesId int
+}
+
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+// IsValid checks if the priority is one of the defined priorities
+func (p Priority) IsValid() bool {
+	return p >= PriorityNone && p <= PriorityUrgent
+}
+
+// Dependency means the blocker task has to be completed before the blocked task
+type Dependency struct {
+	BlockerId int
+	BlockedId int
+}
+
+type DependencyGraph struct {
+	Tasks        []*Task
+	Dependencies []*Dependency
+}
+
+// SubtaskPolicy decides what happens to the subtasks of a deleted task
+type SubtaskPolicy int
+
+const (
+	// RejectSubtasks fails the deletion while the task has subtasks
+	RejectSubtasks SubtaskPolicy = iota
+	// DeleteSubtasks deletes all subtasks together with the task
+	DeleteSubtasks
+	// DetachSubtasks moves the subtasks to the parent of the deleted task
+	DetachSubtasks
+)
+
+type Comment struct {
+	Id        int
+	TaskId    int
+	Author    *user.Model
+	Body      string
+	Mentions  []*user.Model
+	CreatedAt time.Time
+	// UpdatedAt is zero for comments which were never edited
+	UpdatedAt time.Time
+}
+
+type Label struct {
+	Id    int
+	Name  string
+	Color string
 }
 
 type Status struct {
 	Id          int
 	Title       string
 	Description string
+	// ProjectId is 0 for the statuses shared by all projects
+	ProjectId int
+}
+
+// IsUsableIn checks if tasks of the project can have the status
+func (s *Status) IsUsableIn(projectId int) bool {
+	return s.ProjectId == 0 || s.ProjectId == projectId
+}
+
+// Project groups tasks, every task belongs to exactly one project
+type Project struct {
+	Id          int
+	Name        string
+	Description string
+	// CreatorId is empty for the default project
+	CreatorId string
+	CreatedAt time.Time
+}
+
+// ProjectRole is the role of a member in a project, every role can also do what the lower roles can
+type ProjectRole string
+
+const (
+	// ProjectViewer can read the tasks of the project and comment them
+	ProjectViewer ProjectRole = "viewer"
+	// ProjectEditor can also create and change tasks
+	ProjectEditor ProjectRole = "editor"
+	// ProjectOwner can also manage the project, its statuses and its members
+	ProjectOwner ProjectRole = "owner"
+)
+
+// IsValid checks if the role is one of the defined project roles
+func (r ProjectRole) IsValid() bool {
+	return r.rank() > 0
+}
+
+// Includes checks if the role can do what the other role can
+func (r ProjectRole) Includes(other ProjectRole) bool {
+	return r.IsValid() && r.rank() >= other.rank()
+}
+
+func (r ProjectRole) rank() int {
+	switch r {
+	case ProjectViewer:
+		return 1
+	case ProjectEditor:
+		return 2
+	case ProjectOwner:
+		return 3
+	default:
+		return 0
+	}
+}
+
+type ProjectMember struct {
+	ProjectId int
+	User      *user.Model
+	Role      ProjectRole
+	JoinedAt  time.Time
+}
+
+// ProjectInvite lets the user with the email join the project with the role until it expires
+type ProjectInvite struct {
+	// Token is only known when the invite is created, only its hash is stored
+	Token     string
+	ProjectId int
+	Email     string
+	Role      ProjectRole
+	ExpiresAt time.Time
+}
+
+// ReminderSettings are how long before the due of their tasks the user is reminded
+type ReminderSettings struct {
+	LeadTimes []time.Duration
+	// Overdue also notifies when the due passed
+	Overdue bool
+}
+
+// Reminder is a reminder of the task for the user, the LeadTime 0 is the overdue notification
+type Reminder struct {
+	TaskId   int
+	Title    string
+	Due      time.Time
+	LeadTime time.Duration
+	User     *user.Model
+}
+
+// Webhook posts the task events of the project to the url
+type Webhook struct {
+	Id        int
+	ProjectId int
+	URL       string
+	// EventTypes are the posted events like task.created
+	EventTypes []string
+	// CreatorId is empty if the creator was deleted
+	CreatorId string
+	CreatedAt time.Time
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookFailed deliveries ran out of attempts
+	WebhookFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an event claimed for the delivery to a webhook
+type WebhookDelivery struct {
+	Id        int64
+	WebhookId int
+	URL       string
+	Secret    string
+	EventType string
+	Payload   []byte
+	// Attempt is the number of this attempt, starting at 1
+	Attempt int
+}
+
+// WebhookAttempt is the result of a delivery attempt, StatusCode is 0 if the webhook did not answer
+type WebhookAttempt struct {
+	StatusCode int
+	Error      string
+	Duration   time.Duration
 }
 
 type Assignee struct {
@@ -30,10 +224,230 @@ type Assignee struct {
 	TaskId int
 }
 type TaskFilters struct {
+	// ProjectId 0 matches the tasks of all projects
+	ProjectId int
+	// MemberId limits the tasks to the projects of that member, empty for no limit
+	// it is not checked by Match, the watchers check the membership themselves
+	MemberId     string
 	AssignedToMe bool
 	CreatedByMe  bool
 	UnCompleted  bool
 	Completed    bool
 	AssigneeId   string
 	StatusId     int
+	// AnyLabelIds matches tasks with at least one of the labels
+	AnyLabelIds []int
+	// AllLabelIds matches tasks with all of the labels
+	AllLabelIds []int
+	// MinPriority and MaxPriority are the inclusive priority range, nil means no bound
+	MinPriority *Priority
+	MaxPriority *Priority
+	PageSize    int
+	PageToken   string
+	SortBy      TaskSortField
+	SortDesc    bool
+	// Deleted matches only the tasks in the trash instead of the active ones
+	Deleted bool
+}
+
+// TaskSortField is the field tasks are ordered by, ties are always broken by task id
+type TaskSortField int
+
+const (
+	SortById TaskSortField = iota
+	SortByDue
+	SortByTitle
+	SortByStatus
+	// SortByPriority sorts by priority, most urgent first, and then by due date
+	SortByPriority
+	// SortByRank is only used for full text search results
+	SortByRank
+	// SortByDeletedAt is only used for the trash
+	SortByDeletedAt
+)
+
+type TaskSearchResult struct {
+	Task                 *Task
+	Rank                 float32
+	TitleHighlight       string
+	DescriptionHighlight string
+}
+
+type TaskEventType string
+
+const (
+	TaskCreated    TaskEventType = "created"
+	TaskUpdated    TaskEventType = "updated"
+	TaskDeleted    TaskEventType = "deleted"
+	TaskAssigned   TaskEventType = "assigned"
+	TaskUnassigned TaskEventType = "unassigned"
+	TaskRestored   TaskEventType = "restored"
+	// TaskPurged is only recorded in the history, the task was deleted from the trash
+	TaskPurged TaskEventType = "purged"
+)
+
+// TaskEvent is published by the task service after a task was changed
+type TaskEvent struct {
+	Type TaskEventType
+	// Task is the task after the change, for deleted tasks it is the last state
+	Task *Task
+	// Previous is the task before the change, nil for created tasks
+	Previous *Task
+	ActorId  string
+	// UserId is the assigned or unassigned user
+	UserId string
+	At     time.Time
+}
+
+// DomainEventType is the aggregate of a domain event and what happened to it
+type DomainEventType string
+
+const (
+	EventTaskCreated    DomainEventType = "task.created"
+	EventTaskUpdated    DomainEventType = "task.updated"
+	EventTaskDeleted    DomainEventType = "task.deleted"
+	EventTaskRestored   DomainEventType = "task.restored"
+	EventTaskAssigned   DomainEventType = "task.assigned"
+	EventTaskUnassigned DomainEventType = "task.unassigned"
+	EventStatusCreated  DomainEventType = "status.created"
+	EventStatusUpdated  DomainEventType = "status.updated"
+	EventStatusDeleted  DomainEventType = "status.deleted"
+)
+
+// DomainEvent is written to the outbox in the transaction of the change and published by the relay
+type DomainEvent struct {
+	// Id grows in the order the events were written, a published event keeps its id
+	Id   int64
+	Type DomainEventType
+	// AggregateType is task or status, the events of an aggregate are published in order
+	AggregateType string
+	// AggregateId is the id of the task or the status
+	AggregateId int
+	// ProjectId is 0 for the shared statuses
+	ProjectId int
+	// ActorId is empty for the changes made by the server
+	ActorId string
+	// UserId is the assigned or unassigned user
+	UserId string
+	// Data is the json of the task or the status after the change, for deleted ones it is the last state
+	Data json.RawMessage
+	// Previous is the json before the change, nil for created ones
+	Previous   json.RawMessage
+	OccurredAt time.Time
+	// Attempts is the count of the failed publishes of the event
+	Attempts int
+}
+
+// TaskHistoryEntry is one change of a task stored in the task history
+// updates have one entry per changed field, values are empty if not set
+type TaskHistoryEntry struct {
+	Id       int
+	TaskId   int
+	ActorId  string
+	Type     TaskEventType
+	Field    string
+	OldValue string
+	NewValue string
+	At       time.Time
+}
+
+// fields of the task history entries
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldDue         = "due"
+	FieldCompleted   = "completed"
+	FieldStatus      = "status"
+	FieldPriority    = "priority"
+	FieldParent      = "parentId"
+	FieldAssignee    = "assignee"
+	FieldLabel       = "label"
+	FieldRecurrence  = "recurrence"
+)
+
+// Match checks if the task matches the filters for the given user
+// page and sort options are ignored
+func (f *TaskFilters) Match(task *Task, userId string) bool {
+	if task == nil {
+		return false
+	}
+
+	completed := task.Completed != nil && task.Completed.Value
+
+	if f.ProjectId != 0 && task.ProjectId != f.ProjectId {
+		return false
+	}
+
+	if f.CreatedByMe && task.CreatorId != userId {
+		return false
+	}
+
+	if f.Completed && !completed {
+		return false
+	}
+
+	if f.UnCompleted && completed {
+		return false
+	}
+
+	if f.AssigneeId != "" && !task.IsAssigned(f.AssigneeId) {
+		return false
+	}
+
+	if f.AssignedToMe && !task.IsAssigned(userId) {
+		return false
+	}
+
+	if f.StatusId != 0 && (task.Status == nil || task.Status.Id != f.StatusId) {
+		return false
+	}
+
+	if len(f.AnyLabelIds) > 0 {
+		found := false
+		for _, labelId := range f.AnyLabelIds {
+			if task.HasLabel(labelId) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, labelId := range f.AllLabelIds {
+		if !task.HasLabel(labelId) {
+			return false
+		}
+	}
+
+	if f.MinPriority != nil && task.Priority < *f.MinPriority {
+		return false
+	}
+
+	if f.MaxPriority != nil && task.Priority > *f.MaxPriority {
+		return false
+	}
+
+	return true
+}
+
+// IsAssigned checks if the task is assigned to the user
+func (t *Task) IsAssigned(userId string) bool {
+	for _, assignee := range t.Assignees {
+		if assignee.User != nil && assignee.User.Id == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLabel checks if the task has the label
+func (t *Task) HasLabel(labelId int) bool {
+	for _, label := range t.Labels {
+		if label.Id == labelId {
+			return true
+		}
+	}
+	return false
 }
@@ -1,7 +1,97 @@
 package user
 
+import "time"
+
 type Model struct {
 	Id    string
 	Email string
 	Hash  string
+	// Verified is true after the user confirmed the email with the verification code
+	Verified bool
+	Role     Role
+}
+
+// Role decides which methods the user can call
+type Role string
+
+const (
+	RoleMember Role = "member"
+	// RoleAdmin can also manage the shared statuses, the roles of the users and is an owner of all projects
+	RoleAdmin Role = "admin"
+)
+
+// IsValid checks if the role is one of the defined roles
+func (r Role) IsValid() bool {
+	return r == RoleMember || r == RoleAdmin
+}
+
+// Tokens are issued on login, the refresh token is used to get new tokens after the access token expired
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresAt is the expiry of the access token
+	ExpiresAt time.Time
+}
+
+// Challenge is issued by the login of users with two factor auth instead of the tokens
+type Challenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// Totp is the two factor auth of a user
+type Totp struct {
+	Secret string
+	// Enabled is false until the secret was confirmed with a code
+	Enabled bool
+	// LastStep is the time step of the last used code
+	LastStep int64
+	// Attempts is the count of wrong codes sent to confirm the secret
+	Attempts int
+}
+
+// MaxCodeAttempts is how often a wrong code can be sent for a login challenge or to confirm a two factor secret
+const MaxCodeAttempts = 5
+
+// TotpEnrollment is the new secret shown to the user once
+type TotpEnrollment struct {
+	Secret string
+	// URI is the otpauth uri for the qr code of the authenticator apps
+	URI string
+}
+
+// scopes of the api keys
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+)
+
+// IsValidScope checks if the scope is one of the defined scopes
+func IsValidScope(scope string) bool {
+	return scope == ScopeTasksRead || scope == ScopeTasksWrite
+}
+
+// ApiKey is a token for automation, it is used instead of the jwt with the scopes it was created with
+type ApiKey struct {
+	Id     int
+	UserId string
+	Name   string
+	// Prefix is the start of the key to tell the keys apart, the key itself is only shown once
+	Prefix    string
+	Scopes    []string
+	CreatedAt time.Time
+	// ExpiresAt is zero for keys which never expire
+	ExpiresAt time.Time
+	// LastUsedAt is zero for keys which were never used
+	LastUsedAt time.Time
+}
+
+// HasScope checks if the key was created with the scope
+func (k *ApiKey) HasScope(scope string) bool {
+	for _, keyScope := range k.Scopes {
+		if keyScope == scope {
+			return true
+		}
+	}
+	return false
 }
@@ -1,20 +1,95 @@
 package appErrors
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
-	ErrUserExists         = errors.New("user with that Email already exists")
-	ErrUserNotExists      = errors.New("user with that id do not exists")
-	ErrTaskNotExists      = errors.New("task with that id do not exists")
-	ErrInvalidCredentials = errors.New("invalid Credentials")
-	ErrPasswordIncorrect  = errors.New("password Is Incorrect")
-	InvalidToken          = errors.New("token is Incorrect")
-	NoTokenSent           = errors.New("token was not defined in metadata")
-	NothingToDelete       = errors.New("nothing to delete")
-	ErrStatusUndefined    = errors.New("status with that id was not defined")
-	NoArguments           = errors.New("there are not enough arguments to continue")
-	TaskAlreadyAssigned   = errors.New("task was already assigned to the user before")
-	ErrNoPermission       = errors.New("you have no permission to do that")
-	Internal              = errors.New("internal Server Error")
-	TaskNotAssigned       = errors.New("this task was not assigned to this user")
+	ErrUserExists              = errors.New("user with that Email already exists")
+	ErrUserNotExists           = errors.New("user with that id do not exists")
+	ErrTaskNotExists           = errors.New("task with that id do not exists")
+	ErrInvalidCredentials      = errors.New("invalid Credentials")
+	ErrPasswordIncorrect       = errors.New("password Is Incorrect")
+	InvalidToken               = errors.New("token is Incorrect")
+	NoTokenSent                = errors.New("token was not defined in metadata")
+	NothingToDelete            = errors.New("nothing to delete")
+	ErrStatusUndefined         = errors.New("status with that id was not defined")
+	NoArguments                = errors.New("there are not enough arguments to continue")
+	TaskAlreadyAssigned        = errors.New("task was already assigned to the user before")
+	ErrNoPermission            = errors.New("you have no permission to do that")
+	Internal                   = errors.New("internal Server Error")
+	TaskNotAssigned            = errors.New("this task was not assigned to this user")
+	ErrInvalidPageToken        = errors.New("page token is invalid or does not match the request")
+	ErrInvalidPageSize         = errors.New("page size can not be negative")
+	ErrEmptySearchQuery        = errors.New("search query can not be empty")
+	ErrWatchTooSlow            = errors.New("watcher could not keep up with the task events")
+	ErrParentTaskNotExists     = errors.New("parent task with that id do not exists")
+	ErrTaskHasSubtasks         = errors.New("task has subtasks, choose what happens to them")
+	ErrTaskHasOpenSubtasks     = errors.New("task has uncompleted subtasks, complete them first or cascade")
+	ErrTaskBlocked             = errors.New("task is blocked by uncompleted tasks")
+	ErrDependencyCycle         = errors.New("dependency would create a cycle")
+	ErrDependencyExists        = errors.New("dependency already exists")
+	ErrCommentNotExists        = errors.New("comment with that id do not exists")
+	ErrEmptyComment            = errors.New("comment can not be empty")
+	ErrCommentTooLong          = errors.New("comment can have at most 10000 characters")
+	ErrLabelNotExists          = errors.New("label with that id do not exists")
+	ErrLabelExists             = errors.New("label with that name already exists")
+	ErrInvalidLabelColor       = errors.New("label color has to be a hex color like #1a2b3c")
+	TaskAlreadyLabeled         = errors.New("task has the label already")
+	TaskNotLabeled             = errors.New("task does not have this label")
+	ErrInvalidPriority         = errors.New("priority is not defined")
+	ErrParentTaskDeleted       = errors.New("parent task is in the trash, restore it first")
+	ErrRefreshTokenInvalid     = errors.New("refresh token is invalid, expired or revoked")
+	ErrRefreshTokenReused      = errors.New("refresh token was already used, the session was revoked")
+	TokenRevoked               = errors.New("token was revoked")
+	ErrResetTokenInvalid       = errors.New("password reset token is invalid, expired or already used")
+	ErrInvalidEmail            = errors.New("email is not a valid email address")
+	ErrVerificationCodeInvalid = errors.New("verification code is invalid or expired")
+	ErrTooManyAttempts         = errors.New("too many failed logins, try again later")
+	ErrTotpEnabled             = errors.New("two factor auth is already enabled")
+	ErrTotpNotEnabled          = errors.New("two factor auth is not enabled, enroll first")
+	ErrTotpCodeInvalid         = errors.New("two factor code is invalid or already used")
+	ErrTotpAttemptsExceeded    = errors.New("too many wrong two factor codes, enroll again")
+	ErrChallengeInvalid        = errors.New("login challenge is invalid, expired or already used")
+	ErrApiKeyNotExists         = errors.New("api key with that id do not exists")
+	ErrApiKeyInvalid           = errors.New("api key is invalid, expired or revoked")
+	ErrInvalidScope            = errors.New("scope is not defined")
+	ErrApiKeyScope             = errors.New("api key does not have the scope for that method")
+	ErrInvalidExpiry           = errors.New("expiry has to be in the future")
+	ErrNoSigningKey            = errors.New("there is no key to sign the tokens")
+	ErrInvalidRole             = errors.New("role is not defined")
+	ErrRoleNotGranted          = errors.New("user does not have that role")
+	ErrLastAdmin               = errors.New("the last admin can not lose the admin role")
+	ErrEmailNotVerified        = errors.New("email is not verified, verify it first")
+	ErrProjectNotExists        = errors.New("project with that id do not exists")
+	ErrProjectRequired         = errors.New("project of the task is required")
+	ErrProjectNotEmpty         = errors.New("project still has tasks, the ones in the trash count until they are purged")
+	ErrStatusNotInProject      = errors.New("status belongs to another project")
+	ErrParentNotInProject      = errors.New("parent task belongs to another project")
+	ErrInvalidProjectRole      = errors.New("project role has to be owner, editor or viewer")
+	ErrNotProjectMember        = errors.New("user is not a member of the project")
+	ErrAlreadyProjectMember    = errors.New("user is already a member of the project")
+	ErrLastProjectOwner        = errors.New("the last owner can not leave the project")
+	ErrInviteInvalid           = errors.New("invite is invalid, expired, already used or for another email")
+	ErrInvalidRecurrence       = errors.New("recurrence has to be an RRULE like FREQ=WEEKLY;BYDAY=MO")
+	ErrRecurrenceNeedsDue      = errors.New("recurring task needs a due date")
+	ErrInvalidLeadTime         = errors.New("reminder lead times have to be between a minute and 30 days, at most 10")
+	ErrWebhookNotExists        = errors.New("webhook with that id do not exists")
+	ErrInvalidWebhookURL       = errors.New("webhook url has to be an http or https url of a public host")
+	ErrInvalidWebhookEvent     = errors.New("webhook event types have to be task.created, task.updated, task.deleted, task.restored, task.assigned or task.unassigned")
 )
+
+// RetryAfterError is a rejection of a request which can be retried after RetryAfter
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
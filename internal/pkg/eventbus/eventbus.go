@@ -0,0 +1,58 @@
+package eventbus
+
+import (
+	"sso_3.0/internal/domain/models"
+	"sync"
+)
+
+// Bus is an in-process publish/subscribe bus for task events
+// publishing never blocks, a subscriber that can not keep up is dropped and its channel is closed
+type Bus struct {
+	mu          sync.Mutex
+	buffer      int
+	nextId      int
+	subscribers map[int]chan *models.TaskEvent
+}
+
+func New(buffer int) *Bus {
+	return &Bus{buffer: buffer, subscribers: make(map[int]chan *models.TaskEvent)}
+}
+
+// Subscribe returns a channel receiving every published event
+// and a function which has to be called to unsubscribe
+func (b *Bus) Subscribe() (<-chan *models.TaskEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextId
+	b.nextId++
+	events := make(chan *models.TaskEvent, b.buffer)
+	b.subscribers[id] = events
+
+	return events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		// the subscriber could already be dropped
+		if _, ok := b.subscribers[id]; ok {
+			delete(b.subscribers, id)
+			close(events)
+		}
+	}
+}
+
+// Publish sends the event to all subscribers
+func (b *Bus) Publish(event *models.TaskEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			// subscriber is too slow, drop it instead of blocking the publisher
+			delete(b.subscribers, id)
+			close(events)
+		}
+	}
+}
@@ -1,23 +1,44 @@
 package jwt
 
 import (
+	"context"
+	"crypto/rsa"
 	"github.com/dgrijalva/jwt-go"
-	"os"
 	"sso_3.0/internal/domain/user"
 	appErrors "sso_3.0/internal/errors"
 	"time"
 )
 
-func NewToken(user *user.Model) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+// Claims are the claims of a valid token
+type Claims struct {
+	UserId string
+	Email  string
+	// Id is the jti, it is used to revoke the token
+	Id        string
+	ExpiresAt time.Time
+	// Role is the role at the time the token was issued
+	Role user.Role
+}
+
+// KeySource gives the public key of a kid to check the tokens
+type KeySource interface {
+	PublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error)
+}
+
+// NewToken creates a token with the id as jti, it is signed with RS256 by the key
+func NewToken(key *Key, user *user.Model, id string, expiresAt time.Time) (string, error) {
+	token := jwt.New(jwt.SigningMethodRS256)
+	token.Header["kid"] = key.Id
 
 	claims := token.Claims.(jwt.MapClaims)
 
 	claims["uid"] = user.Id
 	claims["email"] = user.Email
-	claims["exp"] = time.Now().Add(time.Hour * 48).Unix()
+	claims["role"] = user.Role
+	claims["jti"] = id
+	claims["exp"] = expiresAt.Unix()
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("TOKEN_SECRET")))
+	tokenString, err := token.SignedString(key.PrivateKey)
 
 	if err != nil {
 		return "", err
@@ -25,20 +46,38 @@ func NewToken(user *user.Model) (string, error) {
 
 	return tokenString, nil
 }
-func CheckToken(tokenStr string) (string, error) {
+
+// CheckToken checks the signature with the key of the kid and the expiry of the token and returns its claims
+// tokens without jti can not be revoked and are not accepted
+func CheckToken(ctx context.Context, keys KeySource, tokenStr string) (*Claims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("TOKEN_SECRET")), nil
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, appErrors.InvalidToken
+		}
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, appErrors.InvalidToken
+		}
+		return keys.PublicKey(ctx, kid)
 	})
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	var uid = claims["uid"]
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
+	claims, ok := token.Claims.(jwt.MapClaims)
 	if !token.Valid || !ok {
-		return "", appErrors.InvalidToken
+		return nil, appErrors.InvalidToken
+	}
+
+	uid, _ := claims["uid"].(string)
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+	id, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+
+	if uid == "" || id == "" {
+		return nil, appErrors.InvalidToken
 	}
 
-	return uid.(string), nil
+	return &Claims{UserId: uid, Email: email, Id: id, ExpiresAt: time.Unix(int64(exp), 0), Role: user.Role(role)}, nil
 }
@@ -0,0 +1,76 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"github.com/google/uuid"
+	"math/big"
+)
+
+const (
+	// Algorithm is the jwt algorithm of the keys
+	Algorithm = "RS256"
+	keyBits   = 2048
+)
+
+// Key is a signing key, the tokens name it with their kid
+type Key struct {
+	Id         string
+	PrivateKey *rsa.PrivateKey
+	// Retired keys do not sign new tokens, they only check the tokens signed before
+	Retired bool
+}
+
+// NewKey generates a new key with a random id
+func NewKey() (*Key, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{Id: uuid.NewString(), PrivateKey: privateKey}, nil
+}
+
+// EncodePrivateKey encodes the private key as pem to store it
+func (k *Key) EncodePrivateKey() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k.PrivateKey)}))
+}
+
+// ParsePrivateKey parses a private key encoded by EncodePrivateKey
+func ParsePrivateKey(encoded string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, errors.New("private key is not pem encoded")
+	}
+
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// Jwk is the public key as json web key
+type Jwk struct {
+	Kty string
+	Kid string
+	Use string
+	Alg string
+	// N and E are the modulus and the exponent as base64url
+	N string
+	E string
+}
+
+// Jwk returns the public part of the key as json web key
+func (k *Key) Jwk() *Jwk {
+	publicKey := k.PrivateKey.PublicKey
+
+	return &Jwk{
+		Kty: "RSA",
+		Kid: k.Id,
+		Use: "sig",
+		Alg: Algorithm,
+		N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+	}
+}
@@ -0,0 +1,98 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message is a notification for a user
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	At      time.Time `json:"at"`
+}
+
+// Notifier delivers messages to the users
+type Notifier interface {
+	Notify(ctx context.Context, message *Message) error
+}
+
+// Options configure the notifier
+type Options struct {
+	// Kind is log, file, webhook or smtp
+	Kind string
+	// File is the path the file notifier appends to
+	File string
+	// WebhookURL is the url the webhook notifier posts to
+	WebhookURL string
+	// SMTPAddr is the host:port of the smtp server, the credentials are optional
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// New creates the notifier of the kind, log writes the messages to the logger
+// file appends them as json lines to a file, webhook posts them as json and smtp sends them as emails
+func New(options *Options, log *slog.Logger) (Notifier, error) {
+	switch options.Kind {
+	case "log":
+		return &LogNotifier{log: log}, nil
+	case "file":
+		return &FileNotifier{path: options.File}, nil
+	case "webhook":
+		return NewWebhookNotifier(options)
+	case "smtp":
+		return NewSMTPNotifier(options)
+	default:
+		return nil, fmt.Errorf("notifier %s is not defined", options.Kind)
+	}
+}
+
+// LogNotifier logs the messages, only for local use
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, message *Message) error {
+	n.log.Info("Notification", "to", message.To, "subject", message.Subject, "body", message.Body)
+	return nil
+}
+
+// FileNotifier appends the messages to a file, only for local use
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, message *Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if message.At.IsZero() {
+		message.At = time.Now()
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPNotifier sends the messages as plain text emails
+// without credentials it works with local smtp stand-ins like mailhog
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPNotifier(options *Options) (*SMTPNotifier, error) {
+	if options.SMTPAddr == "" || options.SMTPFrom == "" {
+		return nil, fmt.Errorf("smtp notifier needs an address and a sender")
+	}
+
+	notifier := &SMTPNotifier{addr: options.SMTPAddr, from: options.SMTPFrom}
+
+	if options.SMTPUsername != "" {
+		host, _, err := net.SplitHostPort(options.SMTPAddr)
+		if err != nil {
+			return nil, err
+		}
+		notifier.auth = smtp.PlainAuth("", options.SMTPUsername, options.SMTPPassword, host)
+	}
+
+	return notifier, nil
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, message *Message) error {
+	// the headers must not contain line breaks
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return fmt.Errorf("invalid message header")
+	}
+
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		n.from, message.To, message.Subject, message.Body)
+
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{message.To}, []byte(body))
+}
@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// webhookTimeout is how long the webhook can take to answer
+const webhookTimeout = 10 * time.Second
+
+// WebhookNotifier posts the messages as json to an url, any 2xx answer is a delivery
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(options *Options) (*WebhookNotifier, error) {
+	target, err := url.Parse(options.WebhookURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("webhook notifier needs an http or https url")
+	}
+
+	return &WebhookNotifier{url: options.WebhookURL, client: &http.Client{Timeout: webhookTimeout}}, nil
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, message *Message) error {
+	if message.At.IsZero() {
+		message.At = time.Now()
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook answered with status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,489 @@
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the FREQ of a rule, the length of its periods
+type Frequency string
+
+// only whole days are supported, the occurrences have the time of day of the start
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxYears is how far Next looks ahead, rules which can never occur like BYMONTH=2;BYMONTHDAY=30 end there
+const maxYears = 100
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// weekday is a BYDAY value, like 1MO for the first monday or -1FR for the last friday
+type weekday struct {
+	// n is the ordinal in the month or the year, 0 matches every of these weekdays
+	n   int
+	day time.Weekday
+}
+
+// Rule is an iCalendar (RFC 5545) recurrence rule like FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=1
+// the parts FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS and WKST are supported
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count is the count of occurrences including the start, 0 repeats forever
+	Count int
+	// Until is the last time an occurrence can have, zero repeats forever
+	Until time.Time
+
+	byDay      []weekday
+	byMonthDay []int
+	byMonth    []int
+	bySetPos   []int
+	weekStart  time.Weekday
+}
+
+// Parse parses a rule, with or without the RRULE: prefix
+func Parse(value string) (*Rule, error) {
+	rule := &Rule{Interval: 1, weekStart: time.Monday}
+	seen := make(map[string]bool)
+
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "RRULE:")
+
+	for _, part := range strings.Split(value, ";") {
+		name, values, ok := strings.Cut(part, "=")
+		if !ok || values == "" || seen[name] {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+		seen[name] = true
+
+		var err error
+
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(values)
+			if !slices.Contains([]Frequency{Daily, Weekly, Monthly, Yearly}, rule.Freq) {
+				err = errors.New("unsupported frequency")
+			}
+		case "INTERVAL":
+			rule.Interval, err = parseInt(values, 1, 1000)
+		case "COUNT":
+			rule.Count, err = parseInt(values, 1, 10000)
+		case "UNTIL":
+			rule.Until, err = parseUntil(values)
+		case "BYDAY":
+			rule.byDay, err = parseWeekdays(values)
+		case "BYMONTHDAY":
+			rule.byMonthDay, err = parseInts(values, 31)
+		case "BYMONTH":
+			rule.byMonth, err = parseInts(values, 12)
+			for _, month := range rule.byMonth {
+				if month < 0 {
+					err = errors.New("negative month")
+				}
+			}
+		case "BYSETPOS":
+			rule.bySetPos, err = parseInts(values, 366)
+		case "WKST":
+			var ok bool
+			if rule.weekStart, ok = weekdays[values]; !ok {
+				err = errors.New("unknown weekday")
+			}
+		default:
+			err = errors.New("unsupported part")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidRule, name, err)
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRule, err)
+	}
+
+	return rule, nil
+}
+
+// validate checks the combinations of the parts
+func (r *Rule) validate() error {
+	if r.Freq == "" {
+		return errors.New("FREQ is required")
+	}
+
+	if r.Count != 0 && !r.Until.IsZero() {
+		return errors.New("COUNT and UNTIL can not be combined")
+	}
+
+	if r.Freq == Weekly && len(r.byMonthDay) > 0 {
+		return errors.New("BYMONTHDAY can not be used with WEEKLY")
+	}
+
+	if len(r.bySetPos) > 0 && len(r.byDay)+len(r.byMonthDay)+len(r.byMonth) == 0 {
+		return errors.New("BYSETPOS needs another BY part")
+	}
+
+	for _, day := range r.byDay {
+		if day.n == 0 {
+			continue
+		}
+		// the ordinals count in the month, in the year only for yearly rules without BYMONTH
+		if r.Freq == Daily || r.Freq == Weekly {
+			return errors.New("BYDAY ordinals need MONTHLY or YEARLY")
+		}
+		if (r.Freq == Monthly || len(r.byMonth) > 0) && (day.n > 5 || day.n < -5) {
+			return errors.New("BYDAY ordinal is out of the month")
+		}
+	}
+
+	return nil
+}
+
+// String returns the rule in the canonical form, without the RRULE: prefix
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.byDay) > 0 {
+		var days []string
+		for _, day := range r.byDay {
+			name := formatWeekday(day.day)
+			if day.n != 0 {
+				name = strconv.Itoa(day.n) + name
+			}
+			days = append(days, name)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.byMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+formatInts(r.byMonthDay))
+	}
+	if len(r.byMonth) > 0 {
+		parts = append(parts, "BYMONTH="+formatInts(r.byMonth))
+	}
+	if len(r.bySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+formatInts(r.bySetPos))
+	}
+	if r.weekStart != time.Monday {
+		parts = append(parts, "WKST="+formatWeekday(r.weekStart))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence after the time in the series which starts at start
+// the start is the first occurrence, also if it does not match the rule like the DTSTART of iCalendar
+// it returns false if the series ends before
+func (r *Rule) Next(start, after time.Time) (time.Time, bool) {
+	if start.After(after) {
+		return start, true
+	}
+
+	count := 1
+	end := after.AddDate(maxYears, 0, 0)
+
+	for period := 0; !r.periodStart(start, period).After(end); period++ {
+		for _, occurrence := range r.occurrences(start, period) {
+			if !occurrence.After(start) {
+				continue
+			}
+
+			if !r.Until.IsZero() && occurrence.After(r.Until) {
+				return time.Time{}, false
+			}
+
+			count++
+			if r.Count > 0 && count > r.Count {
+				return time.Time{}, false
+			}
+
+			if occurrence.After(after) {
+				return occurrence, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// periodStart returns the first day of the period, the periods are counted from the one of the start
+func (r *Rule) periodStart(start time.Time, period int) time.Time {
+	year, month, day := start.Date()
+	step := period * r.Interval
+
+	switch r.Freq {
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.weekStart) + 7) % 7
+		return at(start, year, month, day-offset+7*step)
+	case Monthly:
+		return at(start, year, month+time.Month(step), 1)
+	case Yearly:
+		return at(start, year+step, time.January, 1)
+	default:
+		return at(start, year, month, day+step)
+	}
+}
+
+// occurrences returns the sorted occurrences of the period
+func (r *Rule) occurrences(start time.Time, period int) []time.Time {
+	var days []time.Time
+	first := r.periodStart(start, period)
+	year, month, _ := first.Date()
+	// without BYDAY and BYMONTHDAY the day of the start repeats
+	byStart := len(r.byDay) == 0 && len(r.byMonthDay) == 0
+
+	switch r.Freq {
+	case Daily:
+		days = r.expand(first, 1)
+	case Weekly:
+		for _, day := range r.expand(first, 7) {
+			if len(r.byDay) > 0 || day.Weekday() == start.Weekday() {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		if byStart {
+			days = r.onStartDay(start, year, month)
+			break
+		}
+		days = r.expand(first, daysIn(year, month))
+	case Yearly:
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []int{int(start.Month())}
+		}
+
+		switch {
+		case byStart:
+			for _, m := range months {
+				days = append(days, r.onStartDay(start, year, time.Month(m))...)
+			}
+		case len(r.byMonth) > 0:
+			for _, m := range months {
+				days = append(days, r.expand(at(start, year, time.Month(m), 1), daysIn(year, time.Month(m)))...)
+			}
+		default:
+			days = r.expand(first, daysInYear(year))
+		}
+
+		slices.SortFunc(days, func(a, b time.Time) int { return a.Compare(b) })
+	}
+
+	return r.setPositions(days)
+}
+
+// expand returns the days of the span from first which match the BY parts
+// the BYDAY ordinals count in the span
+func (r *Rule) expand(first time.Time, length int) []time.Time {
+	var days []time.Time
+	year, month, day := first.Date()
+
+	for i := 0; i < length; i++ {
+		date := at(first, year, month, day+i)
+
+		if r.matchesMonth(date) && r.matchesMonthDay(date) && r.matchesWeekday(date, i, length) {
+			days = append(days, date)
+		}
+	}
+
+	return days
+}
+
+// onStartDay returns the day of the month of the start in the month if it matches the BY parts
+// the day does not exist in short months, like the 31st or the 29th of february
+func (r *Rule) onStartDay(start time.Time, year int, month time.Month) []time.Time {
+	if start.Day() > daysIn(year, month) {
+		return nil
+	}
+
+	return r.expand(at(start, year, month, start.Day()), 1)
+}
+
+func (r *Rule) matchesMonth(date time.Time) bool {
+	return len(r.byMonth) == 0 || slices.Contains(r.byMonth, int(date.Month()))
+}
+
+// matchesMonthDay checks BYMONTHDAY, negative days count from the end of the month
+func (r *Rule) matchesMonthDay(date time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+
+	last := daysIn(date.Year(), date.Month())
+
+	for _, day := range r.byMonthDay {
+		if day == date.Day() || last+day+1 == date.Day() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesWeekday checks BYDAY, index is the position of the date in a span of length days
+func (r *Rule) matchesWeekday(date time.Time, index, length int) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+
+	for _, day := range r.byDay {
+		if day.day != date.Weekday() {
+			continue
+		}
+
+		if day.n == 0 || day.n == index/7+1 || day.n == -((length-1-index)/7+1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setPositions keeps the days at the BYSETPOS positions, negative positions count from the end
+func (r *Rule) setPositions(days []time.Time) []time.Time {
+	if len(r.bySetPos) == 0 {
+		return days
+	}
+
+	var selected []time.Time
+
+	for i, day := range days {
+		for _, pos := range r.bySetPos {
+			if pos == i+1 || pos == i-len(days) {
+				selected = append(selected, day)
+				break
+			}
+		}
+	}
+
+	return selected
+}
+
+// at returns the date with the time of day of t, the date is normalized like time.Date
+func at(t time.Time, year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func daysInYear(year int) int {
+	return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
+
+// parseUntil parses a date time in UTC, a local date time is also read as UTC
+// a date is the end of that day
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405"} {
+		if until, err := time.Parse(layout, value); err == nil {
+			return until, nil
+		}
+	}
+
+	date, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date")
+	}
+
+	return date.Add(24*time.Hour - time.Second), nil
+}
+
+func parseWeekdays(values string) ([]weekday, error) {
+	var days []weekday
+
+	for _, value := range strings.Split(values, ",") {
+		if len(value) < 2 {
+			return nil, errors.New("unknown weekday")
+		}
+
+		day, ok := weekdays[value[len(value)-2:]]
+		if !ok {
+			return nil, errors.New("unknown weekday")
+		}
+
+		var n int
+		if ordinal := value[:len(value)-2]; ordinal != "" {
+			var err error
+			if n, err = parseInt(ordinal, -53, 53); err != nil || n == 0 {
+				return nil, errors.New("invalid ordinal")
+			}
+		}
+
+		days = append(days, weekday{n: n, day: day})
+	}
+
+	return days, nil
+}
+
+// parseInts parses a list of values between -max and max without 0
+func parseInts(values string, max int) ([]int, error) {
+	var ints []int
+
+	for _, value := range strings.Split(values, ",") {
+		n, err := parseInt(value, -max, max)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, errors.New("0 is not allowed")
+		}
+		ints = append(ints, n)
+	}
+
+	return ints, nil
+}
+
+func parseInt(value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("not a number")
+	}
+
+	if n < min || n > max {
+		return 0, fmt.Errorf("not between %d and %d", min, max)
+	}
+
+	return n, nil
+}
+
+func formatInts(values []int) string {
+	var parts []string
+	for _, value := range values {
+		parts = append(parts, strconv.Itoa(value))
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatWeekday(day time.Weekday) string {
+	for name, value := range weekdays {
+		if value == day {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,188 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		// want are the occurrences after the start, the series ends after them if ends is set
+		want []time.Time
+		ends bool
+	}{
+		{
+			name:  "monthly on the 31st skips the short months",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31",
+			start: date(2024, time.January, 31),
+			want:  []time.Time{date(2024, time.March, 31), date(2024, time.May, 31), date(2024, time.July, 31), date(2024, time.August, 31)},
+		},
+		{
+			name:  "monthly without BYMONTHDAY repeats the day of the start",
+			rule:  "FREQ=MONTHLY",
+			start: date(2023, time.December, 31),
+			want:  []time.Time{date(2024, time.January, 31), date(2024, time.March, 31)},
+		},
+		{
+			name:  "last day of the month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: date(2024, time.January, 31),
+			want:  []time.Time{date(2024, time.February, 29), date(2024, time.March, 31), date(2024, time.April, 30), date(2024, time.May, 31)},
+		},
+		{
+			name:  "first business day of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=1",
+			start: date(2024, time.June, 3),
+			want:  []time.Time{date(2024, time.July, 1), date(2024, time.August, 1), date(2024, time.September, 2), date(2024, time.October, 1), date(2024, time.November, 1), date(2024, time.December, 2)},
+		},
+		{
+			name:  "last friday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: date(2024, time.January, 26),
+			want:  []time.Time{date(2024, time.February, 23), date(2024, time.March, 29)},
+		},
+		{
+			name:  "every other week on monday and friday",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+			start: date(2024, time.January, 1),
+			want:  []time.Time{date(2024, time.January, 5), date(2024, time.January, 15), date(2024, time.January, 19), date(2024, time.January, 29), date(2024, time.February, 2)},
+		},
+		{
+			name:  "every other week starting on sunday",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU;WKST=SU",
+			start: date(2024, time.January, 1),
+			want:  []time.Time{date(2024, time.January, 14), date(2024, time.January, 15), date(2024, time.January, 28)},
+		},
+		{
+			name:  "yearly on february 29 only in leap years",
+			rule:  "FREQ=YEARLY",
+			start: date(2024, time.February, 29),
+			want:  []time.Time{date(2028, time.February, 29), date(2032, time.February, 29)},
+		},
+		{
+			name:  "count includes the start",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: date(2024, time.January, 1),
+			want:  []time.Time{date(2024, time.January, 2), date(2024, time.January, 3)},
+			ends:  true,
+		},
+		{
+			name:  "until is inclusive",
+			rule:  "FREQ=WEEKLY;UNTIL=20240115T093000Z",
+			start: date(2024, time.January, 1),
+			want:  []time.Time{date(2024, time.January, 8), date(2024, time.January, 15)},
+			ends:  true,
+		},
+		{
+			name:  "february 31 never occurs",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=31",
+			start: date(2024, time.January, 1),
+			want:  nil,
+			ends:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) = %v", tt.rule, err)
+			}
+
+			after := tt.start
+			for i, want := range tt.want {
+				got, ok := rule.Next(tt.start, after)
+				if !ok || !got.Equal(want) {
+					t.Fatalf("occurrence %d = %s, %v, want %s", i+1, got, ok, want)
+				}
+				after = got
+			}
+
+			if got, ok := rule.Next(tt.start, after); tt.ends && ok {
+				t.Errorf("series goes on with %s, want its end", got)
+			}
+		})
+	}
+}
+
+func TestNextBeforeStart(t *testing.T) {
+	rule, err := Parse("FREQ=DAILY")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := date(2024, time.January, 10)
+
+	if got, ok := rule.Next(start, date(2024, time.January, 1)); !ok || !got.Equal(start) {
+		t.Errorf("Next() = %s, %v, want the start %s", got, ok, start)
+	}
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,FR;INTERVAL=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"},
+		{"rrule:freq=monthly;byday=-1fr", "FREQ=MONTHLY;BYDAY=-1FR"},
+		{"FREQ=MONTHLY;BYSETPOS=1;BYDAY=MO,TU,WE,TH,FR", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=1"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1,15", "FREQ=MONTHLY;BYMONTHDAY=-1,15"},
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29;COUNT=4", "FREQ=YEARLY;COUNT=4;BYMONTHDAY=29;BYMONTH=2"},
+		{"FREQ=WEEKLY;WKST=SU;UNTIL=20241231T000000Z", "FREQ=WEEKLY;UNTIL=20241231T000000Z;WKST=SU"},
+		{"FREQ=WEEKLY;WKST=MO", "FREQ=WEEKLY"},
+		{"FREQ=DAILY;INTERVAL=1", "FREQ=DAILY"},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.value)
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", tt.value, err)
+			continue
+		}
+
+		got := rule.String()
+		if got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.value, got, tt.want)
+			continue
+		}
+
+		again, err := Parse(got)
+		if err != nil || again.String() != got {
+			t.Errorf("round trip of %q = %v, %v", got, again, err)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	values := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101T000000Z",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=YEARLY;BYMONTH=-1",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ=DAILY;WKST=XX",
+		"FREQ=DAILY;BYHOUR=9",
+	}
+
+	for _, value := range values {
+		if _, err := Parse(value); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%q) = %v, want %v", value, err, ErrInvalidRule)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package secret
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+)
+
+// New generates a random url safe secret, like a refresh token
+func New() (string, error) {
+	data := make([]byte, 32)
+
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Hash hashes a secret to store it, the secrets are random so no salt is needed
+func Hash(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// NewCode generates a random code of digits which can be typed by hand, like a verification code
+func NewCode(digits int) (string, error) {
+	code := make([]byte, digits)
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+
+	return string(code), nil
+}
@@ -0,0 +1,41 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sso_3.0/internal/domain/models"
+)
+
+// Sink receives the domain events from the relay in the order they were written
+// an event whose publish failed is published again, so a sink can receive an event twice
+type Sink interface {
+	Publish(ctx context.Context, event *models.DomainEvent) error
+}
+
+// New creates the sinks of the kinds, log writes the events to the logger
+func New(kinds []string, log *slog.Logger) ([]Sink, error) {
+	var sinks []Sink
+
+	for _, kind := range kinds {
+		switch kind {
+		case "log":
+			sinks = append(sinks, &LogSink{log: log.With("op", "sink.log")})
+		default:
+			return nil, fmt.Errorf("sink %s is not defined", kind)
+		}
+	}
+
+	return sinks, nil
+}
+
+// LogSink logs the events
+type LogSink struct {
+	log *slog.Logger
+}
+
+func (s *LogSink) Publish(ctx context.Context, event *models.DomainEvent) error {
+	s.log.Info("Event", "id", event.Id, "type", event.Type, "aggregateId", event.AggregateId, "projectId", event.ProjectId,
+		"actorId", event.ActorId, "userId", event.UserId, "data", string(event.Data))
+	return nil
+}
@@ -0,0 +1,78 @@
+package ticker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Loop runs a work right away and then every interval until it is stopped
+// the background apps embed it, so they share the Run and Stop
+type Loop struct {
+	interval time.Duration
+	// timeout is how long one run of the work can take
+	timeout time.Duration
+	work    func(ctx context.Context)
+
+	mu      sync.Mutex
+	stopped bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func New(interval, timeout time.Duration, work func(ctx context.Context)) *Loop {
+	return &Loop{interval: interval, timeout: timeout, work: work}
+}
+
+// Run runs the work every interval until the ctx is cancelled or Stop is called
+// the context of the running work is cancelled with it, so Run returns after the current run
+func (l *Loop) Run(ctx context.Context) {
+	l.mu.Lock()
+	if l.stopped || l.done != nil {
+		l.mu.Unlock()
+		return
+	}
+	ctx, l.cancel = context.WithCancel(ctx)
+	l.done = make(chan struct{})
+	done, cancel := l.done, l.cancel
+	l.mu.Unlock()
+
+	defer close(done)
+	defer cancel()
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		l.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop cancels the running work and waits until Run returned, Run does nothing after it
+func (l *Loop) Stop() {
+	l.mu.Lock()
+	l.stopped = true
+	done, cancel := l.done, l.cancel
+	l.mu.Unlock()
+
+	// Run was not started
+	if done == nil {
+		return
+	}
+
+	cancel()
+	<-done
+}
+
+func (l *Loop) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	l.work(ctx)
+}
@@ -0,0 +1,119 @@
+package ticker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoopRunsRightAwayAndEveryInterval(t *testing.T) {
+	var runs atomic.Int32
+	loop := New(10*time.Millisecond, time.Second, func(ctx context.Context) {
+		runs.Add(1)
+	})
+
+	go loop.Run(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("work ran %d times in a second, want at least 3", runs.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	loop.Stop()
+	after := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+
+	if runs.Load() != after {
+		t.Errorf("work ran %d times after Stop", runs.Load()-after)
+	}
+}
+
+func TestLoopStopCancelsTheRunningWork(t *testing.T) {
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+
+	loop := New(time.Hour, time.Hour, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		cancelled.Store(true)
+	})
+
+	go loop.Run(context.Background())
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		loop.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+
+	// Stop waits for the work, so it saw the cancel before Stop returned
+	if !cancelled.Load() {
+		t.Error("Stop returned before the running work was cancelled")
+	}
+}
+
+func TestLoopReturnsWhenTheContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	loop := New(time.Hour, time.Hour, func(ctx context.Context) {})
+
+	go func() {
+		loop.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
+
+func TestLoopStopBeforeRun(t *testing.T) {
+	var runs atomic.Int32
+	loop := New(time.Millisecond, time.Second, func(ctx context.Context) {
+		runs.Add(1)
+	})
+
+	loop.Stop()
+	loop.Run(context.Background())
+
+	if runs.Load() != 0 {
+		t.Errorf("work ran %d times after Stop, want 0", runs.Load())
+	}
+}
+
+func TestLoopTimeout(t *testing.T) {
+	deadline := make(chan time.Time, 1)
+	loop := New(time.Hour, 50*time.Millisecond, func(ctx context.Context) {
+		d, _ := ctx.Deadline()
+		deadline <- d
+	})
+
+	start := time.Now()
+	go loop.Run(context.Background())
+	defer loop.Stop()
+
+	select {
+	case d := <-deadline:
+		if d.Before(start) || d.After(start.Add(time.Second)) {
+			t.Errorf("deadline of the run = %s, want about 50ms after %s", d, start)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("work did not run")
+	}
+}
@@ -0,0 +1,94 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the sha1 secret "12345678901234567890" of the test vectors of rfc 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// the codes of the rfc have 8 digits, these are their last 6
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, tt := range rfcVectors {
+		got, err := Code(rfcSecret, tt.unix/period)
+		if err != nil {
+			t.Fatalf("Code(%d) = %v", tt.unix, err)
+		}
+
+		if got != tt.code {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	got, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 59/period)
+	if err != nil || got != "287082" {
+		t.Errorf("Code() = %s, %v, want 287082", got, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	current := at.Unix() / period
+
+	tests := []struct {
+		name string
+		code string
+		step int64
+		ok   bool
+	}{
+		{name: "current step", code: "081804", step: current, ok: true},
+		{name: "wrong code", code: "081805"},
+		{name: "too short", code: "81804"},
+		{name: "eight digits of the rfc", code: "07081804"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tt.code, at)
+
+			if ok != tt.ok || step != tt.step {
+				t.Errorf("Validate(%s) = %d, %v, want %d, %v", tt.code, step, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	current := at.Unix() / period
+
+	for _, step := range []int64{current - skew, current + skew} {
+		code, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, ok := Validate(rfcSecret, code, at); !ok || got != step {
+			t.Errorf("Validate() of step %d = %d, %v", step, got, ok)
+		}
+	}
+
+	code, err := Code(rfcSecret, current+skew+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := Validate(rfcSecret, code, at); ok {
+		t.Error("code of a step after the skew is valid")
+	}
+}
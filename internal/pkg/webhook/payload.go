@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"encoding/json"
+	"sso_3.0/internal/domain/models"
+	"time"
+)
+
+// Payload is the json body of a delivery
+type Payload struct {
+	// Id is the id of the event, an event which is delivered twice has the same id
+	Id int64 `json:"id"`
+	// Type is the event like task.created
+	Type       models.DomainEventType `json:"type"`
+	OccurredAt time.Time              `json:"occurredAt"`
+	// ActorId is empty for the changes made by the server, like the next occurrence of a recurring task
+	ActorId string `json:"actorId,omitempty"`
+	// UserId is the assigned or unassigned user
+	UserId string `json:"userId,omitempty"`
+	// Task is the task after the change, for deleted tasks it is the last state
+	Task json.RawMessage `json:"task"`
+	// Previous is the task before the change, it is not set for created tasks
+	Previous json.RawMessage `json:"previous,omitempty"`
+}
+
+// NewPayload creates the json body of the deliveries of the task event
+func NewPayload(event *models.DomainEvent) ([]byte, error) {
+	return json.Marshal(&Payload{
+		Id:         event.Id,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt.UTC(),
+		ActorId:    event.ActorId,
+		UserId:     event.UserId,
+		Task:       event.Data,
+		Previous:   event.Previous,
+	})
+}
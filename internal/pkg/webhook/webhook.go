@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sso_3.0/internal/domain/models"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// timeout is how long the webhook can take to answer
+const timeout = 10 * time.Second
+
+// ErrBlockedAddress is the error of a delivery to an address of the internal network
+var ErrBlockedAddress = errors.New("webhook address is not public")
+
+// the headers of a delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature is sha256= and the hex HMAC-SHA256 of the timestamp, a dot and the body
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign signs the body sent at timestamp with the secret of the webhook
+// the timestamp is signed too, so the receivers can reject replayed deliveries
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a delivery, it is what the receivers do
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// IsBlockedIP reports if the deliveries can not be sent to the ip
+// loopback, private, link-local and unspecified addresses are blocked, so the webhooks can not reach the internal network
+func IsBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// refuseBlocked is the control of the dialer, it runs after the dns lookup so names of blocked ips are refused too
+func refuseBlocked(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || IsBlockedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+
+	return nil
+}
+
+// Sender posts the deliveries to the webhooks, any 2xx answer is a delivery
+type Sender struct {
+	client *http.Client
+}
+
+func NewSender() *Sender {
+	return newSender(timeout, refuseBlocked)
+}
+
+// newSender creates a sender whose dialer checks the addresses with control
+func newSender(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *Sender {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+
+	return &Sender{client: &http.Client{
+		Timeout: timeout,
+		// there is no proxy, the dialer has to see the address of the webhook
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: timeout,
+		},
+		// a redirect is not followed, the webhook has to be changed to the new url
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Send posts the delivery once and returns the result of the attempt
+func (s *Sender) Send(ctx context.Context, delivery *models.WebhookDelivery) *models.WebhookAttempt {
+	start := time.Now()
+	attempt := &models.WebhookAttempt{}
+
+	statusCode, err := s.post(ctx, delivery, start)
+	attempt.StatusCode = statusCode
+	attempt.Duration = time.Since(start)
+
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	return attempt
+}
+
+func (s *Sender) post(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	timestamp := now.Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// the body is read, so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook answered with status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sso_3.0/internal/domain/models"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestSender can reach the httptest receivers on the loopback
+func newTestSender(timeout time.Duration) *Sender {
+	return newSender(timeout, nil)
+}
+
+func newDelivery(url string) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		Id:        42,
+		WebhookId: 7,
+		URL:       url,
+		Secret:    "secret",
+		EventType: string(models.EventTaskCreated),
+		Payload:   []byte(`{"id":42,"type":"task.created"}`),
+		Attempt:   1,
+	}
+}
+
+func TestSendSignsTheDelivery(t *testing.T) {
+	var header http.Header
+	var body []byte
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	delivery := newDelivery(receiver.URL)
+	before := time.Now().Unix()
+
+	attempt := newTestSender(time.Second).Send(context.Background(), delivery)
+
+	if attempt.Error != "" || attempt.StatusCode != http.StatusNoContent {
+		t.Fatalf("attempt = %+v, want a delivered 204", attempt)
+	}
+
+	if string(body) != string(delivery.Payload) {
+		t.Errorf("body = %s, want %s", body, delivery.Payload)
+	}
+
+	if got := header.Get(HeaderEvent); got != delivery.EventType {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, delivery.EventType)
+	}
+
+	if got := header.Get(HeaderDelivery); got != "42" {
+		t.Errorf("%s = %q, want 42", HeaderDelivery, got)
+	}
+
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil || timestamp < before || timestamp > time.Now().Unix() {
+		t.Fatalf("%s = %q, want the time of the send", HeaderTimestamp, header.Get(HeaderTimestamp))
+	}
+
+	signature := header.Get(HeaderSignature)
+	if !strings.HasPrefix(signature, "sha256=") || !Verify(delivery.Secret, timestamp, body, signature) {
+		t.Errorf("%s = %q does not verify", HeaderSignature, signature)
+	}
+
+	if Verify("other", timestamp, body, signature) || Verify(delivery.Secret, timestamp+1, body, signature) {
+		t.Error("signature verifies with another secret or timestamp")
+	}
+}
+
+func TestSendFails(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		statusCode int
+	}{
+		{
+			name:       "server error",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "client error",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusGone) },
+			statusCode: http.StatusGone,
+		},
+		{
+			name: "redirect is not followed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/moved" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				http.Redirect(w, r, "/moved", http.StatusFound)
+			},
+			statusCode: http.StatusFound,
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+			statusCode: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				tt.handler(w, r)
+			}))
+			defer receiver.Close()
+
+			attempt := newTestSender(100*time.Millisecond).Send(context.Background(), newDelivery(receiver.URL))
+
+			if attempt.Error == "" {
+				t.Fatalf("attempt = %+v, want a failure", attempt)
+			}
+
+			if attempt.StatusCode != tt.statusCode {
+				t.Errorf("status code = %d, want %d", attempt.StatusCode, tt.statusCode)
+			}
+
+			if calls != 1 {
+				t.Errorf("receiver was called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestSendRefusesBlockedAddresses(t *testing.T) {
+	var calls int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer receiver.Close()
+
+	// the receiver listens on the loopback, like a database or the metadata service would
+	attempt := NewSender().Send(context.Background(), newDelivery(receiver.URL))
+
+	if !strings.Contains(attempt.Error, ErrBlockedAddress.Error()) {
+		t.Errorf("error = %q, want %q", attempt.Error, ErrBlockedAddress)
+	}
+
+	if calls != 0 {
+		t.Errorf("receiver was called %d times, want 0", calls)
+	}
+}
+
+func TestRefuseBlocked(t *testing.T) {
+	tests := []struct {
+		address string
+		blocked bool
+	}{
+		{"127.0.0.1:5432", true},
+		{"[::1]:80", true},
+		{"10.0.0.3:80", true},
+		{"172.16.5.4:443", true},
+		{"192.168.1.1:80", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"0.0.0.0:80", true},
+		{"[::ffff:127.0.0.1]:80", true},
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+	}
+
+	for _, tt := range tests {
+		err := refuseBlocked("tcp", tt.address, nil)
+
+		if blocked := errors.Is(err, ErrBlockedAddress); blocked != tt.blocked {
+			t.Errorf("refuseBlocked(%s) = %v, want blocked %v", tt.address, err, tt.blocked)
+		}
+	}
+}
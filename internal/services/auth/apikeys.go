@@ -0,0 +1,158 @@
+package authService
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"slices"
+	userModel "sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/secret"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyPrefix tells the api keys apart from the jwts in the authorization metadata
+	apiKeyPrefix = "tsk_"
+	// apiKeyShownLength is the length of the start of the key which is stored to identify it
+	apiKeyShownLength = 12
+	taskApiPrefix     = "/api.TaskApi/"
+)
+
+// CreateApiKey creates an api key of the user with the scopes, a zero expiresAt never expires
+// the key is returned only once, only its hash is stored
+func (s *Service) CreateApiKey(ctx context.Context, name string, scopes []string, expiresAt time.Time, currentUser *userModel.Model) (*userModel.ApiKey, string, error) {
+	if name == "" || len(scopes) == 0 {
+		return nil, "", appErrors.NoArguments
+	}
+
+	var keyScopes []string
+	for _, scope := range scopes {
+		if !userModel.IsValidScope(scope) {
+			return nil, "", appErrors.ErrInvalidScope
+		}
+		if !slices.Contains(keyScopes, scope) {
+			keyScopes = append(keyScopes, scope)
+		}
+	}
+
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return nil, "", appErrors.ErrInvalidExpiry
+	}
+
+	token, err := secret.New()
+	if err != nil {
+		return nil, "", err
+	}
+	token = apiKeyPrefix + token
+
+	apiKey, err := s.tokenStorage.CreateApiKey(ctx, &userModel.ApiKey{
+		UserId:    currentUser.Id,
+		Name:      name,
+		Prefix:    token[:apiKeyShownLength],
+		Scopes:    keyScopes,
+		ExpiresAt: expiresAt,
+	}, secret.Hash(token))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return apiKey, token, nil
+}
+
+// ListApiKeys gets the api keys of the user which are not revoked
+func (s *Service) ListApiKeys(ctx context.Context, currentUser *userModel.Model) ([]*userModel.ApiKey, error) {
+	return s.tokenStorage.GetApiKeys(ctx, currentUser.Id)
+}
+
+// RevokeApiKey revokes the api key, it can not be used anymore
+func (s *Service) RevokeApiKey(ctx context.Context, id int, currentUser *userModel.Model) error {
+	if id == 0 {
+		return appErrors.NoArguments
+	}
+
+	return s.tokenStorage.RevokeApiKey(ctx, id, currentUser.Id)
+}
+
+// apiKeyScopes are the scopes an api key needs for the methods, the methods which are not listed can not be called with an api key
+// so a new method of the task api is denied to the api keys until it is added here
+var apiKeyScopes = map[string]string{
+	"/api.TaskApi/GetAllStatuses":      userModel.ScopeTasksRead,
+	"/api.TaskApi/GetTasksByFilter":    userModel.ScopeTasksRead,
+	"/api.TaskApi/SearchTasks":         userModel.ScopeTasksRead,
+	"/api.TaskApi/WatchTasks":          userModel.ScopeTasksRead,
+	"/api.TaskApi/GetSubtasks":         userModel.ScopeTasksRead,
+	"/api.TaskApi/GetDependencyGraph":  userModel.ScopeTasksRead,
+	"/api.TaskApi/ListComments":        userModel.ScopeTasksRead,
+	"/api.TaskApi/GetAllLabels":        userModel.ScopeTasksRead,
+	"/api.TaskApi/GetTaskHistory":      userModel.ScopeTasksRead,
+	"/api.TaskApi/ListDeletedTasks":    userModel.ScopeTasksRead,
+	"/api.TaskApi/GetProject":          userModel.ScopeTasksRead,
+	"/api.TaskApi/GetAllProjects":      userModel.ScopeTasksRead,
+	"/api.TaskApi/ListMembers":         userModel.ScopeTasksRead,
+	"/api.TaskApi/GetReminderSettings": userModel.ScopeTasksRead,
+	"/api.TaskApi/ListWebhooks":        userModel.ScopeTasksRead,
+	"/api.TaskApi/CreateTask":          userModel.ScopeTasksWrite,
+	"/api.TaskApi/DeleteTask":          userModel.ScopeTasksWrite,
+	"/api.TaskApi/UpdateTask":          userModel.ScopeTasksWrite,
+	"/api.TaskApi/CreateStatus":        userModel.ScopeTasksWrite,
+	"/api.TaskApi/DeleteStatus":        userModel.ScopeTasksWrite,
+	"/api.TaskApi/UpdateStatus":        userModel.ScopeTasksWrite,
+	"/api.TaskApi/AssignTask":          userModel.ScopeTasksWrite,
+	"/api.TaskApi/UnAssignTask":        userModel.ScopeTasksWrite,
+	"/api.TaskApi/AddDependency":       userModel.ScopeTasksWrite,
+	"/api.TaskApi/RemoveDependency":    userModel.ScopeTasksWrite,
+	"/api.TaskApi/AddComment":          userModel.ScopeTasksWrite,
+	"/api.TaskApi/EditComment":         userModel.ScopeTasksWrite,
+	"/api.TaskApi/DeleteComment":       userModel.ScopeTasksWrite,
+	"/api.TaskApi/CreateLabel":         userModel.ScopeTasksWrite,
+	"/api.TaskApi/UpdateLabel":         userModel.ScopeTasksWrite,
+	"/api.TaskApi/DeleteLabel":         userModel.ScopeTasksWrite,
+	"/api.TaskApi/AddLabel":            userModel.ScopeTasksWrite,
+	"/api.TaskApi/RemoveLabel":         userModel.ScopeTasksWrite,
+	"/api.TaskApi/RestoreTask":         userModel.ScopeTasksWrite,
+	"/api.TaskApi/CreateProject":       userModel.ScopeTasksWrite,
+	"/api.TaskApi/UpdateProject":       userModel.ScopeTasksWrite,
+	"/api.TaskApi/DeleteProject":       userModel.ScopeTasksWrite,
+	"/api.TaskApi/InviteMember":        userModel.ScopeTasksWrite,
+	"/api.TaskApi/AcceptInvite":        userModel.ScopeTasksWrite,
+	"/api.TaskApi/RemoveMember":        userModel.ScopeTasksWrite,
+	"/api.TaskApi/SetReminderSettings": userModel.ScopeTasksWrite,
+	"/api.TaskApi/CreateWebhook":       userModel.ScopeTasksWrite,
+	"/api.TaskApi/DeleteWebhook":       userModel.ScopeTasksWrite,
+}
+
+// authenticateApiKey validates the api key of the request and its scope for the method
+// api keys can only be used for the methods of apiKeyScopes
+func (s *Service) authenticateApiKey(ctx context.Context, token, method string) (context.Context, error) {
+	apiKey, err := s.tokenStorage.UseApiKey(ctx, secret.Hash(token))
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Auth error")
+	}
+
+	scope, ok := apiKeyScopes[method]
+	if !ok || !apiKey.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, appErrors.ErrApiKeyScope.Error())
+	}
+
+	user, err := s.userStorage.GetUserById(ctx, apiKey.UserId)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Auth error")
+	}
+
+	if err = s.checkVerified(user, method); err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, "uid", user.Id)
+	ctx = context.WithValue(ctx, "email", user.Email)
+	ctx = context.WithValue(ctx, "role", user.Role)
+	ctx = context.WithValue(ctx, "apiKeyId", apiKey.Id)
+
+	return ctx, nil
+}
+
+func isApiKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
@@ -0,0 +1,60 @@
+package authService
+
+import (
+	userModel "sso_3.0/internal/domain/user"
+	api "sso_3.0/proto/gen"
+	"testing"
+)
+
+func TestApiKeyScopesCoverTheTaskApi(t *testing.T) {
+	for _, method := range api.TaskApi_ServiceDesc.Methods {
+		fullMethod := "/" + api.TaskApi_ServiceDesc.ServiceName + "/" + method.MethodName
+		if _, ok := apiKeyScopes[fullMethod]; !ok {
+			t.Errorf("%s has no api key scope", fullMethod)
+		}
+	}
+
+	for _, stream := range api.TaskApi_ServiceDesc.Streams {
+		fullMethod := "/" + api.TaskApi_ServiceDesc.ServiceName + "/" + stream.StreamName
+		if _, ok := apiKeyScopes[fullMethod]; !ok {
+			t.Errorf("%s has no api key scope", fullMethod)
+		}
+	}
+
+	if count := len(api.TaskApi_ServiceDesc.Methods) + len(api.TaskApi_ServiceDesc.Streams); len(apiKeyScopes) != count {
+		t.Errorf("apiKeyScopes has %d methods, the task api %d", len(apiKeyScopes), count)
+	}
+
+	for _, method := range api.AuthApi_ServiceDesc.Methods {
+		fullMethod := "/" + api.AuthApi_ServiceDesc.ServiceName + "/" + method.MethodName
+		if _, ok := apiKeyScopes[fullMethod]; ok {
+			t.Errorf("%s can be called with an api key", fullMethod)
+		}
+	}
+}
+
+func TestApiKeyScopes(t *testing.T) {
+	tests := []struct {
+		method string
+		scope  string
+	}{
+		{"/api.TaskApi/GetTasksByFilter", userModel.ScopeTasksRead},
+		{"/api.TaskApi/WatchTasks", userModel.ScopeTasksRead},
+		{"/api.TaskApi/ListComments", userModel.ScopeTasksRead},
+		{"/api.TaskApi/CreateTask", userModel.ScopeTasksWrite},
+		{"/api.TaskApi/RestoreTask", userModel.ScopeTasksWrite},
+		{"/api.TaskApi/AcceptInvite", userModel.ScopeTasksWrite},
+	}
+
+	for _, tt := range tests {
+		if got := apiKeyScopes[tt.method]; got != tt.scope {
+			t.Errorf("scope of %s = %q, want %q", tt.method, got, tt.scope)
+		}
+	}
+
+	for _, method := range []string{"/api.AuthApi/CreateApiKey", "/api.AuthApi/ChangePassword", "/api.TaskApi/Unknown", ""} {
+		if scope, ok := apiKeyScopes[method]; ok {
+			t.Errorf("%s needs %q, want it denied to the api keys", method, scope)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package authService
+
+import (
+	"context"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	appErrors "sso_3.0/internal/errors"
+	"strings"
+	"time"
+)
+
+// attemptsWindow is how long failed logins are counted, a failure after a longer pause starts the count again
+const attemptsWindow = 24 * time.Hour
+
+// loginKey is an email or ip the failed logins are counted for
+type loginKey struct {
+	key         string
+	maxAttempts int
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(email)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// getLoginKeys returns the keys of the login request, the ip is only known for requests over the network
+func (s *Service) getLoginKeys(ctx context.Context, email string) []loginKey {
+	keys := []loginKey{{key: emailKey(email), maxAttempts: s.loginMaxAttempts}}
+
+	if ip := getClientIp(ctx, s.trustedProxies); ip != "" {
+		keys = append(keys, loginKey{key: ipKey(ip), maxAttempts: s.loginMaxIpAttempts})
+	}
+
+	return keys
+}
+
+// getClientIp gets the ip of the client, the peer and the x-forwarded-for addresses are read from the right
+// every address of a trusted proxy is skipped, the first untrusted one is the client
+// the addresses left of it could be made up by the client, so they are never used
+func getClientIp(ctx context.Context, trustedProxies []*net.IPNet) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return ""
+	}
+
+	if !isTrustedProxy(host, trustedProxies) {
+		return host
+	}
+
+	// the gateway and the proxies append to the list, the metadata can have several values in the order they came in
+	md, _ := metadata.FromIncomingContext(ctx)
+	var hops []string
+	for _, forwarded := range md.Get("x-forwarded-for") {
+		hops = append(hops, strings.Split(forwarded, ",")...)
+	}
+
+	client := host
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		// a trusted proxy would not add something else than an ip, the last trusted address is used instead
+		if net.ParseIP(hop) == nil {
+			break
+		}
+
+		client = hop
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+
+	return client
+}
+
+// isTrustedProxy checks if the ip is in one of the networks of the trusted proxies
+func isTrustedProxy(address string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkLockout returns a RetryAfterError if one of the keys is locked
+func (s *Service) checkLockout(ctx context.Context, keys []loginKey) error {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.key
+	}
+
+	lockout, err := s.lockoutStorage.GetLockout(ctx, names)
+	if err != nil {
+		return err
+	}
+
+	if lockout > 0 {
+		return &appErrors.RetryAfterError{Err: appErrors.ErrTooManyAttempts, RetryAfter: lockout}
+	}
+
+	return nil
+}
+
+// recordFailedLogin counts the failed login for the keys and locks the ones with too many failures
+// the lockout doubles with every failure after the limit
+func (s *Service) recordFailedLogin(ctx context.Context, keys []loginKey) error {
+	for _, key := range keys {
+		failures, err := s.lockoutStorage.RecordFailure(ctx, key.key, attemptsWindow)
+		if err != nil {
+			return err
+		}
+
+		if failures < key.maxAttempts {
+			continue
+		}
+
+		if err = s.lockoutStorage.Lock(ctx, key.key, s.getLockout(failures-key.maxAttempts)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resetFailedLogins removes the failed logins of the email after a successful login
+// only the email is reset, an ip could try many accounts
+func (s *Service) resetFailedLogins(ctx context.Context, email string) {
+	op := "service.auth.resetFailedLogins"
+	log := s.log.With("op", op)
+
+	if err := s.lockoutStorage.Reset(ctx, []string{emailKey(email)}); err != nil {
+		log.Error("Error on resetting failed logins", "errors", err)
+	}
+}
+
+// getLockout returns the lockout after the count of failures over the limit
+func (s *Service) getLockout(over int) time.Duration {
+	lockout := s.loginLockout
+	for i := 0; i < over && lockout < s.loginMaxLockout; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, s.loginMaxLockout)
+}
+
+// UnlockAccount removes the lockout and the failed logins of the email and optionally of an ip
+func (s *Service) UnlockAccount(ctx context.Context, email, ip string) error {
+	if email == "" && ip == "" {
+		return appErrors.NoArguments
+	}
+
+	var keys []string
+	if email != "" {
+		keys = append(keys, emailKey(email))
+	}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+
+	return s.lockoutStorage.Reset(ctx, keys)
+}
+
+// PurgeFailedLogins deletes the counters of the failed logins which are older than attemptsWindow and not locked
+func (s *Service) PurgeFailedLogins(ctx context.Context) (int, error) {
+	return s.lockoutStorage.PurgeExpired(ctx, attemptsWindow)
+}
@@ -0,0 +1,75 @@
+package authService
+
+import (
+	"context"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestGetLockout(t *testing.T) {
+	s := &Service{loginLockout: time.Minute, loginMaxLockout: time.Hour}
+
+	tests := []struct {
+		over int
+		want time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{2, 4 * time.Minute},
+		{5, 32 * time.Minute},
+		{6, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := s.getLockout(tt.over); got != tt.want {
+			t.Errorf("getLockout(%d) = %s, want %s", tt.over, got, tt.want)
+		}
+	}
+}
+
+func TestGetClientIp(t *testing.T) {
+	trusted := []*net.IPNet{
+		{IP: net.ParseIP("127.0.0.1").To4(), Mask: net.CIDRMask(32, 32)},
+		{IP: net.ParseIP("10.0.0.0").To4(), Mask: net.CIDRMask(8, 32)},
+	}
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		want      string
+	}{
+		{"untrusted peer", "203.0.113.7:5000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted peer without header", "127.0.0.1:5000", nil, "127.0.0.1"},
+		{"gateway", "127.0.0.1:5000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"made up hops are skipped", "127.0.0.1:5000", []string{"1.1.1.1, 2.2.2.2, 198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxies are skipped", "127.0.0.1:5000", []string{"1.1.1.1, 198.51.100.1, 10.0.0.5"}, "198.51.100.1"},
+		{"several values", "127.0.0.1:5000", []string{"1.1.1.1", "198.51.100.1, 10.0.0.5"}, "198.51.100.1"},
+		{"only trusted hops", "127.0.0.1:5000", []string{"10.0.0.6, 10.0.0.5"}, "10.0.0.6"},
+		{"invalid hop", "127.0.0.1:5000", []string{"198.51.100.1, unknown, 10.0.0.5"}, "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		addr, err := net.ResolveTCPAddr("tcp", tt.peer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		if tt.forwarded != nil {
+			ctx = metadata.NewIncomingContext(ctx, metadata.MD{"x-forwarded-for": tt.forwarded})
+		}
+
+		if got := getClientIp(ctx, trusted); got != tt.want {
+			t.Errorf("%s: getClientIp = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := getClientIp(context.Background(), trusted); got != "" {
+		t.Errorf("getClientIp without a peer = %q, want empty", got)
+	}
+}
@@ -0,0 +1,130 @@
+package authService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	userModel "sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/bcrypt"
+	"sso_3.0/internal/pkg/notifier"
+	"sso_3.0/internal/pkg/secret"
+)
+
+// ChangePassword changes the password of the user if the old password is correct
+// all sessions and api keys of the user are revoked together with the change, the tokens of a new session are returned
+// wrong old passwords count as failed logins, so a stolen token can not be used to guess the password
+func (s *Service) ChangePassword(ctx context.Context, oldPassword, newPassword string, currentUser *userModel.Model) (*userModel.Tokens, error) {
+	op := "service.auth.ChangePassword"
+	log := s.log.With("op", op)
+
+	if newPassword == "" {
+		return nil, appErrors.NoArguments
+	}
+
+	keys := s.getLoginKeys(ctx, currentUser.Email)
+	if err := s.checkLockout(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userStorage.GetUserById(ctx, currentUser.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bcrypt.CheckPasswordHash(oldPassword, user.Hash)
+	if err != nil {
+		if errors.Is(appErrors.ErrPasswordIncorrect, err) {
+			if failErr := s.recordFailedLogin(ctx, keys); failErr != nil {
+				log.Error("Error on recording failed login", "errors", failErr)
+			}
+			return nil, appErrors.ErrInvalidCredentials
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	hash, err := bcrypt.HashPassword(newPassword)
+	if err != nil {
+		log.Error("Error on Hashing Password")
+		return nil, err
+	}
+
+	if err = s.tokenStorage.UpdatePassword(ctx, user.Id, hash); err != nil {
+		return nil, err
+	}
+
+	s.resetFailedLogins(ctx, user.Email)
+
+	return s.newSession(ctx, user)
+}
+
+// RequestPasswordReset sends a password reset token to the user with the email
+// unknown emails are ignored, so the response does not tell if a user exists
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	op := "service.auth.RequestPasswordReset"
+	log := s.log.With("op", op)
+
+	user, err := s.userStorage.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(appErrors.ErrUserNotExists, err) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := secret.New()
+	if err != nil {
+		return err
+	}
+
+	if err = s.userStorage.CreatePasswordReset(ctx, user.Id, secret.Hash(token), s.passwordResetTTL); err != nil {
+		return err
+	}
+
+	err = s.notifier.Notify(ctx, &notifier.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body:    fmt.Sprintf("Use this token to reset your password, it is valid for %s: %s", s.passwordResetTTL, token),
+	})
+	if err != nil {
+		log.Error("Error on sending reset token", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// ConfirmPasswordReset sets the new password with a reset token, the token can only be used once
+// all sessions and api keys of the user are revoked
+func (s *Service) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	op := "service.auth.ConfirmPasswordReset"
+	log := s.log.With("op", op)
+
+	if token == "" || newPassword == "" {
+		return appErrors.NoArguments
+	}
+
+	hash, err := bcrypt.HashPassword(newPassword)
+	if err != nil {
+		log.Error("Error on Hashing Password")
+		return err
+	}
+
+	userId, err := s.userStorage.ResetPassword(ctx, secret.Hash(token), hash)
+	if err != nil {
+		return err
+	}
+
+	if err = s.tokenStorage.RevokeUserTokens(ctx, userId); err != nil {
+		log.Error("Error on revoking sessions", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// PurgeExpiredPasswordResets deletes the expired password reset tokens
+func (s *Service) PurgeExpiredPasswordResets(ctx context.Context) (int, error) {
+	return s.userStorage.PurgeExpiredPasswordResets(ctx)
+}
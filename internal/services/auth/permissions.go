@@ -0,0 +1,105 @@
+package authService
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"slices"
+	userModel "sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"strings"
+)
+
+// permissions are the roles which can call the methods, the methods which are not listed can be called by all users
+// the statuses and projects are checked by the task service, the ones of a project can also be managed by its owners
+var permissions = map[string][]userModel.Role{
+	"/api.AuthApi/UnlockAccount": {userModel.RoleAdmin},
+	"/api.AuthApi/GrantRole":     {userModel.RoleAdmin},
+	"/api.AuthApi/RevokeRole":    {userModel.RoleAdmin},
+}
+
+// PermissionInterceptor checks the role of the user for the method, it runs after the AuthInterceptor
+func (s *Service) PermissionInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	if err = checkPermission(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// PermissionStreamInterceptor is the PermissionInterceptor for streaming methods
+func (s *Service) PermissionStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := checkPermission(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+// checkPermission checks the role the AuthInterceptor added to the context
+// the role is read from the users on every request, so a revoked role takes effect before the token expires
+func checkPermission(ctx context.Context, method string) error {
+	roles, ok := permissions[method]
+	if !ok {
+		return nil
+	}
+
+	role, _ := ctx.Value("role").(userModel.Role)
+	if !slices.Contains(roles, role) {
+		return status.Error(codes.PermissionDenied, appErrors.ErrNoPermission.Error())
+	}
+
+	return nil
+}
+
+// GrantRole gives the role to the user with the email
+func (s *Service) GrantRole(ctx context.Context, email string, role userModel.Role) error {
+	if email == "" || role == "" {
+		return appErrors.NoArguments
+	}
+
+	if !role.IsValid() {
+		return appErrors.ErrInvalidRole
+	}
+
+	return s.userStorage.GrantRole(ctx, email, role)
+}
+
+// RevokeRole takes the role from the user with the email, the user is a member then
+func (s *Service) RevokeRole(ctx context.Context, email string, role userModel.Role) error {
+	if email == "" || role == "" {
+		return appErrors.NoArguments
+	}
+
+	if !role.IsValid() {
+		return appErrors.ErrInvalidRole
+	}
+
+	return s.userStorage.RevokeRole(ctx, email, role)
+}
+
+// GrantConfiguredAdmins grants the admin role to the verified users of the configured admin emails
+func (s *Service) GrantConfiguredAdmins(ctx context.Context) error {
+	if len(s.adminEmails) == 0 {
+		return nil
+	}
+
+	emails := make([]string, len(s.adminEmails))
+	for i, email := range s.adminEmails {
+		emails[i] = strings.ToLower(email)
+	}
+
+	return s.userStorage.GrantAdmins(ctx, emails)
+}
+
+// isConfiguredAdmin checks if the email is one of the configured admin emails
+func (s *Service) isConfiguredAdmin(email string) bool {
+	for _, adminEmail := range s.adminEmails {
+		if strings.EqualFold(adminEmail, email) {
+			return true
+		}
+	}
+
+	return false
+}
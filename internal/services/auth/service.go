@@ -7,139 +7,299 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"log/slog"
+	"net"
+	"net/mail"
+	configParser "sso_3.0/internal/config"
 	userModel "sso_3.0/internal/domain/user"
 	appErrors "sso_3.0/internal/errors"
 	"sso_3.0/internal/pkg/bcrypt"
 	"sso_3.0/internal/pkg/jwt"
+	"sso_3.0/internal/pkg/notifier"
+	"sso_3.0/internal/services/keys"
 	"sso_3.0/internal/storage/postgres"
+	"sso_3.0/internal/storage/postgres/lockout"
+	"sso_3.0/internal/storage/postgres/token"
 	"sso_3.0/internal/storage/postgres/user"
 	"strings"
+	"time"
 )
 
 type Service struct {
-	log         *slog.Logger
-	userStorage *user.Storage
+	log              *slog.Logger
+	userStorage      *user.Storage
+	tokenStorage     *token.Storage
+	lockoutStorage   *lockout.Storage
+	notifier         notifier.Notifier
+	keys             *keys.Service
+	accessTokenTTL   time.Duration
+	refreshTokenTTL  time.Duration
+	passwordResetTTL time.Duration
+	verificationTTL  time.Duration
+	// requireVerifiedEmail blocks users with unverified email from the task api
+	requireVerifiedEmail bool
+	loginMaxAttempts     int
+	loginMaxIpAttempts   int
+	loginLockout         time.Duration
+	loginMaxLockout      time.Duration
+	// trustedProxies are the networks whose x-forwarded-for is used to find the ip of the client
+	trustedProxies    []*net.IPNet
+	adminEmails       []string
+	loginChallengeTTL time.Duration
+	totpIssuer        string
 }
 
-func New(log *slog.Logger, storage *postgres.Storage) *Service {
-	return &Service{log: log, userStorage: storage.UserStorage}
+func New(log *slog.Logger, storage *postgres.Storage, notifier notifier.Notifier, keys *keys.Service, cfg *configParser.Config) *Service {
+	return &Service{
+		log:                  log,
+		userStorage:          storage.UserStorage,
+		tokenStorage:         storage.TokenStorage,
+		lockoutStorage:       storage.LockoutStorage,
+		notifier:             notifier,
+		keys:                 keys,
+		accessTokenTTL:       cfg.AccessTokenTTL,
+		refreshTokenTTL:      cfg.RefreshTokenTTL,
+		passwordResetTTL:     cfg.PasswordResetTTL,
+		verificationTTL:      cfg.VerificationTTL,
+		requireVerifiedEmail: cfg.RequireVerifiedEmail,
+		loginMaxAttempts:     cfg.LoginMaxAttempts,
+		loginMaxIpAttempts:   cfg.LoginMaxIpAttempts,
+		loginLockout:         cfg.LoginLockout,
+		loginMaxLockout:      cfg.LoginMaxLockout,
+		trustedProxies:       cfg.TrustedProxies,
+		adminEmails:          cfg.AdminEmails,
+		loginChallengeTTL:    cfg.LoginChallengeTTL,
+		totpIssuer:           cfg.TotpIssuer,
+	}
 }
 
-func (s *Service) Register(ctx context.Context, email, password string) (string, error) {
+func (s *Service) Register(ctx context.Context, email, password string) (*userModel.Tokens, error) {
 	op := "service.auth.Register"
 	log := s.log.With("op", op)
 
+	// only plain addresses, no display names like "Name <email>"
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return nil, appErrors.ErrInvalidEmail
+	}
+
 	hash, err := bcrypt.HashPassword(password)
 	if err != nil {
 		log.Error("Error on Hashing Password")
-		return "", err
+		return nil, err
 	}
 	user, err := s.userStorage.Register(ctx, email, hash)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	token, err := jwt.NewToken(user)
+	// the user can request a new code, so the registration does not fail
+	if err = s.sendVerification(ctx, user, 0); err != nil {
+		log.Error("Error on sending verification code", "errors", err)
+	}
 
-	return token, nil
+	return s.newSession(ctx, user)
 }
 
-func (s *Service) Login(ctx context.Context, email, password string) (string, error) {
+// Login checks the credentials and starts a new session
+// users with two factor auth get a challenge instead, which is exchanged with a code for the tokens by LoginVerifyTotp
+// too many failed logins of the email or from the ip lock them for a while
+func (s *Service) Login(ctx context.Context, email, password string) (*userModel.Tokens, *userModel.Challenge, error) {
 	op := "service.auth.Login"
 	log := s.log.With("op", op)
 
+	keys := s.getLoginKeys(ctx, email)
+	if err := s.checkLockout(ctx, keys); err != nil {
+		return nil, nil, err
+	}
+
 	user, err := s.userStorage.GetUserByEmail(ctx, email)
+	if err == nil {
+		err = bcrypt.CheckPasswordHash(password, user.Hash)
+	}
 	if err != nil {
-		if errors.Is(appErrors.ErrUserNotExists, err) {
-			return "", appErrors.ErrInvalidCredentials
+		// unknown emails are counted too, so the lockout does not tell if a user exists
+		if errors.Is(appErrors.ErrUserNotExists, err) || errors.Is(appErrors.ErrPasswordIncorrect, err) {
+			if err = s.recordFailedLogin(ctx, keys); err != nil {
+				log.Error("Error on recording failed login", "errors", err)
+			}
+			return nil, nil, appErrors.ErrInvalidCredentials
 		}
-		log.Error("Error: ", err)
-		return "", err
+		log.Error("Error", "errors", err)
+		return nil, nil, err
 	}
 
-	err = bcrypt.CheckPasswordHash(password, user.Hash)
+	totpEnabled, err := s.isTotpEnabled(ctx, user.Id)
 	if err != nil {
-		if errors.Is(appErrors.ErrPasswordIncorrect, err) {
-			return "", appErrors.ErrInvalidCredentials
-		}
-		log.Error("Error: ", err)
-		return "", err
+		log.Error("Error", "errors", err)
+		return nil, nil, err
+	}
+
+	// the failed logins are reset after the code was checked
+	if totpEnabled {
+		challenge, err := s.newChallenge(ctx, user)
+		return nil, challenge, err
 	}
 
-	token, err := jwt.NewToken(user)
+	s.resetFailedLogins(ctx, email)
 
-	return token, nil
+	tokens, err := s.newSession(ctx, user)
+	return tokens, nil, err
 }
 
 func (s *Service) ValidateAuth(ctx context.Context) (error, *userModel.Model) {
-	md, ok := metadata.FromIncomingContext(ctx)
+	user, _, err := s.validateAuth(ctx)
+	return err, user
+}
 
-	if !ok {
-		return appErrors.NoTokenSent, nil
+// validateAuth validates the jwt of the request and returns its user and claims
+func (s *Service) validateAuth(ctx context.Context) (*userModel.Model, *jwt.Claims, error) {
+	jwtToken, err := getAuthToken(ctx)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	tokens := md.Get("authorization")
+	err, claims := s.ValidateToken(ctx, jwtToken)
+	if err != nil {
+		fmt.Println(err)
+		return nil, nil, err
+	}
 
-	if len(tokens) == 0 {
-		return appErrors.NoTokenSent, nil
+	user, err := s.userStorage.GetUserById(ctx, claims.UserId)
+	if err != nil {
+		fmt.Println(err)
+		return nil, nil, err
 	}
+	return user, claims, nil
+}
 
-	token := tokens[0]
+// GetJwks returns the public keys which check the tokens, so other services can check them without a secret
+func (s *Service) GetJwks(ctx context.Context) ([]*jwt.Jwk, error) {
+	return s.keys.GetJwks(ctx)
+}
 
-	jwtToken := strings.TrimPrefix(token, "Bearer ")
-	err, uid := s.ValidateToken(ctx, jwtToken)
-	if err != nil || uid == "" {
-		fmt.Println(err)
+// ValidateToken checks the token and that it was not revoked
+func (s *Service) ValidateToken(ctx context.Context, token string) (error, *jwt.Claims) {
+	claims, err := jwt.CheckToken(ctx, s.keys, token)
+	if err != nil {
 		return err, nil
 	}
 
-	user, err := s.userStorage.GetUserById(ctx, uid)
+	revoked, err := s.tokenStorage.IsRevoked(ctx, claims.Id)
 	if err != nil {
-		fmt.Println(err)
 		return err, nil
 	}
-	return nil, user
+
+	if revoked {
+		return appErrors.TokenRevoked, nil
+	}
+
+	return nil, claims
 }
 
-func (s *Service) ValidateToken(ctx context.Context, token string) (error, string) {
-	uid, err := jwt.CheckToken(token)
-	if err != nil {
-		return err, ""
+func (s *Service) AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	if !s.checkIfRoutePrivate(info.FullMethod) {
+		return handler(ctx, req)
 	}
 
+	ctx, err = s.authenticate(ctx, info.FullMethod)
 	if err != nil {
-		return err, ""
+		return nil, err
 	}
 
-	return nil, uid
+	return handler(ctx, req)
 }
 
-func (s *Service) AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+// AuthStreamInterceptor is the AuthInterceptor for streaming methods
+func (s *Service) AuthStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 	if !s.checkIfRoutePrivate(info.FullMethod) {
-		return handler(ctx, req)
+		return handler(srv, ss)
 	}
 
-	err, user := s.ValidateAuth(ctx)
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authServerStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate validates the jwt or api key of the request to the method and adds the user to the context
+func (s *Service) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if token, err := getAuthToken(ctx); err == nil && isApiKey(token) {
+		return s.authenticateApiKey(ctx, token, method)
+	}
+
+	user, claims, err := s.validateAuth(ctx)
 
 	if err != nil {
 		fmt.Printf("error: %e", err)
 		if errors.Is(appErrors.NoTokenSent, err) {
-			return nil, grpc.Errorf(codes.InvalidArgument, err.Error())
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
-		return nil, grpc.Errorf(codes.Unauthenticated, "Auth error")
+		return nil, status.Error(codes.Unauthenticated, "Auth error")
+	}
+
+	if err = s.checkVerified(user, method); err != nil {
+		return nil, err
 	}
 
 	ctx = context.WithValue(ctx, "uid", user.Id)
 	ctx = context.WithValue(ctx, "email", user.Email)
+	ctx = context.WithValue(ctx, "role", user.Role)
+	ctx = context.WithValue(ctx, "jti", claims.Id)
+	ctx = context.WithValue(ctx, "exp", claims.ExpiresAt)
 
-	return handler(ctx, req)
+	return ctx, nil
+}
+
+// checkVerified blocks users with unverified email from the task api if it is required
+func (s *Service) checkVerified(user *userModel.Model, method string) error {
+	if s.requireVerifiedEmail && !user.Verified && strings.HasPrefix(method, taskApiPrefix) {
+		return status.Error(codes.PermissionDenied, appErrors.ErrEmailNotVerified.Error())
+	}
+
+	return nil
+}
+
+// getAuthToken gets the jwt or api key from the authorization metadata, the Bearer prefix is optional
+func getAuthToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+
+	if !ok {
+		return "", appErrors.NoTokenSent
+	}
+
+	tokens := md.Get("authorization")
+
+	if len(tokens) == 0 {
+		return "", appErrors.NoTokenSent
+	}
+
+	return strings.TrimPrefix(tokens[0], "Bearer "), nil
+}
+
+// authServerStream is a server stream with the authenticated context
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
 }
 
 func (s *Service) checkIfRoutePrivate(route string) bool {
 	public := []string{
 		"/api.AuthApi/Login",
+		"/api.AuthApi/LoginVerifyTotp",
 		"/api.AuthApi/Register",
+		"/api.AuthApi/RefreshToken",
+		"/api.AuthApi/RequestPasswordReset",
+		"/api.AuthApi/ConfirmPasswordReset",
+		"/api.AuthApi/VerifyEmail",
+		"/api.AuthApi/ResendVerification",
+		"/api.AuthApi/GetJwks",
 	}
 
 	for _, item := range public {
@@ -154,7 +314,16 @@ func (s *Service) checkIfRoutePrivate(route string) bool {
 func (s *Service) GetUserFromCTX(ctx context.Context) *userModel.Model {
 	email := ctx.Value("email").(string)
 	uid := ctx.Value("uid").(string)
+	role, _ := ctx.Value("role").(userModel.Role)
+
+	return &userModel.Model{Id: uid, Email: email, Role: role}
+
+}
 
-	return &userModel.Model{Id: uid, Email: email}
+// getTokenFromCTX gets the jti and the expiry of the access token of the request
+func getTokenFromCTX(ctx context.Context) (string, time.Time) {
+	jti := ctx.Value("jti").(string)
+	exp := ctx.Value("exp").(time.Time)
 
+	return jti, exp
 }
@@ -0,0 +1,100 @@
+package authService
+
+import (
+	"context"
+	"github.com/google/uuid"
+	userModel "sso_3.0/internal/domain/user"
+	"sso_3.0/internal/pkg/jwt"
+	"sso_3.0/internal/pkg/secret"
+	"time"
+)
+
+// RefreshToken exchanges the refresh token for new tokens, the refresh token can only be used once
+// using it a second time revokes the whole session
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (*userModel.Tokens, error) {
+	op := "service.auth.RefreshToken"
+	log := s.log.With("op", op)
+
+	newRefreshToken, err := secret.New()
+	if err != nil {
+		return nil, err
+	}
+
+	jti := uuid.NewString()
+	expiresAt := time.Now().Add(s.accessTokenTTL)
+
+	userId, err := s.tokenStorage.RotateRefreshToken(ctx, secret.Hash(refreshToken), secret.Hash(newRefreshToken), jti, expiresAt, s.refreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userStorage.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	accessToken, err := s.newAccessToken(ctx, user, jti, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userModel.Tokens{AccessToken: accessToken, RefreshToken: newRefreshToken, ExpiresAt: expiresAt}, nil
+}
+
+// Logout revokes the access token of the request
+// with the refresh token also the session of it is revoked
+func (s *Service) Logout(ctx context.Context, refreshToken string, currentUser *userModel.Model) error {
+	jti, expiresAt := getTokenFromCTX(ctx)
+
+	if refreshToken != "" {
+		if err := s.tokenStorage.RevokeFamily(ctx, secret.Hash(refreshToken), currentUser.Id); err != nil {
+			return err
+		}
+	}
+
+	return s.tokenStorage.RevokeAccessToken(ctx, jti, expiresAt)
+}
+
+// PurgeExpiredSessions deletes the expired refresh tokens and the denylist entries of the expired access tokens
+func (s *Service) PurgeExpiredSessions(ctx context.Context) (int, error) {
+	return s.tokenStorage.PurgeExpired(ctx)
+}
+
+// newSession issues the tokens of a new session for the user
+func (s *Service) newSession(ctx context.Context, user *userModel.Model) (*userModel.Tokens, error) {
+	op := "service.auth.newSession"
+	log := s.log.With("op", op)
+
+	refreshToken, err := secret.New()
+	if err != nil {
+		return nil, err
+	}
+
+	jti := uuid.NewString()
+	expiresAt := time.Now().Add(s.accessTokenTTL)
+
+	accessToken, err := s.newAccessToken(ctx, user, jti, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// every session is a new family of refresh tokens
+	err = s.tokenStorage.CreateRefreshToken(ctx, user.Id, uuid.NewString(), secret.Hash(refreshToken), jti, expiresAt, s.refreshTokenTTL)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	return &userModel.Tokens{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresAt: expiresAt}, nil
+}
+
+// newAccessToken signs an access token with the current signing key
+func (s *Service) newAccessToken(ctx context.Context, user *userModel.Model, jti string, expiresAt time.Time) (string, error) {
+	key, err := s.keys.SigningKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return jwt.NewToken(key, user, jti, expiresAt)
+}
@@ -0,0 +1,239 @@
+package authService
+
+import (
+	"context"
+	"errors"
+	userModel "sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/secret"
+	"sso_3.0/internal/pkg/totp"
+	"time"
+)
+
+const (
+	recoveryCodesCount = 10
+	recoveryCodeDigits = 10
+)
+
+// EnrollTotp creates a new two factor secret for the user, it is enabled after ConfirmTotp
+func (s *Service) EnrollTotp(ctx context.Context, currentUser *userModel.Model) (*userModel.TotpEnrollment, error) {
+	totpSecret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.userStorage.CreateTotp(ctx, currentUser.Id, totpSecret); err != nil {
+		return nil, err
+	}
+
+	return &userModel.TotpEnrollment{
+		Secret: totpSecret,
+		URI:    totp.URI(s.totpIssuer, currentUser.Email, totpSecret),
+	}, nil
+}
+
+// ConfirmTotp enables the two factor auth with a code of the enrolled secret
+// it returns the recovery codes, they are only shown once
+// after MaxCodeAttempts wrong codes the secret has to be enrolled again
+func (s *Service) ConfirmTotp(ctx context.Context, code string, currentUser *userModel.Model) ([]string, error) {
+	op := "service.auth.ConfirmTotp"
+	log := s.log.With("op", op)
+
+	if code == "" {
+		return nil, appErrors.NoArguments
+	}
+
+	userTotp, err := s.userStorage.GetTotp(ctx, currentUser.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if userTotp.Enabled {
+		return nil, appErrors.ErrTotpEnabled
+	}
+
+	if userTotp.Attempts >= userModel.MaxCodeAttempts {
+		return nil, appErrors.ErrTotpAttemptsExceeded
+	}
+
+	step, ok := totp.Validate(userTotp.Secret, code, time.Now())
+	if !ok {
+		if err = s.userStorage.FailTotpConfirm(ctx, currentUser.Id); err != nil {
+			log.Error("Error on counting the wrong code", "errors", err)
+		}
+		return nil, appErrors.ErrTotpCodeInvalid
+	}
+
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		if codes[i], err = secret.NewCode(recoveryCodeDigits); err != nil {
+			return nil, err
+		}
+		hashes[i] = secret.Hash(codes[i])
+	}
+
+	if err = s.userStorage.EnableTotp(ctx, currentUser.Id, step, hashes); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTotp disables the two factor auth, it needs a code or a recovery code
+// wrong codes count as failed logins, so a stolen token can not be used to guess the code
+func (s *Service) DisableTotp(ctx context.Context, code string, currentUser *userModel.Model) error {
+	op := "service.auth.DisableTotp"
+	log := s.log.With("op", op)
+
+	if code == "" {
+		return appErrors.NoArguments
+	}
+
+	keys := s.getLoginKeys(ctx, currentUser.Email)
+	if err := s.checkLockout(ctx, keys); err != nil {
+		return err
+	}
+
+	userTotp, err := s.userStorage.GetTotp(ctx, currentUser.Id)
+	if err != nil {
+		return err
+	}
+
+	if !userTotp.Enabled {
+		return appErrors.ErrTotpNotEnabled
+	}
+
+	if err = s.checkTotpCode(ctx, currentUser.Id, userTotp, code); err != nil {
+		if errors.Is(appErrors.ErrTotpCodeInvalid, err) {
+			if failErr := s.recordFailedLogin(ctx, keys); failErr != nil {
+				log.Error("Error on recording failed login", "errors", failErr)
+			}
+		}
+		return err
+	}
+
+	return s.userStorage.DisableTotp(ctx, currentUser.Id)
+}
+
+// LoginVerifyTotp exchanges the challenge of the login and a code or a recovery code for the tokens
+// wrong codes count as failed logins
+func (s *Service) LoginVerifyTotp(ctx context.Context, challengeToken, code string) (*userModel.Tokens, error) {
+	op := "service.auth.LoginVerifyTotp"
+	log := s.log.With("op", op)
+
+	if challengeToken == "" || code == "" {
+		return nil, appErrors.NoArguments
+	}
+
+	challengeHash := secret.Hash(challengeToken)
+
+	userId, err := s.tokenStorage.GetLoginChallenge(ctx, challengeHash)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userStorage.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := s.getLoginKeys(ctx, user.Email)
+	if err = s.checkLockout(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	// the two factor auth was disabled after the login
+	userTotp, err := s.userStorage.GetTotp(ctx, userId)
+	if err != nil {
+		if errors.Is(appErrors.ErrTotpNotEnabled, err) {
+			return nil, appErrors.ErrChallengeInvalid
+		}
+		return nil, err
+	}
+
+	err = s.checkTotpCode(ctx, userId, userTotp, code)
+	if err != nil {
+		if errors.Is(appErrors.ErrTotpCodeInvalid, err) {
+			if failErr := s.tokenStorage.FailLoginChallenge(ctx, challengeHash); failErr != nil {
+				log.Error("Error on counting challenge attempt", "errors", failErr)
+			}
+			if failErr := s.recordFailedLogin(ctx, keys); failErr != nil {
+				log.Error("Error on recording failed login", "errors", failErr)
+			}
+		}
+		return nil, err
+	}
+
+	if err = s.tokenStorage.UseLoginChallenge(ctx, challengeHash); err != nil {
+		return nil, err
+	}
+
+	s.resetFailedLogins(ctx, user.Email)
+
+	return s.newSession(ctx, user)
+}
+
+// checkTotpCode checks a code of the secret or a recovery code, both can only be used once
+func (s *Service) checkTotpCode(ctx context.Context, userId string, userTotp *userModel.Totp, code string) error {
+	if !userTotp.Enabled {
+		return appErrors.ErrTotpNotEnabled
+	}
+
+	if step, ok := totp.Validate(userTotp.Secret, code, time.Now()); ok {
+		used, err := s.userStorage.UseTotpStep(ctx, userId, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return appErrors.ErrTotpCodeInvalid
+		}
+		return nil
+	}
+
+	used, err := s.userStorage.UseRecoveryCode(ctx, userId, secret.Hash(code))
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return appErrors.ErrTotpCodeInvalid
+	}
+
+	return nil
+}
+
+// isTotpEnabled checks if the user has to send a code on login
+func (s *Service) isTotpEnabled(ctx context.Context, userId string) (bool, error) {
+	userTotp, err := s.userStorage.GetTotp(ctx, userId)
+	if err != nil {
+		if errors.Is(appErrors.ErrTotpNotEnabled, err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return userTotp.Enabled, nil
+}
+
+// newChallenge issues a login challenge for the user
+func (s *Service) newChallenge(ctx context.Context, user *userModel.Model) (*userModel.Challenge, error) {
+	token, err := secret.New()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.loginChallengeTTL)
+
+	if err = s.tokenStorage.CreateLoginChallenge(ctx, user.Id, secret.Hash(token), s.loginChallengeTTL); err != nil {
+		return nil, err
+	}
+
+	return &userModel.Challenge{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// PurgeExpiredChallenges deletes the expired login challenges
+func (s *Service) PurgeExpiredChallenges(ctx context.Context) (int, error) {
+	return s.tokenStorage.PurgeExpiredChallenges(ctx)
+}
@@ -0,0 +1,99 @@
+package authService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	userModel "sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/notifier"
+	"sso_3.0/internal/pkg/secret"
+	"time"
+)
+
+const (
+	verificationCodeDigits = 6
+	// resendThrottle is how long a user has to wait before a new code is sent
+	resendThrottle = time.Minute
+)
+
+// VerifyEmail verifies the email of the user with the code sent to it
+func (s *Service) VerifyEmail(ctx context.Context, email, code string) error {
+	if email == "" || code == "" {
+		return appErrors.NoArguments
+	}
+
+	user, err := s.userStorage.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(appErrors.ErrUserNotExists, err) {
+			return appErrors.ErrVerificationCodeInvalid
+		}
+		return err
+	}
+
+	if user.Verified {
+		return nil
+	}
+
+	if err = s.userStorage.VerifyEmail(ctx, user.Id, secret.Hash(code)); err != nil {
+		return err
+	}
+
+	// the configured admin role is only granted once the user has shown to own the email
+	if s.isConfiguredAdmin(user.Email) {
+		return s.userStorage.GrantRole(ctx, user.Email, userModel.RoleAdmin)
+	}
+
+	return nil
+}
+
+// ResendVerification sends a new verification code to the user with the email
+// unknown or already verified emails are ignored, so the response does not tell if a user exists
+func (s *Service) ResendVerification(ctx context.Context, email string) error {
+	op := "service.auth.ResendVerification"
+	log := s.log.With("op", op)
+
+	user, err := s.userStorage.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(appErrors.ErrUserNotExists, err) {
+			return nil
+		}
+		return err
+	}
+
+	if user.Verified {
+		return nil
+	}
+
+	if err = s.sendVerification(ctx, user, resendThrottle); err != nil {
+		log.Error("Error on sending verification code", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// sendVerification stores a new verification code for the user and sends it to the email
+// nothing is sent if the last code is younger than throttle
+func (s *Service) sendVerification(ctx context.Context, user *userModel.Model, throttle time.Duration) error {
+	code, err := secret.NewCode(verificationCodeDigits)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.userStorage.CreateVerification(ctx, user.Id, secret.Hash(code), s.verificationTTL, throttle)
+	if err != nil || !created {
+		return err
+	}
+
+	return s.notifier.Notify(ctx, &notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body:    fmt.Sprintf("Use this code to verify your email, it is valid for %s: %s", s.verificationTTL, code),
+	})
+}
+
+// PurgeExpiredVerifications deletes the expired email verification codes
+func (s *Service) PurgeExpiredVerifications(ctx context.Context) (int, error) {
+	return s.userStorage.PurgeExpiredVerifications(ctx)
+}
@@ -0,0 +1,181 @@
+package keys
+
+import (
+	"context"
+	"crypto/rsa"
+	"log/slog"
+	configParser "sso_3.0/internal/config"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/jwt"
+	"sso_3.0/internal/storage/postgres"
+	"sso_3.0/internal/storage/postgres/token"
+	"sync"
+	"time"
+)
+
+const (
+	// refreshInterval is how long the keys are cached, other replicas may have rotated meanwhile
+	refreshInterval = time.Minute
+	// unknownKidInterval is how often an unknown kid reloads the keys, so bad tokens can not flood the database
+	unknownKidInterval = 5 * time.Second
+)
+
+// Service manages the keys signing the tokens, they are shared by all replicas through the database
+type Service struct {
+	log          *slog.Logger
+	tokenStorage *token.Storage
+	rotation     time.Duration
+	retention    time.Duration
+
+	mu       sync.RWMutex
+	keys     []*jwt.Key
+	loadedAt time.Time
+}
+
+func New(log *slog.Logger, storage *postgres.Storage, cfg *configParser.Config) *Service {
+	return &Service{
+		log:          log,
+		tokenStorage: storage.TokenStorage,
+		rotation:     cfg.SigningKeyRotation,
+		// the replicas may sign with the previous key until they reload the keys
+		retention: cfg.AccessTokenTTL + refreshInterval,
+	}
+}
+
+// Rotate creates a new signing key if the current one is older than the rotation, also the first one
+// it returns true if a new key was created
+func (s *Service) Rotate(ctx context.Context) (bool, error) {
+	op := "service.keys.Rotate"
+	log := s.log.With("op", op)
+
+	current, err := s.tokenStorage.IsSigningKeyCurrent(ctx, s.rotation)
+	if err != nil || current {
+		return false, err
+	}
+
+	key, err := jwt.NewKey()
+	if err != nil {
+		return false, err
+	}
+
+	rotated, err := s.tokenStorage.RotateSigningKey(ctx, key, s.rotation, s.retention)
+	if err != nil {
+		return false, err
+	}
+
+	if rotated {
+		log.Info("Rotated signing key", "kid", key.Id)
+	}
+
+	return rotated, s.load(ctx)
+}
+
+// SigningKey returns the key which signs new tokens
+func (s *Service) SigningKey(ctx context.Context) (*jwt.Key, error) {
+	keys, err := s.getKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if !key.Retired {
+			return key, nil
+		}
+	}
+
+	return nil, appErrors.ErrNoSigningKey
+}
+
+// PublicKey returns the public key of the kid if it is not retired yet
+func (s *Service) PublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	keys, err := s.getKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if key := findKey(keys, kid); key != nil {
+		return &key.PrivateKey.PublicKey, nil
+	}
+
+	// the key may have been created by another replica after the last load
+	s.mu.RLock()
+	recent := time.Since(s.loadedAt) < unknownKidInterval
+	s.mu.RUnlock()
+
+	if recent {
+		return nil, appErrors.InvalidToken
+	}
+
+	if err = s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	key := findKey(s.keys, kid)
+	s.mu.RUnlock()
+
+	if key == nil {
+		return nil, appErrors.InvalidToken
+	}
+
+	return &key.PrivateKey.PublicKey, nil
+}
+
+// GetJwks returns the public keys which can check the tokens as json web keys
+func (s *Service) GetJwks(ctx context.Context) ([]*jwt.Jwk, error) {
+	var jwks []*jwt.Jwk
+
+	keys, err := s.getKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		jwks = append(jwks, key.Jwk())
+	}
+
+	return jwks, nil
+}
+
+// getKeys returns the cached keys, they are reloaded after the refresh interval
+func (s *Service) getKeys(ctx context.Context) ([]*jwt.Key, error) {
+	s.mu.RLock()
+	keys, loadedAt := s.keys, s.loadedAt
+	s.mu.RUnlock()
+
+	if time.Since(loadedAt) < refreshInterval {
+		return keys, nil
+	}
+
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.keys, nil
+}
+
+func (s *Service) load(ctx context.Context) error {
+	keys, err := s.tokenStorage.GetSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+func findKey(keys []*jwt.Key, kid string) *jwt.Key {
+	for _, key := range keys {
+		if key.Id == kid {
+			return key
+		}
+	}
+	return nil
+}
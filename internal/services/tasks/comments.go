@@ -0,0 +1,141 @@
+package tasks
+
+import (
+	"context"
+	"regexp"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCommentLength is the count of characters a comment can have
+const maxCommentLength = 10000
+
+// mentionRegexp matches @email mentions, the email is the first group
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\w@])@([\w.%+-]+@[\w-]+(?:\.[\w-]+)+)`)
+
+// AddComment adds a comment to the task, only the creator and the assignees can comment
+// they have to be members of the project of the task
+func (s *Service) AddComment(ctx context.Context, taskId int, body string, currentUser *user.Model) (*models.Comment, error) {
+	if err := checkCommentBody(body); err != nil {
+		return nil, err
+	}
+
+	task, err := s.getTaskForRole(ctx, taskId, currentUser, models.ProjectViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.CreatorId != currentUser.Id && !task.IsAssigned(currentUser.Id) {
+		return nil, appErrors.ErrNoPermission
+	}
+
+	mentionIds, err := s.getMentionIds(ctx, task.ProjectId, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storage.CommentStorage.CreateComment(ctx, taskId, currentUser.Id, body, mentionIds)
+}
+
+// EditComment changes the body of the comment, only the author can edit it
+func (s *Service) EditComment(ctx context.Context, commentId int, body string, currentUser *user.Model) (*models.Comment, error) {
+	if err := checkCommentBody(body); err != nil {
+		return nil, err
+	}
+
+	comment, err := s.storage.CommentStorage.GetCommentById(ctx, commentId)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.Author.Id != currentUser.Id {
+		return nil, appErrors.ErrNoPermission
+	}
+
+	// authors which left the project can not edit their comments anymore
+	task, err := s.getTaskForRole(ctx, comment.TaskId, currentUser, models.ProjectViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	mentionIds, err := s.getMentionIds(ctx, task.ProjectId, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storage.CommentStorage.UpdateComment(ctx, commentId, body, mentionIds)
+}
+
+// DeleteComment deletes the comment, only the author and the task creator can delete it
+// they have to be members of the project of the task
+func (s *Service) DeleteComment(ctx context.Context, commentId int, currentUser *user.Model) error {
+	comment, err := s.storage.CommentStorage.GetCommentById(ctx, commentId)
+	if err != nil {
+		return err
+	}
+
+	task, err := s.getTaskForRole(ctx, comment.TaskId, currentUser, models.ProjectViewer)
+	if err != nil {
+		return err
+	}
+
+	if comment.Author.Id != currentUser.Id && task.CreatorId != currentUser.Id {
+		return appErrors.ErrNoPermission
+	}
+
+	return s.storage.CommentStorage.DeleteComment(ctx, commentId)
+}
+
+// ListComments gets one page of the comments of the task and the token of the next page
+// only the members of the project of the task can read them
+func (s *Service) ListComments(ctx context.Context, taskId, pageSize int, pageToken string, currentUser *user.Model) ([]*models.Comment, string, error) {
+	if pageSize < 0 {
+		return nil, "", appErrors.ErrInvalidPageSize
+	}
+
+	_, err := s.getTaskForRole(ctx, taskId, currentUser, models.ProjectViewer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.storage.CommentStorage.GetComments(ctx, taskId, pageSize, pageToken)
+}
+
+// getMentionIds gets the ids of the members of the project mentioned in the body
+// other emails are ignored, so a comment does not tell if an email is registered
+func (s *Service) getMentionIds(ctx context.Context, projectId int, body string) ([]string, error) {
+	return s.storage.ProjectStorage.GetMemberIdsByEmails(ctx, projectId, parseMentions(body))
+}
+
+// checkCommentBody checks that the body is not blank and not longer than maxCommentLength
+func checkCommentBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return appErrors.ErrEmptyComment
+	}
+
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		return appErrors.ErrCommentTooLong
+	}
+
+	return nil
+}
+
+// parseMentions gets the lower case emails of all @email mentions without duplicates
+func parseMentions(body string) []string {
+	var emails []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionRegexp.FindAllStringSubmatch(body, -1) {
+		email := strings.ToLower(match[1])
+
+		if !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+
+	return emails
+}
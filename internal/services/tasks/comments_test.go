@@ -0,0 +1,41 @@
+package tasks
+
+import (
+	"errors"
+	"slices"
+	appErrors "sso_3.0/internal/errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckCommentBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want error
+	}{
+		{name: "blank", body: " \n\t", want: appErrors.ErrEmptyComment},
+		{name: "at the limit", body: strings.Repeat("a", maxCommentLength)},
+		{name: "characters not bytes", body: strings.Repeat("ü", maxCommentLength)},
+		{name: "too long", body: strings.Repeat("a", maxCommentLength+1), want: appErrors.ErrCommentTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkCommentBody(tt.body); !errors.Is(err, tt.want) {
+				t.Errorf("checkCommentBody() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMentions(t *testing.T) {
+	body := "@Ann@example.com and @bob@example.org, again @ann@example.com but not mail@carl@example.com"
+
+	got := parseMentions(body)
+	want := []string{"ann@example.com", "bob@example.org"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("parseMentions() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/storage/postgres/outbox"
+	"time"
+)
+
+const (
+	// relayBatchSize is the count of events published in one transaction of the relay
+	relayBatchSize = 100
+	// an event which could not be published waits twice as long after every attempt, up to relayMaxBackoff
+	relayBackoff    = time.Second
+	relayMaxBackoff = 10 * time.Minute
+)
+
+// Relay publishes at most limit pending events of the outbox in order, it is the Relay of the outbox storage
+type Relay func(ctx context.Context, limit int, publish outbox.Publish, retry outbox.Retry) (int, error)
+
+// RelayEvents publishes the events of the outbox to the webhooks and the sinks, it returns the count of published events
+// an event which could not be published holds up the later events of its task or status until it is published again
+// after a growing backoff, the other events are still published, after outboxMaxAttempts attempts it is given up
+func (s *Service) RelayEvents(ctx context.Context) (int, error) {
+	var published int
+
+	for {
+		count, err := s.relay(ctx, relayBatchSize, s.publishEvent, s.getRelayRetry)
+		published += count
+
+		if err != nil || count < relayBatchSize {
+			return published, err
+		}
+	}
+}
+
+// publishEvent publishes the event to the webhooks of its project and to every sink
+// the deliveries of the webhooks are added in the transaction of the relay
+func (s *Service) publishEvent(ctx context.Context, tx *sql.Tx, event *models.DomainEvent) error {
+	if err := s.enqueueWebhooks(ctx, tx, event); err != nil {
+		return err
+	}
+
+	for _, eventSink := range s.sinks {
+		if err := eventSink.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getRelayRetry gets how long an event waits after its failed attempts, false if it ran out of attempts
+func (s *Service) getRelayRetry(attempts int) (time.Duration, bool) {
+	if attempts >= s.outboxMaxAttempts {
+		return 0, false
+	}
+
+	retryIn := relayBackoff
+	for i := 1; i < attempts && retryIn < relayMaxBackoff; i++ {
+		retryIn *= 2
+	}
+
+	return min(retryIn, relayMaxBackoff), true
+}
+
+// PurgeEvents deletes the published and the failed events older than retention, it returns the count of deleted ones
+func (s *Service) PurgeEvents(ctx context.Context, retention time.Duration) (int, error) {
+	return s.storage.OutboxStorage.PurgeEvents(ctx, time.Now().UTC().Add(-retention))
+}
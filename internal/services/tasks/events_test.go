@@ -0,0 +1,176 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/pkg/sink"
+	"sso_3.0/internal/storage/postgres/outbox"
+	"testing"
+	"time"
+)
+
+// fakeOutbox relays its pending events like the outbox storage, every event is of its own task
+// an event which could not be published stays pending and its attempts are counted
+type fakeOutbox struct {
+	pending []*models.DomainEvent
+	calls   int
+	// retries are the waits the relay got for the failed events
+	retries []time.Duration
+}
+
+func newFakeOutbox(count int) *fakeOutbox {
+	box := &fakeOutbox{}
+	for id := int64(1); id <= int64(count); id++ {
+		// the status events are not posted to the webhooks, so the relay does not need the storage
+		box.pending = append(box.pending, &models.DomainEvent{Id: id, Type: models.EventStatusUpdated, AggregateId: int(id)})
+	}
+	return box
+}
+
+func (o *fakeOutbox) relay(ctx context.Context, limit int, publish outbox.Publish, retry outbox.Retry) (int, error) {
+	o.calls++
+	var published int
+	var pending []*models.DomainEvent
+	var errs []error
+
+	for i, event := range o.pending {
+		if i == limit {
+			pending = append(pending, o.pending[i:]...)
+			break
+		}
+		if err := publish(ctx, nil, event); err != nil {
+			event.Attempts++
+			if retryIn, ok := retry(event.Attempts); ok {
+				o.retries = append(o.retries, retryIn)
+				pending = append(pending, event)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		published++
+	}
+
+	o.pending = pending
+	return published, errors.Join(errs...)
+}
+
+// flakySink fails once for each of the ids in failOn and records the events it received
+type flakySink struct {
+	failOn   map[int64]bool
+	received []int64
+}
+
+var errSinkDown = errors.New("sink is down")
+
+func (s *flakySink) Publish(ctx context.Context, event *models.DomainEvent) error {
+	if s.failOn[event.Id] {
+		delete(s.failOn, event.Id)
+		return errSinkDown
+	}
+	s.received = append(s.received, event.Id)
+	return nil
+}
+
+func TestRelayEventsRedeliversTheFailedEvent(t *testing.T) {
+	box := newFakeOutbox(5)
+	flaky := &flakySink{failOn: map[int64]bool{3: true}}
+	first := &flakySink{}
+	s := &Service{relay: box.relay, sinks: []sink.Sink{first, flaky}, outboxMaxAttempts: 10}
+
+	// the events of the other tasks are not held up by the failed one
+	published, err := s.RelayEvents(context.Background())
+	if !errors.Is(err, errSinkDown) || published != 4 {
+		t.Fatalf("first RelayEvents() = %d, %v, want 4, %v", published, err, errSinkDown)
+	}
+
+	if len(box.pending) != 1 || box.pending[0].Id != 3 {
+		t.Fatalf("pending after the failure = %d events, want only event 3", len(box.pending))
+	}
+
+	if !slices.Equal(box.retries, []time.Duration{time.Second}) {
+		t.Errorf("retries = %v, want [1s]", box.retries)
+	}
+
+	published, err = s.RelayEvents(context.Background())
+	if err != nil || published != 1 {
+		t.Fatalf("second RelayEvents() = %d, %v, want 1, nil", published, err)
+	}
+
+	if want := []int64{1, 2, 4, 5, 3}; !slices.Equal(flaky.received, want) {
+		t.Errorf("flaky sink received %v, want %v", flaky.received, want)
+	}
+
+	// the sinks before the failed one get the event again, they receive it at least once
+	if want := []int64{1, 2, 3, 4, 5, 3}; !slices.Equal(first.received, want) {
+		t.Errorf("first sink received %v, want %v", first.received, want)
+	}
+}
+
+func TestRelayEventsGivesUpAfterTheLastAttempt(t *testing.T) {
+	box := newFakeOutbox(1)
+	flaky := &flakySink{}
+	s := &Service{relay: box.relay, sinks: []sink.Sink{flaky}, outboxMaxAttempts: 3}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		flaky.failOn = map[int64]bool{1: true}
+		if _, err := s.RelayEvents(context.Background()); !errors.Is(err, errSinkDown) {
+			t.Fatalf("RelayEvents() attempt %d = %v, want %v", attempt, err, errSinkDown)
+		}
+	}
+
+	if len(box.pending) != 0 {
+		t.Errorf("%d events pending after the last attempt, want none", len(box.pending))
+	}
+
+	if want := []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(box.retries, want) {
+		t.Errorf("retries = %v, want %v", box.retries, want)
+	}
+}
+
+func TestRelayEventsRelaysEveryBatch(t *testing.T) {
+	box := newFakeOutbox(2*relayBatchSize + 1)
+	flaky := &flakySink{}
+	s := &Service{relay: box.relay, sinks: []sink.Sink{flaky}}
+
+	published, err := s.RelayEvents(context.Background())
+	if err != nil || published != 2*relayBatchSize+1 {
+		t.Fatalf("RelayEvents() = %d, %v, want %d, nil", published, err, 2*relayBatchSize+1)
+	}
+
+	if box.calls != 3 {
+		t.Errorf("relay was called %d times, want 3", box.calls)
+	}
+
+	for i, id := range flaky.received {
+		if id != int64(i+1) {
+			t.Fatalf("event %d = %d, the events are not in order", i, id)
+		}
+	}
+}
+
+func TestGetRelayRetry(t *testing.T) {
+	s := &Service{outboxMaxAttempts: 12}
+
+	tests := []struct {
+		attempts int
+		retryIn  time.Duration
+		ok       bool
+	}{
+		{1, time.Second, true},
+		{2, 2 * time.Second, true},
+		{3, 4 * time.Second, true},
+		{10, 512 * time.Second, true},
+		{11, 10 * time.Minute, true},
+		{12, 0, false},
+		{20, 0, false},
+	}
+
+	for _, tt := range tests {
+		retryIn, ok := s.getRelayRetry(tt.attempts)
+		if retryIn != tt.retryIn || ok != tt.ok {
+			t.Errorf("getRelayRetry(%d) = %s, %t, want %s, %t", tt.attempts, retryIn, ok, tt.retryIn, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"context"
+	"regexp"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+)
+
+var colorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func (s *Service) CreateLabel(ctx context.Context, name, color string) (*models.Label, error) {
+	if name == "" {
+		return nil, appErrors.NoArguments
+	}
+
+	if !colorRegexp.MatchString(color) {
+		return nil, appErrors.ErrInvalidLabelColor
+	}
+
+	return s.storage.TaskStorage.CreateLabel(ctx, name, color)
+}
+
+func (s *Service) UpdateLabel(ctx context.Context, name, color string, labelId int) (*models.Label, error) {
+	if color != "" && !colorRegexp.MatchString(color) {
+		return nil, appErrors.ErrInvalidLabelColor
+	}
+
+	_, err := s.storage.TaskStorage.GetLabelById(ctx, labelId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storage.TaskStorage.UpdateLabel(ctx, name, color, labelId)
+}
+
+func (s *Service) DeleteLabel(ctx context.Context, id int, currentUser *user.Model) error {
+	return s.storage.TaskStorage.DeleteLabel(ctx, id, currentUser.Id)
+}
+
+func (s *Service) GetAllLabels(ctx context.Context) ([]*models.Label, error) {
+	return s.storage.TaskStorage.GetAllLabels(ctx)
+}
+
+// AddLabel adds the label to the task, only the creator of the task can label it
+func (s *Service) AddLabel(ctx context.Context, taskId, labelId int, currentUser *user.Model) (*models.Task, error) {
+	previous, err := s.verifyUserIsTaskCreator(ctx, taskId, currentUser)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := s.storage.TaskStorage.AddLabel(ctx, taskId, labelId, currentUser.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(models.TaskUpdated, task, previous, currentUser.Id, "")
+
+	return task, nil
+}
+
+// RemoveLabel removes the label from the task, only the creator of the task can do it
+func (s *Service) RemoveLabel(ctx context.Context, taskId, labelId int, currentUser *user.Model) (*models.Task, error) {
+	previous, err := s.verifyUserIsTaskCreator(ctx, taskId, currentUser)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := s.storage.TaskStorage.RemoveLabel(ctx, taskId, labelId, currentUser.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(models.TaskUpdated, task, previous, currentUser.Id, "")
+
+	return task, nil
+}
@@ -0,0 +1,112 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/notifier"
+	"sso_3.0/internal/pkg/secret"
+	"strings"
+)
+
+// InviteMember invites the email to the project with the role, only the owners of the project can invite
+// the token is sent to the email and returned, so the owner can also share it
+func (s *Service) InviteMember(ctx context.Context, projectId int, email string, role models.ProjectRole, currentUser *user.Model) (*models.ProjectInvite, error) {
+	op := "service.tasks.InviteMember"
+	log := s.log.With("op", op)
+
+	if email == "" || role == "" {
+		return nil, appErrors.NoArguments
+	}
+
+	if !role.IsValid() {
+		return nil, appErrors.ErrInvalidProjectRole
+	}
+
+	project, err := s.verifyUserCanManageProject(ctx, projectId, currentUser)
+	if err != nil {
+		return nil, err
+	}
+
+	invited, err := s.storage.UserStorage.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(appErrors.ErrUserNotExists, err) {
+		return nil, err
+	}
+
+	// users which are not registered yet can accept the invite after the registration
+	if invited != nil {
+		current, err := s.storage.ProjectStorage.GetMemberRole(ctx, projectId, invited.Id)
+		if err != nil {
+			return nil, err
+		}
+		if current != "" {
+			return nil, appErrors.ErrAlreadyProjectMember
+		}
+	}
+
+	token, err := secret.New()
+	if err != nil {
+		return nil, err
+	}
+
+	invite := &models.ProjectInvite{Token: token, ProjectId: projectId, Email: strings.ToLower(email), Role: role}
+
+	invite.ExpiresAt, err = s.storage.ProjectStorage.CreateInvite(ctx, invite, secret.Hash(token), currentUser.Id, s.inviteTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.notifier.Notify(ctx, &notifier.Message{
+		To:      invite.Email,
+		Subject: fmt.Sprintf("You are invited to %s", project.Name),
+		Body:    fmt.Sprintf("%s invited you to the project %s as %s, accept it until %s with this token: %s", currentUser.Email, project.Name, role, invite.ExpiresAt.Format("2006-01-02 15:04 MST"), token),
+	})
+	// the token is also returned, so the invite is not lost
+	if err != nil {
+		log.Error("Error on sending invite", "errors", err)
+	}
+
+	return invite, nil
+}
+
+// AcceptInvite makes the user a member of the project, the invite has to be for the email of the user
+func (s *Service) AcceptInvite(ctx context.Context, token string, currentUser *user.Model) (*models.ProjectMember, error) {
+	if token == "" {
+		return nil, appErrors.NoArguments
+	}
+
+	return s.storage.ProjectStorage.AcceptInvite(ctx, secret.Hash(token), currentUser.Id, currentUser.Email)
+}
+
+// RemoveMember removes the user from the project and unassigns the user from its tasks
+// owners can remove all members, the other members can only leave
+func (s *Service) RemoveMember(ctx context.Context, projectId int, userId string, currentUser *user.Model) error {
+	if userId == "" {
+		return appErrors.NoArguments
+	}
+
+	if userId != currentUser.Id {
+		if _, err := s.verifyUserCanManageProject(ctx, projectId, currentUser); err != nil {
+			return err
+		}
+	}
+
+	return s.storage.ProjectStorage.RemoveMember(ctx, projectId, userId, currentUser.Id)
+}
+
+// ListMembers gets the members of the project, only its members can see them
+func (s *Service) ListMembers(ctx context.Context, projectId int, currentUser *user.Model) ([]*models.ProjectMember, error) {
+	if _, err := s.GetProject(ctx, projectId, currentUser); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ProjectStorage.GetMembers(ctx, projectId)
+}
+
+// PurgeExpiredInvites deletes the invites which can not be accepted anymore, it returns their count
+func (s *Service) PurgeExpiredInvites(ctx context.Context) (int, error) {
+	return s.storage.ProjectStorage.PurgeExpiredInvites(ctx)
+}
@@ -0,0 +1,129 @@
+package tasks
+
+import (
+	"context"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+)
+
+// CreateProject creates a project, the creator is its owner
+func (s *Service) CreateProject(ctx context.Context, name, description string, currentUser *user.Model) (*models.Project, error) {
+	if name == "" {
+		return nil, appErrors.NoArguments
+	}
+
+	return s.storage.ProjectStorage.CreateProject(ctx, name, description, currentUser.Id)
+}
+
+// GetProject gets the project, only its members can see it
+func (s *Service) GetProject(ctx context.Context, id int, currentUser *user.Model) (*models.Project, error) {
+	project, err := s.storage.ProjectStorage.GetProjectById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyProjectRole(ctx, id, currentUser, models.ProjectViewer); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// GetAllProjects gets the projects the user is a member of, admins get all projects
+func (s *Service) GetAllProjects(ctx context.Context, currentUser *user.Model) ([]*models.Project, error) {
+	return s.storage.ProjectStorage.GetAllProjects(ctx, getMemberId(currentUser))
+}
+
+// UpdateProject updates the project, only the owners of the project can do it
+func (s *Service) UpdateProject(ctx context.Context, name, description string, id int, currentUser *user.Model) (*models.Project, error) {
+	if _, err := s.verifyUserCanManageProject(ctx, id, currentUser); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ProjectStorage.UpdateProject(ctx, name, description, id)
+}
+
+// DeleteProject deletes the project with its statuses, only the owners of the project can do it
+func (s *Service) DeleteProject(ctx context.Context, id int, currentUser *user.Model) error {
+	if _, err := s.verifyUserCanManageProject(ctx, id, currentUser); err != nil {
+		return err
+	}
+
+	return s.storage.ProjectStorage.DeleteProject(ctx, id, currentUser.Id)
+}
+
+// verifyUserCanManageProject checks if the user owns the project and returns the project
+func (s *Service) verifyUserCanManageProject(ctx context.Context, projectId int, currentUser *user.Model) (*models.Project, error) {
+	project, err := s.storage.ProjectStorage.GetProjectById(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyProjectRole(ctx, projectId, currentUser, models.ProjectOwner); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// verifyUserCanManageStatus checks if the user can manage the statuses of the project
+// the shared statuses with projectId 0 can only be managed by admins
+func (s *Service) verifyUserCanManageStatus(ctx context.Context, projectId int, currentUser *user.Model) error {
+	if projectId == 0 {
+		if currentUser.Role != user.RoleAdmin {
+			return appErrors.ErrNoPermission
+		}
+		return nil
+	}
+
+	_, err := s.verifyUserCanManageProject(ctx, projectId, currentUser)
+	return err
+}
+
+// getProjectRole gets the role of the user in the project, it is empty for users which are not members
+// admins are owners of all projects
+func (s *Service) getProjectRole(ctx context.Context, projectId int, currentUser *user.Model) (models.ProjectRole, error) {
+	if currentUser.Role == user.RoleAdmin {
+		return models.ProjectOwner, nil
+	}
+
+	return s.storage.ProjectStorage.GetMemberRole(ctx, projectId, currentUser.Id)
+}
+
+// verifyProjectRole checks if the user has at least the role in the project
+func (s *Service) verifyProjectRole(ctx context.Context, projectId int, currentUser *user.Model, role models.ProjectRole) error {
+	current, err := s.getProjectRole(ctx, projectId, currentUser)
+	if err != nil {
+		return err
+	}
+
+	if !current.Includes(role) {
+		return appErrors.ErrNoPermission
+	}
+
+	return nil
+}
+
+// getTaskForRole gets the task if the user has at least the role in the project of the task
+func (s *Service) getTaskForRole(ctx context.Context, taskId int, currentUser *user.Model, role models.ProjectRole) (*models.Task, error) {
+	task, err := s.GetTaskById(ctx, taskId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.verifyProjectRole(ctx, task.ProjectId, currentUser, role); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// getMemberId gets the MemberId of the task filters, admins can see the tasks of all projects
+func getMemberId(currentUser *user.Model) string {
+	if currentUser.Role == user.RoleAdmin {
+		return ""
+	}
+
+	return currentUser.Id
+}
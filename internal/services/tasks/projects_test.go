@@ -0,0 +1,93 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres"
+	"sso_3.0/internal/storage/postgres/pgtest"
+	"sso_3.0/internal/storage/postgres/project"
+	"sso_3.0/internal/storage/postgres/task"
+	userStorage "sso_3.0/internal/storage/postgres/user"
+	"testing"
+	"time"
+)
+
+func TestProjectMembership(t *testing.T) {
+	db := pgtest.Open(t)
+	log := pgtest.Logger()
+	ctx := context.Background()
+	s := &Service{log: log, storage: &postgres.Storage{TaskStorage: task.New(db, log), ProjectStorage: project.New(db, log)}}
+	users := userStorage.New(db, log)
+
+	register := func() *user.Model {
+		u, err := users.Register(ctx, pgtest.Email(t), "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	owner, editor, viewer, outsider := register(), register(), register(), register()
+	admin := register()
+	admin.Role = user.RoleAdmin
+
+	p, err := s.storage.ProjectStorage.CreateProject(ctx, "membership", "", owner.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for member, role := range map[*user.Model]models.ProjectRole{editor: models.ProjectEditor, viewer: models.ProjectViewer} {
+		if _, err = db.ExecContext(ctx, "INSERT INTO project_members (projectId, userId, role) VALUES ($1, $2, $3)", p.Id, member.Id, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err := s.storage.TaskStorage.CreateStatus(ctx, "todo", "", p.Id, owner.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := s.storage.TaskStorage.CreateTask(ctx, "task", "", owner.Id, p.Id, status.Id, 0, models.PriorityNone, time.Time{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		user *user.Model
+		role models.ProjectRole
+		err  error
+	}{
+		{name: "owner manages", user: owner, role: models.ProjectOwner},
+		{name: "editor edits", user: editor, role: models.ProjectEditor},
+		{name: "editor reads", user: editor, role: models.ProjectViewer},
+		{name: "editor can not manage", user: editor, role: models.ProjectOwner, err: appErrors.ErrNoPermission},
+		{name: "viewer reads", user: viewer, role: models.ProjectViewer},
+		{name: "viewer can not edit", user: viewer, role: models.ProjectEditor, err: appErrors.ErrNoPermission},
+		{name: "outsider can not read", user: outsider, role: models.ProjectViewer, err: appErrors.ErrNoPermission},
+		{name: "admin owns every project", user: admin, role: models.ProjectOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.verifyProjectRole(ctx, p.Id, tt.user, tt.role); !errors.Is(err, tt.err) {
+				t.Errorf("verifyProjectRole() = %v, want %v", err, tt.err)
+			}
+
+			got, err := s.getTaskForRole(ctx, created.Id, tt.user, tt.role)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("getTaskForRole() = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && got.Id != created.Id {
+				t.Errorf("getTaskForRole() = task %d, want %d", got.Id, created.Id)
+			}
+		})
+	}
+
+	if _, err = s.getTaskForRole(ctx, -1, owner, models.ProjectViewer); !errors.Is(err, appErrors.ErrTaskNotExists) {
+		t.Errorf("getTaskForRole(unknown task) = %v, want %v", err, appErrors.ErrTaskNotExists)
+	}
+}
@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"context"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/rrule"
+	"time"
+)
+
+// recurBatchSize is the count of recurring tasks read at once
+const recurBatchSize = 100
+
+// RecurTasks creates the next occurrence of every recurring task which is completed or due
+// a task which fails to recur is logged and skipped until the next run, so it does not block the tasks behind it
+// it returns the count of created occurrences
+func (s *Service) RecurTasks(ctx context.Context) (int, error) {
+	log := s.log.With("op", "tasks.service.RecurTasks")
+	var created int
+	var failed []int
+
+	for {
+		tasks, err := s.storage.TaskStorage.GetRecurringTasks(ctx, time.Now().UTC(), recurBatchSize, failed)
+		if err != nil {
+			return created, err
+		}
+
+		for _, task := range tasks {
+			next, err := s.recur(ctx, task)
+			if err != nil {
+				// the run ends with the context, the other errors only concern the task
+				if ctx.Err() != nil {
+					return created, ctx.Err()
+				}
+				log.Error("Error on creating the next occurrence", "taskId", task.Id, "errors", err)
+				failed = append(failed, task.Id)
+				continue
+			}
+			if next != nil {
+				created++
+			}
+		}
+
+		// every task of the batch recurred or failed, so the next batch has other tasks
+		if len(tasks) < recurBatchSize {
+			return created, nil
+		}
+	}
+}
+
+// recur creates the next occurrence of the recurring task, it returns nil if another replica created it already
+// the next occurrence is after the due and after now, so the occurrences missed while the task was overdue are skipped
+func (s *Service) recur(ctx context.Context, task *models.Task) (*models.Task, error) {
+	log := s.log.With("op", "tasks.service.recur")
+
+	rule, err := rrule.Parse(task.Recurrence)
+	if err != nil {
+		log.Error("Error on parsing the recurrence, the series ends", "taskId", task.Id, "errors", err)
+		return nil, s.storage.TaskStorage.EndSeries(ctx, task.Id, task.Recurrence)
+	}
+
+	start := task.RecurrenceStart
+	if start.IsZero() {
+		start = task.Due
+	}
+
+	after := task.Due
+	if now := time.Now().UTC(); now.After(after) {
+		after = now
+	}
+
+	due, ok := rule.Next(start, after)
+	if !ok {
+		return nil, s.storage.TaskStorage.EndSeries(ctx, task.Id, task.Recurrence)
+	}
+
+	next, err := s.storage.TaskStorage.CreateNextOccurrence(ctx, task.Id, task.Recurrence, due)
+	if err != nil || next == nil {
+		return nil, err
+	}
+
+	s.publish(models.TaskCreated, next, nil, "", "")
+
+	return next, nil
+}
+
+// parseRecurrence checks the rule of a recurring task and returns it in the canonical form
+func parseRecurrence(recurrence string) (string, error) {
+	if recurrence == "" {
+		return "", nil
+	}
+
+	rule, err := rrule.Parse(recurrence)
+	if err != nil {
+		return "", appErrors.ErrInvalidRecurrence
+	}
+
+	return rule.String(), nil
+}
+
+// hasDue checks if the due is set, a due which is not set in the request is the unix epoch
+func hasDue(due time.Time) bool {
+	return due.After(time.Unix(0, 0))
+}
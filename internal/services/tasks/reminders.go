@@ -0,0 +1,158 @@
+package tasks
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/notifier"
+	"strings"
+	"time"
+)
+
+const (
+	// reminderBatchSize is the count of reminders claimed at once
+	reminderBatchSize = 100
+	// overdueWindow is how long after the due the overdue notification is still sent
+	// tasks which were overdue long before the reminders were started are not notified
+	overdueWindow = 7 * 24 * time.Hour
+	minLeadTime   = time.Minute
+	maxLeadTime   = 30 * 24 * time.Hour
+	maxLeadTimes  = 10
+	// releaseTimeout is how long the unsent reminders of a batch can take to be released after the run ended
+	releaseTimeout = 10 * time.Second
+)
+
+// GetReminderSettings gets the reminder settings of the user, the configured ones if the user did not set them
+func (s *Service) GetReminderSettings(ctx context.Context, currentUser *user.Model) (*models.ReminderSettings, error) {
+	settings, err := s.storage.TaskStorage.GetReminderSettings(ctx, currentUser.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings == nil {
+		return &models.ReminderSettings{LeadTimes: s.reminderLeadTimes, Overdue: true}, nil
+	}
+
+	return settings, nil
+}
+
+// SetReminderSettings sets how long before the due of their tasks the user is reminded
+// the lead times are rounded to minutes, no lead times and no overdue turn the reminders off
+func (s *Service) SetReminderSettings(ctx context.Context, settings *models.ReminderSettings, currentUser *user.Model) (*models.ReminderSettings, error) {
+	var leadTimes []time.Duration
+
+	if len(settings.LeadTimes) > maxLeadTimes {
+		return nil, appErrors.ErrInvalidLeadTime
+	}
+
+	for _, leadTime := range settings.LeadTimes {
+		leadTime = leadTime.Round(time.Minute)
+		if leadTime < minLeadTime || leadTime > maxLeadTime {
+			return nil, appErrors.ErrInvalidLeadTime
+		}
+		if !slices.Contains(leadTimes, leadTime) {
+			leadTimes = append(leadTimes, leadTime)
+		}
+	}
+
+	// the longest lead time is reminded first
+	slices.SortFunc(leadTimes, func(a, b time.Duration) int { return cmp.Compare(b, a) })
+
+	settings = &models.ReminderSettings{LeadTimes: leadTimes, Overdue: settings.Overdue}
+
+	if err := s.storage.TaskStorage.SetReminderSettings(ctx, currentUser.Id, settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// SendReminders sends the reminders of the tasks which are due soon or overdue
+// every reminder is sent once, a reminder which could not be delivered is sent again on the next call
+// it returns the count of sent reminders
+func (s *Service) SendReminders(ctx context.Context) (int, error) {
+	log := s.log.With("op", "tasks.service.SendReminders")
+	var sent int
+
+	// the configured lead times may be longer than the ones users can set
+	longest := maxLeadTime
+	for _, leadTime := range s.reminderLeadTimes {
+		longest = max(longest, leadTime)
+	}
+
+	for {
+		now := time.Now().UTC()
+
+		reminders, err := s.storage.TaskStorage.ClaimReminders(ctx, now, now.Add(-overdueWindow), now.Add(longest), s.reminderLeadTimes, reminderBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		failed := false
+
+		for i, reminder := range reminders {
+			// the claimed reminders which were not sent are released, so the next call sends them
+			if ctx.Err() != nil {
+				s.releaseReminders(ctx, reminders[i:])
+				return sent, ctx.Err()
+			}
+
+			if err = s.notifier.Notify(ctx, getReminderMessage(reminder, now)); err != nil {
+				log.Error("Error on sending reminder", "taskId", reminder.TaskId, "userId", reminder.User.Id, "errors", err)
+				failed = true
+
+				if err = s.storage.TaskStorage.ReleaseReminder(ctx, reminder); err != nil {
+					s.releaseReminders(ctx, reminders[i:])
+					return sent, err
+				}
+				continue
+			}
+			sent++
+		}
+
+		// the released reminders would be claimed again at once, they wait for the next call
+		if failed || len(reminders) < reminderBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// releaseReminders releases the claimed reminders when the run ends early, the context of the run may be done already
+// so they are released with a short context of their own
+func (s *Service) releaseReminders(ctx context.Context, reminders []*models.Reminder) {
+	log := s.log.With("op", "tasks.service.releaseReminders")
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+
+	for _, reminder := range reminders {
+		if err := s.storage.TaskStorage.ReleaseReminder(ctx, reminder); err != nil {
+			log.Error("Error on releasing the reminder, it is not sent", "taskId", reminder.TaskId, "userId", reminder.User.Id, "errors", err)
+		}
+	}
+}
+
+// getReminderMessage is the message of the reminder, the lead time 0 is the overdue notification
+func getReminderMessage(reminder *models.Reminder, now time.Time) *notifier.Message {
+	due := reminder.Due.Format("2006-01-02 15:04 MST")
+	// the subject must not contain line breaks
+	title := strings.NewReplacer("\r", " ", "\n", " ").Replace(reminder.Title)
+
+	if reminder.LeadTime == 0 {
+		return &notifier.Message{
+			To:      reminder.User.Email,
+			Subject: fmt.Sprintf("Overdue: %s", title),
+			Body:    fmt.Sprintf("The task %s (%d) was due at %s and is not completed yet", reminder.Title, reminder.TaskId, due),
+		}
+	}
+
+	return &notifier.Message{
+		To:      reminder.User.Email,
+		Subject: fmt.Sprintf("Reminder: %s is due in %s", title, reminder.Due.Sub(now).Round(time.Minute)),
+		Body:    fmt.Sprintf("The task %s (%d) is due at %s", reminder.Title, reminder.TaskId, due),
+	}
+}
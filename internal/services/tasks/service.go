@@ -2,75 +2,332 @@ package tasks
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 	"log/slog"
+	"slices"
+	configParser "sso_3.0/internal/config"
 	"sso_3.0/internal/domain/models"
 	"sso_3.0/internal/domain/user"
 	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/eventbus"
+	"sso_3.0/internal/pkg/notifier"
+	"sso_3.0/internal/pkg/sink"
+	"sso_3.0/internal/pkg/webhook"
 	"sso_3.0/internal/storage/postgres"
+	"strings"
 	"time"
 )
 
 type Service struct {
-	log     *slog.Logger
-	storage *postgres.Storage
+	log       *slog.Logger
+	storage   *postgres.Storage
+	bus       *eventbus.Bus
+	notifier  notifier.Notifier
+	inviteTTL time.Duration
+	// reminderLeadTimes are the lead times of the users who did not set their own
+	reminderLeadTimes  []time.Duration
+	webhookSender      *webhook.Sender
+	webhookMaxAttempts int
+	// sinks receive the events of the outbox
+	sinks []sink.Sink
+	relay Relay
+	// outboxMaxAttempts is how often an event is published before it is given up
+	outboxMaxAttempts int
 }
 
-func New(log *slog.Logger, storage *postgres.Storage) *Service {
-	return &Service{log: log, storage: storage}
+func New(log *slog.Logger, storage *postgres.Storage, bus *eventbus.Bus, notifier notifier.Notifier, sinks []sink.Sink, cfg *configParser.Config) *Service {
+	return &Service{
+		log:                log,
+		storage:            storage,
+		bus:                bus,
+		notifier:           notifier,
+		inviteTTL:          cfg.ProjectInviteTTL,
+		reminderLeadTimes:  cfg.ReminderLeadTimes,
+		webhookSender:      webhook.NewSender(),
+		webhookMaxAttempts: cfg.WebhookMaxAttempts,
+		sinks:              sinks,
+		relay:              storage.OutboxStorage.Relay,
+		outboxMaxAttempts:  cfg.OutboxMaxAttempts,
+	}
 }
 
-func (s *Service) CreateTask(ctx context.Context, title, description, creatorId string, statusId int, due time.Time) (*models.Task, error) {
+// CreateTask creates a task in the project, the editors of the project can create tasks
+// with parentId it is created as a subtask of that task
+// subtasks can be created by the creator and the assignees of the parent, they are in the project of the parent
+// with a recurrence the task is the first occurrence of a series, so it needs a due
+func (s *Service) CreateTask(ctx context.Context, title, description string, creator *user.Model, projectId, statusId, parentId int, priority models.Priority, due time.Time, recurrence string) (*models.Task, error) {
+	if projectId == 0 {
+		return nil, appErrors.ErrProjectRequired
+	}
 
-	task, err := s.storage.TaskStorage.CreateTask(ctx, title, description, creatorId, statusId, due)
+	if !priority.IsValid() {
+		return nil, appErrors.ErrInvalidPriority
+	}
 
+	recurrence, err := parseRecurrence(recurrence)
 	if err != nil {
 		return nil, err
 	}
 
+	if recurrence != "" && !hasDue(due) {
+		return nil, appErrors.ErrRecurrenceNeedsDue
+	}
+
+	if _, err := s.storage.ProjectStorage.GetProjectById(ctx, projectId); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyProjectRole(ctx, projectId, creator, models.ProjectEditor); err != nil {
+		return nil, err
+	}
+
+	if statusId != 0 {
+		status, err := s.storage.TaskStorage.GetStatusById(ctx, statusId)
+		if err != nil {
+			return nil, err
+		}
+
+		if !status.IsUsableIn(projectId) {
+			return nil, appErrors.ErrStatusNotInProject
+		}
+	}
+
+	if parentId != 0 {
+		parent, err := s.GetTaskById(ctx, parentId)
+		if err != nil {
+			if errors.Is(appErrors.ErrTaskNotExists, err) {
+				return nil, appErrors.ErrParentTaskNotExists
+			}
+			return nil, err
+		}
+
+		if parent.CreatorId != creator.Id && !parent.IsAssigned(creator.Id) {
+			return nil, appErrors.ErrNoPermission
+		}
+
+		if parent.ProjectId != projectId {
+			return nil, appErrors.ErrParentNotInProject
+		}
+	}
+
+	task, err := s.storage.TaskStorage.CreateTask(ctx, title, description, creator.Id, projectId, statusId, parentId, priority, due, recurrence)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(models.TaskCreated, task, nil, creator.Id, "")
+
 	return task, nil
 }
-func (s *Service) DeleteTask(ctx context.Context, id int, currentUser *user.Model) error {
-	err := s.verifyUserIsTaskCreator(ctx, id, currentUser.Id)
+func (s *Service) DeleteTask(ctx context.Context, id int, policy models.SubtaskPolicy, currentUser *user.Model) error {
+	var subtasks []*models.Task
+
+	task, err := s.verifyUserIsTaskCreator(ctx, id, currentUser)
+	if err != nil {
+		return err
+	}
+
+	// get the subtasks changed by the policy to notify the watchers
+	switch policy {
+	case models.DeleteSubtasks:
+		subtasks, err = s.storage.TaskStorage.GetSubtasks(ctx, id, true)
+	case models.DetachSubtasks:
+		subtasks, err = s.storage.TaskStorage.GetSubtasks(ctx, id, false)
+	}
 	if err != nil {
 		return err
 	}
 
-	err = s.storage.TaskStorage.DeleteTask(ctx, id)
+	err = s.storage.TaskStorage.DeleteTask(ctx, id, policy, currentUser.Id)
 
 	if err != nil {
 		return err
 	}
 
+	s.publish(models.TaskDeleted, task, task, currentUser.Id, "")
+
+	for _, subtask := range subtasks {
+		if policy == models.DeleteSubtasks {
+			s.publish(models.TaskDeleted, subtask, subtask, currentUser.Id, "")
+			continue
+		}
+
+		detached := *subtask
+		detached.ParentId = task.ParentId
+		s.publish(models.TaskUpdated, &detached, subtask, currentUser.Id, "")
+	}
+
 	return nil
 }
-func (s *Service) UpdateTask(ctx context.Context, title, description string, due time.Time, statusId, id int, completed *wrapperspb.BoolValue, user *user.Model) (*models.Task, error) {
+
+// UpdateTask updates the task
+// a task can only be completed when all subtasks are completed, with cascade they are completed too
+// completing an occurrence of a recurring task creates the next occurrence
+func (s *Service) UpdateTask(ctx context.Context, title, description string, due time.Time, statusId, id int, completed *wrapperspb.BoolValue, priority *models.Priority, recurrence *string, cascade bool, user *user.Model) (*models.Task, error) {
 	var status *models.Status = nil
+	var subtasks []*models.Task
+
+	if priority != nil && !priority.IsValid() {
+		return nil, appErrors.ErrInvalidPriority
+	}
+
+	if recurrence != nil {
+		rule, err := parseRecurrence(*recurrence)
+		if err != nil {
+			return nil, err
+		}
+		recurrence = &rule
+	}
 
-	err := s.verifyUserIsTaskCreator(ctx, id, user.Id)
+	previous, err := s.verifyUserIsTaskCreator(ctx, id, user)
 	if err != nil {
 		return nil, err
 	}
 
+	if recurrence != nil && *recurrence != "" && due.IsZero() && !hasDue(previous.Due) {
+		return nil, appErrors.ErrRecurrenceNeedsDue
+	}
+
 	// check if status is to update
 	if statusId != 0 {
 		status, err = s.storage.TaskStorage.GetStatusById(ctx, statusId)
 		if err != nil {
 			return nil, err
 		}
+
+		if !status.IsUsableIn(previous.ProjectId) {
+			return nil, appErrors.ErrStatusNotInProject
+		}
+	}
+
+	// get the subtasks completed by the cascade to notify the watchers
+	if cascade && completed != nil && completed.Value {
+		subtasks, err = s.storage.TaskStorage.GetSubtasks(ctx, id, true)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// update task
-	task, err := s.storage.TaskStorage.UpdateTask(ctx, title, description, due, status, completed, id)
+	task, err := s.storage.TaskStorage.UpdateTask(ctx, title, description, due, status, completed, priority, recurrence, cascade, id, user.Id)
 	if err != nil {
 		return nil, err
 	}
 
+	s.publish(models.TaskUpdated, task, previous, user.Id, "")
+
+	for _, subtask := range subtasks {
+		if subtask.Completed != nil && subtask.Completed.Value {
+			continue
+		}
+
+		updated := *subtask
+		updated.Completed = wrapperspb.Bool(true)
+		s.publish(models.TaskUpdated, &updated, subtask, user.Id, "")
+	}
+
+	// the generator creates the next occurrence later if it fails here
+	if task.Recurrence != "" && task.Completed != nil && task.Completed.Value {
+		if _, err = s.recur(ctx, task); err != nil {
+			s.log.Error("Error on creating the next occurrence", "taskId", task.Id, "errors", err)
+		}
+	}
+
 	return task, nil
 }
-func (s *Service) CreateStatus(ctx context.Context, title, description string) (*models.Status, error) {
-	status, err := s.storage.TaskStorage.CreateStatus(ctx, title, description)
+
+// RestoreTask moves the task out of the trash, only the creator of the task can restore it
+// the subtasks deleted together with the task are restored too
+func (s *Service) RestoreTask(ctx context.Context, id int, currentUser *user.Model) (*models.Task, error) {
+	previous, err := s.storage.TaskStorage.GetDeletedTaskById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous.CreatorId != currentUser.Id {
+		return nil, appErrors.ErrNoPermission
+	}
+
+	if err = s.verifyProjectRole(ctx, previous.ProjectId, currentUser, models.ProjectEditor); err != nil {
+		return nil, err
+	}
+
+	task, subtasks, err := s.storage.TaskStorage.RestoreTask(ctx, id, currentUser.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(models.TaskRestored, task, previous, currentUser.Id, "")
+
+	for _, subtask := range subtasks {
+		s.publish(models.TaskRestored, subtask, nil, currentUser.Id, "")
+	}
+
+	return task, nil
+}
+
+// ListDeletedTasks gets one page of the tasks in the trash created by the user, the last deleted first
+// only the tasks of the projects the user is still a member of
+func (s *Service) ListDeletedTasks(ctx context.Context, currentUser *user.Model, pageSize int, pageToken string) ([]*models.Task, string, error) {
+	if pageSize < 0 {
+		return nil, "", appErrors.ErrInvalidPageSize
+	}
+
+	filters := &models.TaskFilters{
+		CreatedByMe: true,
+		MemberId:    getMemberId(currentUser),
+		Deleted:     true,
+		PageSize:    pageSize,
+		PageToken:   pageToken,
+		SortBy:      models.SortByDeletedAt,
+		SortDesc:    true,
+	}
+
+	return s.storage.TaskStorage.GetCreatedTasksByFilter(ctx, filters, currentUser.Id)
+}
+
+// PurgeDeletedTasks permanently deletes the tasks which are longer than the retention in the trash
+// it returns the count of purged tasks
+func (s *Service) PurgeDeletedTasks(ctx context.Context, retention time.Duration) (int, error) {
+	const batchSize = 500
+	var purged int
+
+	// purge in batches to keep the transactions short
+	for {
+		count, err := s.storage.TaskStorage.PurgeDeletedTasks(ctx, retention, batchSize)
+		purged += count
+		if err != nil {
+			return purged, err
+		}
+
+		if count < batchSize {
+			return purged, nil
+		}
+	}
+}
+
+// GetSubtasks gets the subtasks of the task, with recursive also the nested ones
+// subtasks are in the project of the task, so only its members can see them
+func (s *Service) GetSubtasks(ctx context.Context, taskId int, recursive bool, currentUser *user.Model) ([]*models.Task, error) {
+	_, err := s.getTaskForRole(ctx, taskId, currentUser, models.ProjectViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storage.TaskStorage.GetSubtasks(ctx, taskId, recursive)
+}
+
+// CreateStatus creates a status for the tasks of the project, projectId 0 creates a status shared by all projects
+// shared statuses can be managed by admins, the ones of a project also by the creator of the project
+func (s *Service) CreateStatus(ctx context.Context, title, description string, projectId int, currentUser *user.Model) (*models.Status, error) {
+	if err := s.verifyUserCanManageStatus(ctx, projectId, currentUser); err != nil {
+		return nil, err
+	}
+
+	status, err := s.storage.TaskStorage.CreateStatus(ctx, title, description, projectId, currentUser.Id)
 
 	if err != nil {
 		return nil, err
@@ -79,8 +336,17 @@ func (s *Service) CreateStatus(ctx context.Context, title, description string) (
 	return status, nil
 }
 
-func (s *Service) DeleteStatus(ctx context.Context, id int) error {
-	err := s.storage.TaskStorage.DeleteStatus(ctx, id)
+func (s *Service) DeleteStatus(ctx context.Context, id int, currentUser *user.Model) error {
+	status, err := s.storage.TaskStorage.GetStatusById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = s.verifyUserCanManageStatus(ctx, status.ProjectId, currentUser); err != nil {
+		return err
+	}
+
+	err = s.storage.TaskStorage.DeleteStatus(ctx, id, currentUser.Id)
 
 	if err != nil {
 		return err
@@ -89,14 +355,18 @@ func (s *Service) DeleteStatus(ctx context.Context, id int) error {
 	return nil
 }
 
-func (s *Service) UpdateStatus(ctx context.Context, title, description string, statusId int) (*models.Status, error) {
-	_, err := s.storage.TaskStorage.GetStatusById(ctx, statusId)
+func (s *Service) UpdateStatus(ctx context.Context, title, description string, statusId int, currentUser *user.Model) (*models.Status, error) {
+	previous, err := s.storage.TaskStorage.GetStatusById(ctx, statusId)
 
 	if err != nil {
 		return nil, err
 	}
 
-	status, err := s.storage.TaskStorage.UpdateStatus(ctx, title, description, statusId)
+	if err = s.verifyUserCanManageStatus(ctx, previous.ProjectId, currentUser); err != nil {
+		return nil, err
+	}
+
+	status, err := s.storage.TaskStorage.UpdateStatus(ctx, title, description, statusId, currentUser.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -110,68 +380,271 @@ func (s *Service) GetTaskById(ctx context.Context, taskId int) (*models.Task, er
 	return task, nil
 }
 
-func (s *Service) GetCreatedTasksByFilter(ctx context.Context, userId string, filters *models.TaskFilters) ([]*models.Task, error) {
-	tasks, err := s.storage.TaskStorage.GetCreatedTasksByFilter(ctx, filters, userId)
+// GetCreatedTasksByFilter gets one page of tasks and the token of the next page
+// only the tasks of the projects the user is a member of
+func (s *Service) GetCreatedTasksByFilter(ctx context.Context, currentUser *user.Model, filters *models.TaskFilters) ([]*models.Task, string, error) {
+	if filters.PageSize < 0 {
+		return nil, "", appErrors.ErrInvalidPageSize
+	}
+
+	if (filters.MinPriority != nil && !filters.MinPriority.IsValid()) || (filters.MaxPriority != nil && !filters.MaxPriority.IsValid()) {
+		return nil, "", appErrors.ErrInvalidPriority
+	}
+
+	filters.MemberId = getMemberId(currentUser)
+
+	tasks, nextPageToken, err := s.storage.TaskStorage.GetCreatedTasksByFilter(ctx, filters, currentUser.Id)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return tasks, nil
+	return tasks, nextPageToken, nil
 }
 
+// SearchTasks gets one page of tasks matching the full text query and the token of the next page
+// only the tasks of the projects the user is a member of
+func (s *Service) SearchTasks(ctx context.Context, currentUser *user.Model, query string, filters *models.TaskFilters) ([]*models.TaskSearchResult, string, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, "", appErrors.ErrEmptySearchQuery
+	}
+
+	if filters.PageSize < 0 {
+		return nil, "", appErrors.ErrInvalidPageSize
+	}
+
+	filters.MemberId = getMemberId(currentUser)
+
+	results, nextPageToken, err := s.storage.TaskStorage.SearchTasks(ctx, query, filters, currentUser.Id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return results, nextPageToken, nil
+}
+
+// AssignTask assigns the user to the task, only members of the project of the task can be assigned
 func (s *Service) AssignTask(ctx context.Context, userId, role string, taskId int, currentUser *user.Model) (*models.Task, error) {
-	err := s.verifyUserIsTaskCreator(ctx, taskId, currentUser.Id)
+	previous, err := s.verifyUserIsTaskCreator(ctx, taskId, currentUser)
 	if err != nil {
 		return nil, err
 	}
 
-	task, err := s.storage.TaskStorage.AssignTask(ctx, userId, role, taskId)
+	assigneeRole, err := s.storage.ProjectStorage.GetMemberRole(ctx, previous.ProjectId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if assigneeRole == "" {
+		return nil, appErrors.ErrNotProjectMember
+	}
+
+	task, err := s.storage.TaskStorage.AssignTask(ctx, userId, role, taskId, currentUser.Id)
 
 	if err != nil {
 		return nil, err
 	}
 
+	s.publish(models.TaskAssigned, task, previous, currentUser.Id, userId)
+
 	return task, nil
 }
 
 func (s *Service) UnAssignTask(ctx context.Context, userId string, taskId int, currentUser *user.Model) (*models.Task, error) {
 
-	err := s.verifyUserIsTaskCreator(ctx, taskId, currentUser.Id)
+	previous, err := s.verifyUserIsTaskCreator(ctx, taskId, currentUser)
 	if err != nil {
 		return nil, err
 	}
 
-	task, err := s.storage.TaskStorage.UnAssignTask(ctx, userId, taskId)
+	task, err := s.storage.TaskStorage.UnAssignTask(ctx, userId, taskId, currentUser.Id)
 	if err != nil {
 		return nil, err
 	}
 
+	s.publish(models.TaskUnassigned, task, previous, currentUser.Id, userId)
+
 	return task, nil
 }
 
-func (s *Service) verifyUserIsTaskCreator(ctx context.Context, taskId int, userId string) error {
+// AddDependency adds the dependency that the blocker task blocks the blocked task
+// only the creator of the blocked task can add its blockers
+func (s *Service) AddDependency(ctx context.Context, blockerId, blockedId int, currentUser *user.Model) (*models.Dependency, error) {
+	_, err := s.verifyUserIsTaskCreator(ctx, blockedId, currentUser)
+	if err != nil {
+		return nil, err
+	}
+
+	// check that the blocker exists and the user can see it
+	_, err = s.getTaskForRole(ctx, blockerId, currentUser, models.ProjectViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storage.TaskStorage.AddDependency(ctx, blockerId, blockedId)
+}
+
+// RemoveDependency removes the dependency that the blocker task blocks the blocked task
+func (s *Service) RemoveDependency(ctx context.Context, blockerId, blockedId int, currentUser *user.Model) error {
+	_, err := s.verifyUserIsTaskCreator(ctx, blockedId, currentUser)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.TaskStorage.RemoveDependency(ctx, blockerId, blockedId)
+}
+
+// GetDependencyGraph gets the tasks blocking and blocked by the task
+// the dependencies can cross projects, the tasks of projects the user is not a member of are left out
+func (s *Service) GetDependencyGraph(ctx context.Context, taskId int, currentUser *user.Model) (*models.DependencyGraph, error) {
+	_, err := s.getTaskForRole(ctx, taskId, currentUser, models.ProjectViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := s.storage.TaskStorage.GetDependencyGraph(ctx, taskId)
+	if err != nil || currentUser.Role == user.RoleAdmin {
+		return graph, err
+	}
+
+	projectIds, err := s.storage.ProjectStorage.GetMemberProjectIds(ctx, currentUser.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make(map[int]bool)
+	filtered := &models.DependencyGraph{}
+
+	for _, task := range graph.Tasks {
+		if slices.Contains(projectIds, task.ProjectId) {
+			visible[task.Id] = true
+			filtered.Tasks = append(filtered.Tasks, task)
+		}
+	}
+
+	for _, dependency := range graph.Dependencies {
+		if visible[dependency.BlockerId] && visible[dependency.BlockedId] {
+			filtered.Dependencies = append(filtered.Dependencies, dependency)
+		}
+	}
+
+	return filtered, nil
+}
+
+// GetTaskHistory gets one page of the changes of the task, the newest first, and the token of the next page
+// the history of deleted tasks is kept, only the members of the project can read it
+// the project of purged tasks is unknown, so only admins can read their history
+func (s *Service) GetTaskHistory(ctx context.Context, taskId, pageSize int, pageToken string, currentUser *user.Model) ([]*models.TaskHistoryEntry, string, error) {
+	if pageSize < 0 {
+		return nil, "", appErrors.ErrInvalidPageSize
+	}
+
 	task, err := s.GetTaskById(ctx, taskId)
+	if errors.Is(appErrors.ErrTaskNotExists, err) {
+		task, err = s.storage.TaskStorage.GetDeletedTaskById(ctx, taskId)
+	}
+
+	if err != nil {
+		if !errors.Is(appErrors.ErrTaskNotExists, err) || currentUser.Role != user.RoleAdmin {
+			return nil, "", err
+		}
+	} else if err = s.verifyProjectRole(ctx, task.ProjectId, currentUser, models.ProjectViewer); err != nil {
+		return nil, "", err
+	}
+
+	entries, nextPageToken, err := s.storage.TaskStorage.GetTaskHistory(ctx, taskId, pageSize, pageToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// tasks created before the history was recorded have no entries
+	if len(entries) == 0 && pageToken == "" && task == nil {
+		return nil, "", appErrors.ErrTaskNotExists
+	}
+
+	return entries, nextPageToken, nil
+}
+
+// WatchTasks sends every task event matching the filters until ctx is done or send fails
+// an event matches if the task matches before or after the change
+// the membership is checked for every event, so removed members do not get the events of the project anymore
+func (s *Service) WatchTasks(ctx context.Context, currentUser *user.Model, filters *models.TaskFilters, send func(event *models.TaskEvent) error) error {
+	events, unsubscribe := s.bus.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			// the bus dropped the subscription
+			if !ok {
+				return appErrors.ErrWatchTooSlow
+			}
+
+			if !filters.Match(event.Task, currentUser.Id) && !filters.Match(event.Previous, currentUser.Id) {
+				continue
+			}
+
+			role, err := s.getProjectRole(ctx, event.Task.ProjectId, currentUser)
+			if err != nil {
+				return err
+			}
+
+			if !role.Includes(models.ProjectViewer) {
+				continue
+			}
+
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// publish publishes a task event to the watchers
+// the watchers may miss events, the reliable ones are written to the outbox by the storage
+func (s *Service) publish(eventType models.TaskEventType, task, previous *models.Task, actorId, userId string) {
+	s.bus.Publish(&models.TaskEvent{
+		Type:     eventType,
+		Task:     task,
+		Previous: previous,
+		ActorId:  actorId,
+		UserId:   userId,
+		At:       time.Now(),
+	})
+}
+
+// verifyUserIsTaskCreator checks if the user created the task and can still edit the tasks of its project and returns the task
+func (s *Service) verifyUserIsTaskCreator(ctx context.Context, taskId int, currentUser *user.Model) (*models.Task, error) {
+	task, err := s.getTaskForRole(ctx, taskId, currentUser, models.ProjectEditor)
 
 	if err != nil {
 		fmt.Println(err)
-		return err
+		return nil, err
 	}
 
 	//check if Creator is owner of the task
-	if userId != task.CreatorId {
-		return appErrors.ErrNoPermission
+	if currentUser.Id != task.CreatorId {
+		return nil, appErrors.ErrNoPermission
 	}
 
-	return nil
+	return task, nil
 }
 
-func (s *Service) GetAllStatuses(ctx context.Context) ([]*models.Status, error) {
-	log := s.log.With("tasks.service.GetAllStatuses")
+// GetAllStatuses gets all statuses, with projectId only the ones usable in that project
+func (s *Service) GetAllStatuses(ctx context.Context, projectId int, currentUser *user.Model) ([]*models.Status, error) {
+	log := s.log.With("op", "tasks.service.GetAllStatuses")
+
+	if projectId != 0 {
+		if err := s.verifyProjectRole(ctx, projectId, currentUser, models.ProjectViewer); err != nil {
+			return nil, err
+		}
+	}
 
-	statuses, err := s.storage.TaskStorage.GetAllStatuses(ctx)
+	statuses, err := s.storage.TaskStorage.GetAllStatuses(ctx, projectId)
 
 	if err != nil {
-		log.Error("Error: ", err)
+		log.Error("Error", "errors", err)
 		return nil, err
 	}
 
@@ -0,0 +1,213 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/url"
+	"slices"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/pkg/secret"
+	"sso_3.0/internal/pkg/webhook"
+	webhookStorage "sso_3.0/internal/storage/postgres/webhook"
+	"sync"
+	"time"
+)
+
+const (
+	// webhookBatchSize is the count of deliveries claimed at once
+	webhookBatchSize = 50
+	// webhookWorkers is the count of deliveries sent at the same time
+	webhookWorkers = 10
+	// webhookLease is how long a claimed delivery is not claimed again, it is longer than a run of the deliveries
+	webhookLease = 5 * time.Minute
+	// the retries wait twice as long after every attempt, up to webhookMaxBackoff
+	webhookBackoff    = 30 * time.Second
+	webhookMaxBackoff = 6 * time.Hour
+	maxWebhookURL     = 2048
+)
+
+// webhookEvents are the events the webhooks can post
+var webhookEvents = []models.DomainEventType{
+	models.EventTaskCreated,
+	models.EventTaskUpdated,
+	models.EventTaskDeleted,
+	models.EventTaskRestored,
+	models.EventTaskAssigned,
+	models.EventTaskUnassigned,
+}
+
+// CreateWebhook creates a webhook which posts the events of the project to the url, the owners of the project can create webhooks
+// it returns the secret which signs the deliveries, it can not be read later
+func (s *Service) CreateWebhook(ctx context.Context, projectId int, target string, eventTypes []string, currentUser *user.Model) (*models.Webhook, string, error) {
+	if len(target) > maxWebhookURL {
+		return nil, "", appErrors.ErrInvalidWebhookURL
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, "", appErrors.ErrInvalidWebhookURL
+	}
+
+	// names are checked by the sender after the dns lookup
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && webhook.IsBlockedIP(ip) {
+		return nil, "", appErrors.ErrInvalidWebhookURL
+	}
+
+	if len(eventTypes) == 0 {
+		return nil, "", appErrors.ErrInvalidWebhookEvent
+	}
+
+	var events []string
+	for _, eventType := range eventTypes {
+		if !slices.Contains(webhookEvents, models.DomainEventType(eventType)) {
+			return nil, "", appErrors.ErrInvalidWebhookEvent
+		}
+		if !slices.Contains(events, eventType) {
+			events = append(events, eventType)
+		}
+	}
+
+	if _, err = s.verifyUserCanManageProject(ctx, projectId, currentUser); err != nil {
+		return nil, "", err
+	}
+
+	webhookSecret, err := secret.New()
+	if err != nil {
+		return nil, "", err
+	}
+
+	created, err := s.storage.WebhookStorage.CreateWebhook(ctx, projectId, target, webhookSecret, events, currentUser.Id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return created, webhookSecret, nil
+}
+
+// ListWebhooks gets the webhooks of the project, the owners of the project can see them
+func (s *Service) ListWebhooks(ctx context.Context, projectId int, currentUser *user.Model) ([]*models.Webhook, error) {
+	if _, err := s.verifyUserCanManageProject(ctx, projectId, currentUser); err != nil {
+		return nil, err
+	}
+
+	return s.storage.WebhookStorage.GetWebhooks(ctx, projectId)
+}
+
+// DeleteWebhook deletes the webhook, the deliveries which were not sent yet are dropped
+func (s *Service) DeleteWebhook(ctx context.Context, id int, currentUser *user.Model) error {
+	found, err := s.storage.WebhookStorage.GetWebhookById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.verifyUserCanManageProject(ctx, found.ProjectId, currentUser); err != nil {
+		return err
+	}
+
+	return s.storage.WebhookStorage.DeleteWebhook(ctx, id)
+}
+
+// enqueueWebhooks adds the deliveries of the event to the webhooks of its project in the transaction of the relay
+func (s *Service) enqueueWebhooks(ctx context.Context, tx *sql.Tx, event *models.DomainEvent) error {
+	if !slices.Contains(webhookEvents, event.Type) {
+		return nil
+	}
+
+	payload, err := webhook.NewPayload(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = webhookStorage.EnqueueDeliveries(ctx, tx, event.ProjectId, string(event.Type), payload)
+	return err
+}
+
+// DeliverWebhooks sends the pending deliveries which are due
+// a delivery which failed is retried with a growing backoff until it ran out of attempts
+// it returns the count of delivered events
+func (s *Service) DeliverWebhooks(ctx context.Context) (int, error) {
+	var delivered int
+
+	for ctx.Err() == nil {
+		deliveries, err := s.storage.WebhookStorage.ClaimDeliveries(ctx, webhookBatchSize, webhookLease)
+		if err != nil {
+			return delivered, err
+		}
+
+		delivered += s.deliver(ctx, deliveries)
+
+		if len(deliveries) < webhookBatchSize {
+			return delivered, nil
+		}
+	}
+
+	return delivered, ctx.Err()
+}
+
+// deliver sends the claimed deliveries and records their attempts, it returns the count of delivered ones
+// an attempt which could not be recorded is made again after the lease
+func (s *Service) deliver(ctx context.Context, deliveries []*models.WebhookDelivery) int {
+	log := s.log.With("op", "tasks.service.deliver")
+	var delivered int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, webhookWorkers)
+
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		workers <- struct{}{}
+
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			attempt := s.webhookSender.Send(ctx, delivery)
+			status, retryIn := s.getDeliveryStatus(delivery, attempt)
+
+			if attempt.Error != "" {
+				log.Warn("Webhook delivery failed", "deliveryId", delivery.Id, "webhookId", delivery.WebhookId, "attempt", delivery.Attempt, "errors", attempt.Error)
+			}
+
+			if err := s.storage.WebhookStorage.RecordAttempt(ctx, delivery, attempt, status, retryIn); err != nil {
+				log.Error("Error on recording the attempt", "deliveryId", delivery.Id, "errors", err)
+				return
+			}
+
+			if status == models.WebhookDelivered {
+				mu.Lock()
+				delivered++
+				mu.Unlock()
+			}
+		}(delivery)
+	}
+
+	wg.Wait()
+
+	return delivered
+}
+
+// getDeliveryStatus gets the status of the delivery after the attempt and how long the next attempt waits
+func (s *Service) getDeliveryStatus(delivery *models.WebhookDelivery, attempt *models.WebhookAttempt) (models.WebhookDeliveryStatus, time.Duration) {
+	if attempt.Error == "" {
+		return models.WebhookDelivered, 0
+	}
+
+	if delivery.Attempt >= s.webhookMaxAttempts {
+		return models.WebhookFailed, 0
+	}
+
+	retryIn := webhookBackoff
+	for i := 1; i < delivery.Attempt && retryIn < webhookMaxBackoff; i++ {
+		retryIn *= 2
+	}
+
+	return models.WebhookPending, min(retryIn, webhookMaxBackoff)
+}
+
+// PurgeWebhookDeliveries deletes the sent and failed deliveries older than retention, it returns the count of deleted ones
+func (s *Service) PurgeWebhookDeliveries(ctx context.Context, retention time.Duration) (int, error) {
+	return s.storage.WebhookStorage.PurgeDeliveries(ctx, time.Now().UTC().Add(-retention))
+}
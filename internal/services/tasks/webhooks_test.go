@@ -0,0 +1,38 @@
+package tasks
+
+import (
+	"sso_3.0/internal/domain/models"
+	"testing"
+	"time"
+)
+
+func TestGetDeliveryStatus(t *testing.T) {
+	s := &Service{webhookMaxAttempts: 12}
+
+	tests := []struct {
+		name    string
+		attempt int
+		error   string
+		status  models.WebhookDeliveryStatus
+		retryIn time.Duration
+	}{
+		{name: "delivered", attempt: 3, status: models.WebhookDelivered},
+		{name: "first failure", attempt: 1, error: "timeout", status: models.WebhookPending, retryIn: 30 * time.Second},
+		{name: "second failure", attempt: 2, error: "timeout", status: models.WebhookPending, retryIn: time.Minute},
+		{name: "third failure", attempt: 3, error: "timeout", status: models.WebhookPending, retryIn: 2 * time.Minute},
+		{name: "tenth failure", attempt: 10, error: "timeout", status: models.WebhookPending, retryIn: 512 * 30 * time.Second},
+		{name: "capped", attempt: 11, error: "timeout", status: models.WebhookPending, retryIn: 6 * time.Hour},
+		{name: "last attempt", attempt: 12, error: "timeout", status: models.WebhookFailed},
+		{name: "delivered on the last attempt", attempt: 12, status: models.WebhookDelivered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, retryIn := s.getDeliveryStatus(&models.WebhookDelivery{Attempt: tt.attempt}, &models.WebhookAttempt{Error: tt.error})
+
+			if status != tt.status || retryIn != tt.retryIn {
+				t.Errorf("getDeliveryStatus() = %s, %s, want %s, %s", status, retryIn, tt.status, tt.retryIn)
+			}
+		})
+	}
+}
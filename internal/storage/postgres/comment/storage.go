@@ -0,0 +1,303 @@
+package comment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"log/slog"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/dbutil"
+)
+
+type Storage struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func New(db *sql.DB, log *slog.Logger) *Storage {
+	return &Storage{db: db, log: log}
+}
+
+// CreateComment creates a comment on the task and records the mentioned users
+func (s *Storage) CreateComment(ctx context.Context, taskId int, authorId, body string, mentionIds []string) (*models.Comment, error) {
+	op := "storage.CreateComment"
+	log := s.log.With("op", op)
+	var id int
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO task_comments (taskId, authorId, body) VALUES ($1, $2, $3) RETURNING id", taskId, authorId, body).Scan(&id)
+	if err != nil {
+		log.Error("Error on creating comment", "errors", err)
+		return nil, err
+	}
+
+	if err = insertMentions(ctx, tx, id, mentionIds); err != nil {
+		log.Error("Error on creating mentions", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetCommentById(ctx, id)
+}
+
+// UpdateComment updates the body of the comment and replaces the mentioned users
+func (s *Storage) UpdateComment(ctx context.Context, id int, body string, mentionIds []string) (*models.Comment, error) {
+	op := "storage.UpdateComment"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	execContext, err := tx.ExecContext(ctx, "UPDATE task_comments SET body = $1, updatedAt = now() WHERE id = $2", body, id)
+	if err != nil {
+		log.Error("Error on updating comment", "errors", err)
+		return nil, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, appErrors.ErrCommentNotExists
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM comment_mentions WHERE commentId = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = insertMentions(ctx, tx, id, mentionIds); err != nil {
+		log.Error("Error on creating mentions", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetCommentById(ctx, id)
+}
+
+// DeleteComment deletes the comment with its mentions
+func (s *Storage) DeleteComment(ctx context.Context, id int) error {
+	execContext, err := s.db.ExecContext(ctx, "DELETE FROM task_comments WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+	// if no rows were deleted
+	if affected == 0 {
+		return appErrors.NothingToDelete
+	}
+
+	return nil
+}
+
+// GetCommentById gets the comment with its mentions
+func (s *Storage) GetCommentById(ctx context.Context, id int) (*models.Comment, error) {
+	op := "storage.GetCommentById"
+	log := s.log.With("op", op)
+
+	comment, err := scanComment(s.db.QueryRowContext(ctx, `
+	SELECT c.id, c.taskId, c.body, c.createdAt, c.updatedAt, u.id, u.email
+	FROM task_comments c
+	JOIN users u ON u.id = c.authorId
+	WHERE c.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, appErrors.ErrCommentNotExists
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = s.addMentions(ctx, []*models.Comment{comment}); err != nil {
+		log.Error("Error on getting mentions", "errors", err)
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// GetComments gets one page of the comments of the task, oldest first
+// it returns the token of the next page, which is empty on the last page
+func (s *Storage) GetComments(ctx context.Context, taskId, pageSize int, pageToken string) ([]*models.Comment, string, error) {
+	op := "storage.GetComments"
+	log := s.log.With("op", op)
+	var comments []*models.Comment
+	var afterId int
+
+	pageSize = dbutil.PageSize(pageSize)
+
+	// continue after the last comment of the previous page
+	if pageToken != "" {
+		c, err := dbutil.DecodeCursor(pageToken, models.SortById, false, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		afterId = c.Id
+	}
+
+	// fetch one more comment than needed to know if there is a next page
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT c.id, c.taskId, c.body, c.createdAt, c.updatedAt, u.id, u.email
+	FROM task_comments c
+	JOIN users u ON u.id = c.authorId
+	WHERE c.taskId = $1 AND c.id > $2
+	ORDER BY c.id
+	LIMIT $3`, taskId, afterId, pageSize+1)
+	if err != nil {
+		log.Error("Error on getting comments", "errors", err)
+		return nil, "", err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		comments = append(comments, comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextPageToken string
+	if len(comments) > pageSize {
+		comments = comments[:pageSize]
+		nextPageToken = (&dbutil.Cursor{SortBy: models.SortById, Id: comments[pageSize-1].Id}).Encode()
+	}
+
+	if err = s.addMentions(ctx, comments); err != nil {
+		log.Error("Error on getting mentions", "errors", err)
+		return nil, "", err
+	}
+
+	return comments, nextPageToken, nil
+}
+
+// GetMentionedComments gets the ids of the comments mentioning the user
+func (s *Storage) GetMentionedComments(ctx context.Context, userId string) ([]int, error) {
+	var ids []int
+
+	rows, err := s.db.QueryContext(ctx, "SELECT commentId FROM comment_mentions WHERE userId = $1 ORDER BY commentId", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// addMentions gets the mentioned users of given comments in one query and adds them to the comments
+func (s *Storage) addMentions(ctx context.Context, comments []*models.Comment) error {
+	var commentIds []int64
+	mentions := make(map[int][]*user.Model)
+
+	if len(comments) == 0 {
+		return nil
+	}
+
+	for _, comment := range comments {
+		commentIds = append(commentIds, int64(comment.Id))
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT m.commentId, u.id, u.email
+	FROM comment_mentions m
+	JOIN users u ON u.id = m.userId
+	WHERE m.commentId = ANY($1)
+	ORDER BY u.email`, pq.Array(commentIds))
+	if err != nil {
+		return err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentId int
+		var mentioned user.Model
+		if err = rows.Scan(&commentId, &mentioned.Id, &mentioned.Email); err != nil {
+			return err
+		}
+		mentions[commentId] = append(mentions[commentId], &mentioned)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, comment := range comments {
+		comment.Mentions = mentions[comment.Id]
+	}
+
+	return nil
+}
+
+// insertMentions records the mentioned users of the comment
+func insertMentions(ctx context.Context, tx *sql.Tx, commentId int, userIds []string) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO comment_mentions (commentId, userId)
+	SELECT $1, unnest($2::text[])
+	ON CONFLICT DO NOTHING`, commentId, pq.Array(userIds))
+
+	return err
+}
+
+func scanComment(row dbutil.Scanner) (*models.Comment, error) {
+	var comment models.Comment
+	var author user.Model
+	var updatedAt sql.NullTime
+
+	err := row.Scan(&comment.Id, &comment.TaskId, &comment.Body, &comment.CreatedAt, &updatedAt, &author.Id, &author.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if updatedAt.Valid {
+		comment.UpdatedAt = updatedAt.Time
+	}
+	comment.Author = &author
+
+	return &comment, nil
+}
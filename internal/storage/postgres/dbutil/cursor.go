@@ -0,0 +1,59 @@
+package dbutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+)
+
+// the page sizes of every paginated list
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Cursor is the position after the last row of a page
+// it is sent to the client as an opaque page token
+type Cursor struct {
+	SortBy   models.TaskSortField `json:"s"`
+	SortDesc bool                 `json:"d"`
+	Values   []string             `json:"v"`
+	Id       int                  `json:"i"`
+}
+
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes the page token and checks that it was created for the same sort options
+// valueCount is the count of sort values the sort needs besides the id
+func DecodeCursor(token string, sortBy models.TaskSortField, sortDesc bool, valueCount int) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, appErrors.ErrInvalidPageToken
+	}
+
+	var c Cursor
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, appErrors.ErrInvalidPageToken
+	}
+
+	if c.SortBy != sortBy || c.SortDesc != sortDesc || len(c.Values) != valueCount {
+		return nil, appErrors.ErrInvalidPageToken
+	}
+
+	return &c, nil
+}
+
+// PageSize returns the page size to use for the requested size
+func PageSize(size int) int {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+	if size > MaxPageSize {
+		return MaxPageSize
+	}
+	return size
+}
@@ -0,0 +1,66 @@
+package dbutil
+
+import (
+	"errors"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := &Cursor{SortBy: models.SortByDue, SortDesc: true, Values: []string{"2024-01-02 00:00:00"}, Id: 42}
+
+	got, err := DecodeCursor(c.Encode(), models.SortByDue, true, 1)
+	if err != nil {
+		t.Fatalf("DecodeCursor() = %v", err)
+	}
+
+	if got.Id != 42 || len(got.Values) != 1 || got.Values[0] != c.Values[0] {
+		t.Errorf("DecodeCursor() = %+v, want %+v", got, c)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	token := (&Cursor{SortBy: models.SortByDue, Values: []string{"2024-01-02 00:00:00"}, Id: 42}).Encode()
+
+	tests := []struct {
+		name       string
+		token      string
+		sortBy     models.TaskSortField
+		sortDesc   bool
+		valueCount int
+	}{
+		{name: "not base64", token: "!!", sortBy: models.SortByDue, valueCount: 1},
+		{name: "not json", token: "bm90IGpzb24", sortBy: models.SortByDue, valueCount: 1},
+		{name: "other sort field", token: token, sortBy: models.SortByTitle, valueCount: 1},
+		{name: "other direction", token: token, sortBy: models.SortByDue, sortDesc: true, valueCount: 1},
+		{name: "other value count", token: token, sortBy: models.SortByDue, valueCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.token, tt.sortBy, tt.sortDesc, tt.valueCount); !errors.Is(err, appErrors.ErrInvalidPageToken) {
+				t.Errorf("DecodeCursor() = %v, want %v", err, appErrors.ErrInvalidPageToken)
+			}
+		})
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	tests := []struct {
+		size int
+		want int
+	}{
+		{-1, DefaultPageSize},
+		{0, DefaultPageSize},
+		{1, 1},
+		{MaxPageSize, MaxPageSize},
+		{MaxPageSize + 1, MaxPageSize},
+	}
+
+	for _, tt := range tests {
+		if got := PageSize(tt.size); got != tt.want {
+			t.Errorf("PageSize(%d) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
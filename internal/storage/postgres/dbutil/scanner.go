@@ -0,0 +1,6 @@
+package dbutil
+
+// Scanner is a sql.Row or sql.Rows
+type Scanner interface {
+	Scan(dest ...any) error
+}
@@ -0,0 +1,103 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"log/slog"
+	"time"
+)
+
+// Storage counts the failed logins in the database, so all replicas share the counters
+type Storage struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func New(db *sql.DB, log *slog.Logger) *Storage {
+	return &Storage{db: db, log: log}
+}
+
+// GetLockout returns how long the longest lockout of the keys lasts, zero if none of them is locked
+func (s *Storage) GetLockout(ctx context.Context, keys []string) (time.Duration, error) {
+	op := "storage.lockout.GetLockout"
+	log := s.log.With("op", op)
+	var seconds float64
+
+	err := s.db.QueryRowContext(ctx, `
+	SELECT COALESCE(EXTRACT(EPOCH FROM max(lockedUntil) - now()), 0)
+	FROM login_attempts WHERE key = ANY($1) AND lockedUntil > now()`, pq.Array(keys)).Scan(&seconds)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// RecordFailure counts a failed login of the key and returns the failures in a row
+// the count starts again if the last failure is older than window
+func (s *Storage) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	op := "storage.lockout.RecordFailure"
+	log := s.log.With("op", op)
+	var failures int
+
+	err := s.db.QueryRowContext(ctx, `
+	INSERT INTO login_attempts (key, failures) VALUES ($1, 1)
+	ON CONFLICT (key) DO UPDATE SET lastFailureAt = now(), failures = CASE
+		WHEN login_attempts.lastFailureAt < now() - make_interval(secs => $2) THEN 1
+		ELSE login_attempts.failures + 1
+	END
+	RETURNING failures`, key, window.Seconds()).Scan(&failures)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+// Lock locks the key for the duration
+func (s *Storage) Lock(ctx context.Context, key string, duration time.Duration) error {
+	op := "storage.lockout.Lock"
+	log := s.log.With("op", op)
+
+	_, err := s.db.ExecContext(ctx, "UPDATE login_attempts SET lockedUntil = now() + make_interval(secs => $2) WHERE key = $1", key, duration.Seconds())
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// Reset removes the failures and the lockout of the keys
+func (s *Storage) Reset(ctx context.Context, keys []string) error {
+	op := "storage.lockout.Reset"
+	log := s.log.With("op", op)
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE key = ANY($1)", pq.Array(keys))
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes the counters which are not locked and older than window, it returns their count
+func (s *Storage) PurgeExpired(ctx context.Context, window time.Duration) (int, error) {
+	execContext, err := s.db.ExecContext(ctx, `
+	DELETE FROM login_attempts
+	WHERE lastFailureAt < now() - make_interval(secs => $1) AND (lockedUntil IS NULL OR lockedUntil < now())`, window.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
@@ -0,0 +1,139 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"sso_3.0/internal/domain/models"
+)
+
+// the aggregates of the events, they are also the namespaces of the advisory locks
+const (
+	aggregateTask   = "task"
+	aggregateStatus = "status"
+)
+
+var lockNamespaces = map[string]int{aggregateTask: 1, aggregateStatus: 2}
+
+// taskData is the json of a task t in the events
+const taskData = `json_build_object(
+	'id', t.id, 'projectId', t.projectId, 'title', t.title, 'description', t.description,
+	'due', CASE WHEN t.due > 'epoch' THEN to_char(t.due, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') END,
+	'completed', COALESCE(t.completed, false), 'creatorId', t.creatorId, 'statusId', t.statusId,
+	'parentId', t.parentId, 'priority', t.priority,
+	'assigneeIds', ARRAY(SELECT ta.userId FROM task_assignees ta WHERE ta.taskId = t.id AND ta.userId IS NOT NULL ORDER BY ta.userId),
+	'labelIds', ARRAY(SELECT tl.labelId FROM task_labels tl WHERE tl.taskId = t.id ORDER BY tl.labelId),
+	'recurrence', t.recurrence, 'seriesId', t.seriesId,
+	'deletedAt', to_char(t.deletedAt, 'YYYY-MM-DD"T"HH24:MI:SS"Z"'))`
+
+// statusData is the json of a status t in the events
+const statusData = `json_build_object('id', t.id, 'title', t.title, 'description', t.description, 'projectId', t.projectId)`
+
+// SnapshotTasks locks the tasks for their events and returns their json before the change, it is the previous of AddTaskEvents
+func SnapshotTasks(ctx context.Context, tx *sql.Tx, ids []int64) (map[int64]json.RawMessage, error) {
+	return snapshot(ctx, tx, aggregateTask, "tasks", taskData, ids)
+}
+
+// AddTaskEvents writes an event for each of the tasks to the outbox in the transaction of the change
+// previous is the snapshot of the tasks before the change, nil for created tasks
+func AddTaskEvents(ctx context.Context, tx *sql.Tx, eventType models.DomainEventType, ids []int64, previous map[int64]json.RawMessage, actorId, userId string) error {
+	return addEvents(ctx, tx, aggregateTask, "tasks", taskData, "t.projectId", eventType, ids, previous, actorId, userId)
+}
+
+// SnapshotStatus locks the status for its events and returns its json before the change
+func SnapshotStatus(ctx context.Context, tx *sql.Tx, id int) (map[int64]json.RawMessage, error) {
+	return snapshot(ctx, tx, aggregateStatus, "statuses", statusData, []int64{int64(id)})
+}
+
+// AddStatusEvent writes the event of the status to the outbox in the transaction of the change
+// a deleted status has to be added before it is deleted
+func AddStatusEvent(ctx context.Context, tx *sql.Tx, eventType models.DomainEventType, id int, previous map[int64]json.RawMessage, actorId string) error {
+	return addEvents(ctx, tx, aggregateStatus, "statuses", statusData, "COALESCE(t.projectId, 0)", eventType, []int64{int64(id)}, previous, actorId, "")
+}
+
+// SelectIds gets the ids returned by the query in the transaction, for the changes of several tasks at once
+func SelectIds(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]int64, error) {
+	var ids []int64
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// lock locks the aggregates until the end of the transaction, so their events get ids in the order of the commits
+// the ids are locked in ascending order to avoid deadlocks
+func lock(ctx context.Context, tx *sql.Tx, aggregate string, ids []int64) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, id::int) FROM unnest($2::bigint[]) AS id ORDER BY id", lockNamespaces[aggregate], pq.Array(ids))
+	return err
+}
+
+func snapshot(ctx context.Context, tx *sql.Tx, aggregate, table, data string, ids []int64) (map[int64]json.RawMessage, error) {
+	snapshots := make(map[int64]json.RawMessage)
+
+	if len(ids) == 0 {
+		return snapshots, nil
+	}
+
+	if err := lock(ctx, tx, aggregate, ids); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT t.id, %s FROM %s t WHERE t.id = ANY($1)", data, table), pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var snapshot []byte
+		if err = rows.Scan(&id, &snapshot); err != nil {
+			return nil, err
+		}
+		snapshots[id] = snapshot
+	}
+
+	return snapshots, rows.Err()
+}
+
+func addEvents(ctx context.Context, tx *sql.Tx, aggregate, table, data, projectId string, eventType models.DomainEventType, ids []int64, previous map[int64]json.RawMessage, actorId, userId string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := lock(ctx, tx, aggregate, ids); err != nil {
+		return err
+	}
+
+	// the previous snapshots are passed as one json object by id
+	previousJson, err := json.Marshal(previous)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+	INSERT INTO outbox (aggregateType, aggregateId, projectId, eventType, actorId, userId, data, previous)
+	SELECT $1, t.id, %s, $2, $3, $4, %s, $5::jsonb -> t.id::text
+	FROM %s t WHERE t.id = ANY($6) ORDER BY t.id`, projectId, data, table),
+		aggregate, eventType, actorId, userId, string(previousJson), pq.Array(ids))
+
+	return err
+}
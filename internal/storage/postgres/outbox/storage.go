@@ -0,0 +1,194 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+	"sso_3.0/internal/domain/models"
+	"time"
+)
+
+// relayLock is the advisory lock of the relay, only one replica relays at a time
+const relayLock = 7340001
+
+type Storage struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func New(db *sql.DB, log *slog.Logger) *Storage {
+	return &Storage{db: db, log: log}
+}
+
+// Publish publishes one event, tx is the transaction of the relay which marks the event as published
+type Publish func(ctx context.Context, tx *sql.Tx, event *models.DomainEvent) error
+
+// Retry returns how long an event waits after its failed attempt, false if it ran out of attempts
+type Retry func(attempts int) (time.Duration, bool)
+
+// aggregate is the task or status of an event
+type aggregate struct {
+	aggregateType string
+	id            int
+}
+
+// Relay publishes at most limit pending events in the order they were written and marks them as published
+// it returns the count of published events, it is 0 if another replica is relaying at the moment
+// an event which could not be published waits for retry and holds up the later events of its task or status,
+// the events of the other aggregates are still published, so every event is published at least once and in order per aggregate
+// an event which ran out of attempts is marked as failed and no longer holds up its aggregate
+func (s *Storage) Relay(ctx context.Context, limit int, publish Publish, retry Retry) (int, error) {
+	op := "storage.Relay"
+	log := s.log.With("op", op)
+	var locked bool
+	var events []*models.DomainEvent
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	if err = tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", relayLock).Scan(&locked); err != nil {
+		log.Error("Error", "errors", err)
+		return 0, err
+	}
+
+	if !locked {
+		return 0, nil
+	}
+
+	// the events behind a waiting event of their aggregate are not due yet
+	rows, err := tx.QueryContext(ctx, `
+	SELECT o.id, o.eventType, o.aggregateType, o.aggregateId, o.projectId, o.actorId, o.userId, o.data, o.previous, o.createdAt, o.attempts
+	FROM outbox o WHERE o.publishedAt IS NULL AND o.failedAt IS NULL AND NOT EXISTS (
+		SELECT 1 FROM outbox w WHERE w.aggregateType = o.aggregateType AND w.aggregateId = o.aggregateId AND w.id <= o.id
+		AND w.publishedAt IS NULL AND w.failedAt IS NULL AND w.nextAttemptAt > now()
+	)
+	ORDER BY o.id LIMIT $1`, limit)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return 0, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.DomainEvent
+		var data, previous []byte
+
+		if err = rows.Scan(&event.Id, &event.Type, &event.AggregateType, &event.AggregateId, &event.ProjectId, &event.ActorId, &event.UserId, &data, &previous, &event.OccurredAt, &event.Attempts); err != nil {
+			log.Error("Error", "errors", err)
+			return 0, err
+		}
+
+		event.Data = data
+		event.Previous = previous
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published, failed, publishErr := publishEvents(ctx, events, func(ctx context.Context, event *models.DomainEvent) error {
+		return publishInSavepoint(ctx, tx, event, publish)
+	})
+
+	if len(published) > 0 {
+		if _, err = tx.ExecContext(ctx, "UPDATE outbox SET publishedAt = now() WHERE id = ANY($1)", pq.Array(published)); err != nil {
+			log.Error("Error", "errors", err)
+			return 0, err
+		}
+	}
+
+	for _, event := range failed {
+		attempts := event.Attempts + 1
+
+		retryIn, ok := retry(attempts)
+		if !ok {
+			log.Warn("Event ran out of attempts", "id", event.Id, "type", event.Type, "attempts", attempts)
+			_, err = tx.ExecContext(ctx, "UPDATE outbox SET attempts = $2, failedAt = now() WHERE id = $1", event.Id, attempts)
+		} else {
+			_, err = tx.ExecContext(ctx, "UPDATE outbox SET attempts = $2, nextAttemptAt = now() + make_interval(secs => $3) WHERE id = $1", event.Id, attempts, retryIn.Seconds())
+		}
+		if err != nil {
+			log.Error("Error", "errors", err)
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(published), publishErr
+}
+
+// publishEvents publishes the events in order and returns the ids of the published ones and the failed events
+// the events of an aggregate after one which failed are skipped, they are published after it
+func publishEvents(ctx context.Context, events []*models.DomainEvent, publish func(ctx context.Context, event *models.DomainEvent) error) ([]int64, []*models.DomainEvent, error) {
+	var published []int64
+	var failed []*models.DomainEvent
+	var errs []error
+	blocked := make(map[aggregate]bool)
+
+	for _, event := range events {
+		key := aggregate{aggregateType: event.AggregateType, id: event.AggregateId}
+		if blocked[key] {
+			continue
+		}
+
+		if err := publish(ctx, event); err != nil {
+			blocked[key] = true
+			failed = append(failed, event)
+			errs = append(errs, fmt.Errorf("event %d: %w", event.Id, err))
+			continue
+		}
+
+		published = append(published, event.Id)
+	}
+
+	return published, failed, errors.Join(errs...)
+}
+
+// publishInSavepoint publishes the event, what the publish wrote in the transaction is undone if it fails
+func publishInSavepoint(ctx context.Context, tx *sql.Tx, event *models.DomainEvent, publish Publish) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT publish"); err != nil {
+		return err
+	}
+
+	if err := publish(ctx, tx, event); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT publish"); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT publish")
+	return err
+}
+
+// PurgeEvents deletes the published and the failed events written before olderThan, it returns the count of deleted events
+func (s *Storage) PurgeEvents(ctx context.Context, olderThan time.Time) (int, error) {
+	op := "storage.PurgeEvents"
+	log := s.log.With("op", op)
+
+	execContext, err := s.db.ExecContext(ctx, "DELETE FROM outbox WHERE (publishedAt IS NOT NULL OR failedAt IS NOT NULL) AND createdAt < $1", olderThan)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return 0, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
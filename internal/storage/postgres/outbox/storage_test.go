@@ -0,0 +1,94 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sso_3.0/internal/domain/models"
+	"testing"
+)
+
+var errSinkDown = errors.New("sink is down")
+
+func TestPublishEventsSkipsTheBlockedAggregate(t *testing.T) {
+	events := []*models.DomainEvent{
+		{Id: 1, AggregateType: aggregateTask, AggregateId: 1},
+		{Id: 2, AggregateType: aggregateTask, AggregateId: 2},
+		{Id: 3, AggregateType: aggregateTask, AggregateId: 1},
+		// a status with the id of the blocked task is another aggregate
+		{Id: 4, AggregateType: aggregateStatus, AggregateId: 1},
+		{Id: 5, AggregateType: aggregateTask, AggregateId: 2},
+		{Id: 6, AggregateType: aggregateTask, AggregateId: 1},
+	}
+
+	var attempted []int64
+	published, failed, err := publishEvents(context.Background(), events, func(ctx context.Context, event *models.DomainEvent) error {
+		attempted = append(attempted, event.Id)
+		if event.Id == 1 {
+			return errSinkDown
+		}
+		return nil
+	})
+
+	if !errors.Is(err, errSinkDown) {
+		t.Errorf("publishEvents() error = %v, want %v", err, errSinkDown)
+	}
+
+	if want := []int64{1, 2, 4, 5}; !slices.Equal(attempted, want) {
+		t.Errorf("attempted %v, want %v", attempted, want)
+	}
+
+	if want := []int64{2, 4, 5}; !slices.Equal(published, want) {
+		t.Errorf("published %v, want %v", published, want)
+	}
+
+	if len(failed) != 1 || failed[0].Id != 1 {
+		t.Errorf("failed %v, want only event 1", failed)
+	}
+}
+
+func TestPublishEventsReportsEveryFailure(t *testing.T) {
+	errOther := errors.New("other sink is down")
+	events := []*models.DomainEvent{
+		{Id: 1, AggregateType: aggregateTask, AggregateId: 1},
+		{Id: 2, AggregateType: aggregateTask, AggregateId: 2},
+		{Id: 3, AggregateType: aggregateTask, AggregateId: 3},
+	}
+
+	published, failed, err := publishEvents(context.Background(), events, func(ctx context.Context, event *models.DomainEvent) error {
+		switch event.Id {
+		case 1:
+			return errSinkDown
+		case 3:
+			return errOther
+		}
+		return nil
+	})
+
+	if !errors.Is(err, errSinkDown) || !errors.Is(err, errOther) {
+		t.Errorf("publishEvents() error = %v, want both failures", err)
+	}
+
+	if want := []int64{2}; !slices.Equal(published, want) {
+		t.Errorf("published %v, want %v", published, want)
+	}
+
+	if len(failed) != 2 || failed[0].Id != 1 || failed[1].Id != 3 {
+		t.Errorf("failed %d events, want events 1 and 3", len(failed))
+	}
+}
+
+func TestPublishEventsWithoutFailures(t *testing.T) {
+	events := []*models.DomainEvent{
+		{Id: 1, AggregateType: aggregateTask, AggregateId: 1},
+		{Id: 2, AggregateType: aggregateTask, AggregateId: 1},
+	}
+
+	published, failed, err := publishEvents(context.Background(), events, func(ctx context.Context, event *models.DomainEvent) error {
+		return nil
+	})
+
+	if err != nil || len(failed) != 0 || !slices.Equal(published, []int64{1, 2}) {
+		t.Errorf("publishEvents() = %v, %d failed, %v, want [1 2], 0 failed, nil", published, len(failed), err)
+	}
+}
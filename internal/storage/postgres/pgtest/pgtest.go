@@ -0,0 +1,54 @@
+package pgtest
+
+import (
+	"database/sql"
+	"fmt"
+	_ "github.com/lib/pq"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sso_3.0/cmd/migrations"
+	"sync"
+	"testing"
+	"time"
+)
+
+// migrated runs the migrations once for all tests of a package
+var migrated = sync.OnceValue(func() error {
+	_, file, _, _ := runtime.Caller(0)
+	return migrations.MigrateDb(os.Getenv("TEST_DB_URL"), filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "migrations"), "up")
+})
+
+// Open connects to the database of TEST_DB_URL and migrates it, without TEST_DB_URL the test is skipped
+// the tests add their own rows with unique emails, so they can share the database and run again
+func Open(t *testing.T) *sql.DB {
+	t.Helper()
+
+	if os.Getenv("TEST_DB_URL") == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+
+	if err := migrated(); err != nil {
+		t.Fatalf("migrating the test database: %v", err)
+	}
+
+	db, err := sql.Open("postgres", os.Getenv("TEST_DB_URL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// Logger discards the logs of the storages
+func Logger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// Email returns an email no other test run uses
+func Email(t *testing.T) string {
+	return fmt.Sprintf("%d-%s@test.local", time.Now().UnixNano(), filepath.Base(t.Name()))
+}
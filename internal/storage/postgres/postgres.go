@@ -7,14 +7,26 @@ import (
 	"log/slog"
 	"sso_3.0/cmd/migrations"
 	configParser "sso_3.0/internal/config"
+	"sso_3.0/internal/storage/postgres/comment"
+	"sso_3.0/internal/storage/postgres/lockout"
+	"sso_3.0/internal/storage/postgres/outbox"
+	"sso_3.0/internal/storage/postgres/project"
 	"sso_3.0/internal/storage/postgres/task"
+	"sso_3.0/internal/storage/postgres/token"
 	"sso_3.0/internal/storage/postgres/user"
+	"sso_3.0/internal/storage/postgres/webhook"
 	"time"
 )
 
 type Storage struct {
-	TaskStorage *task.Storage
-	UserStorage *user.Storage
+	TaskStorage    *task.Storage
+	UserStorage    *user.Storage
+	CommentStorage *comment.Storage
+	TokenStorage   *token.Storage
+	LockoutStorage *lockout.Storage
+	ProjectStorage *project.Storage
+	WebhookStorage *webhook.Storage
+	OutboxStorage  *outbox.Storage
 }
 
 func New(cfg *configParser.Config, log *slog.Logger) (*Storage, error) {
@@ -31,8 +43,14 @@ func New(cfg *configParser.Config, log *slog.Logger) (*Storage, error) {
 
 	taskStorage := task.New(db, log)
 	userStorage := user.New(db, log)
+	commentStorage := comment.New(db, log)
+	tokenStorage := token.New(db, log)
+	lockoutStorage := lockout.New(db, log)
+	projectStorage := project.New(db, log)
+	webhookStorage := webhook.New(db, log)
+	outboxStorage := outbox.New(db, log)
 
-	return &Storage{taskStorage, userStorage}, nil
+	return &Storage{taskStorage, userStorage, commentStorage, tokenStorage, lockoutStorage, projectStorage, webhookStorage, outboxStorage}, nil
 }
 
 func Migrate(dbUrl string, triesCount int) error {
@@ -40,7 +58,7 @@ func Migrate(dbUrl string, triesCount int) error {
 		err := migrations.MigrateDb(dbUrl, "/app/migrations", "up")
 
 		if err == nil {
-			fmt.Println("\nSuccessfully Migrated DB\n")
+			fmt.Print("\nSuccessfully Migrated DB\n\n")
 			break
 		}
 		fmt.Printf("ERROR on migrate: %e", err)
@@ -0,0 +1,280 @@
+package project
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/outbox"
+	"strings"
+	"time"
+)
+
+// GetMemberRole gets the role of the user in the project, it is empty if the user is not a member
+func (s *Storage) GetMemberRole(ctx context.Context, projectId int, userId string) (models.ProjectRole, error) {
+	var role models.ProjectRole
+
+	err := s.db.QueryRowContext(ctx, "SELECT role FROM project_members WHERE projectId = $1 AND userId = $2", projectId, userId).Scan(&role)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return role, nil
+}
+
+// GetMemberProjectIds gets the ids of the projects the user is a member of
+func (s *Storage) GetMemberProjectIds(ctx context.Context, userId string) ([]int, error) {
+	var ids []int
+
+	rows, err := s.db.QueryContext(ctx, "SELECT projectId FROM project_members WHERE userId = $1", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetMemberIdsByEmails gets the ids of the members of the project with given lower case emails
+// the emails of users outside the project are skipped like unknown ones
+func (s *Storage) GetMemberIdsByEmails(ctx context.Context, projectId int, emails []string) ([]string, error) {
+	op := "storage.GetMemberIdsByEmails"
+	log := s.log.With("op", op)
+	var ids []string
+
+	if len(emails) == 0 {
+		return ids, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT u.id FROM users u
+	JOIN project_members pm ON pm.userId = u.id AND pm.projectId = $1
+	WHERE lower(u.email) = ANY($2)`, projectId, pq.Array(emails))
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetMembers gets the members of the project, the owners first
+func (s *Storage) GetMembers(ctx context.Context, projectId int) ([]*models.ProjectMember, error) {
+	op := "storage.GetMembers"
+	log := s.log.With("op", op)
+	var members []*models.ProjectMember
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT pm.userId, u.email, pm.role, pm.createdAt
+	FROM project_members pm
+	JOIN users u ON u.id = pm.userId
+	WHERE pm.projectId = $1
+	ORDER BY pm.role = $2 DESC, pm.createdAt, pm.userId`, projectId, models.ProjectOwner)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		member := models.ProjectMember{ProjectId: projectId, User: &user.Model{}}
+		if err = rows.Scan(&member.User.Id, &member.User.Email, &member.Role, &member.JoinedAt); err != nil {
+			log.Error("Error", "errors", err)
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+
+	return members, rows.Err()
+}
+
+// RemoveMember removes the user from the project and from the tasks of the project
+// the last owner can not be removed, so there is always someone who can manage the project
+func (s *Storage) RemoveMember(ctx context.Context, projectId int, userId, actorId string) error {
+	op := "storage.RemoveMember"
+	log := s.log.With("op", op)
+	var role sql.NullString
+	var owners int
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	// the owners are locked, so two owners can not remove each other at the same time
+	err = tx.QueryRowContext(ctx, `
+	SELECT (SELECT role FROM project_members WHERE projectId = $1 AND userId = $2),
+	       (SELECT count(*) FROM (SELECT userId FROM project_members WHERE projectId = $1 AND role = $3 FOR UPDATE) owners)`,
+		projectId, userId, models.ProjectOwner).Scan(&role, &owners)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	if !role.Valid {
+		return appErrors.ErrNotProjectMember
+	}
+
+	if models.ProjectRole(role.String) == models.ProjectOwner && owners <= 1 {
+		return appErrors.ErrLastProjectOwner
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM project_members WHERE projectId = $1 AND userId = $2", projectId, userId); err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	unassignedIds, err := outbox.SelectIds(ctx, tx, `
+	SELECT t.id FROM tasks t JOIN task_assignees ta ON ta.taskId = t.id
+	WHERE t.projectId = $1 AND ta.userId = $2`, projectId, userId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	previous, err := outbox.SnapshotTasks(ctx, tx, unassignedIds)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	// only members can be assigned to the tasks of the project
+	_, err = tx.ExecContext(ctx, `
+	WITH removed AS (
+		DELETE FROM task_assignees ta USING tasks t
+		WHERE t.id = ta.taskId AND t.projectId = $1 AND ta.userId = $2
+		RETURNING ta.taskId
+	)
+	INSERT INTO task_events (taskId, actorId, type, field, oldValue)
+	SELECT taskId, NULLIF($3, ''), $4, $5, $2 FROM removed`, projectId, userId, actorId, models.TaskUnassigned, models.FieldAssignee)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskUnassigned, unassignedIds, previous, actorId, userId); err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateInvite stores the hash of the invite token, it can be accepted until ttl is over
+func (s *Storage) CreateInvite(ctx context.Context, invite *models.ProjectInvite, tokenHash, invitedBy string, ttl time.Duration) (time.Time, error) {
+	op := "storage.CreateInvite"
+	log := s.log.With("op", op)
+	var expiresAt time.Time
+
+	err := s.db.QueryRowContext(ctx, `
+	INSERT INTO project_invites (projectId, email, role, tokenHash, invitedBy, expiresAt)
+	VALUES ($1, $2, $3, $4, $5, now() + make_interval(secs => $6))
+	RETURNING expiresAt`, invite.ProjectId, invite.Email, invite.Role, tokenHash, invitedBy, ttl.Seconds()).Scan(&expiresAt)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return time.Time{}, err
+	}
+
+	return expiresAt, nil
+}
+
+// AcceptInvite makes the user a member of the project of the invite, the invite can only be used once
+// the invite has to be for the email of the user, members keep their role
+func (s *Storage) AcceptInvite(ctx context.Context, tokenHash, userId, email string) (*models.ProjectMember, error) {
+	op := "storage.AcceptInvite"
+	log := s.log.With("op", op)
+	var invite models.ProjectInvite
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+	SELECT projectId, email, role FROM project_invites
+	WHERE tokenHash = $1 AND acceptedAt IS NULL AND expiresAt > now()
+	FOR UPDATE`, tokenHash).Scan(&invite.ProjectId, &invite.Email, &invite.Role)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return nil, appErrors.ErrInviteInvalid
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if !strings.EqualFold(invite.Email, email) {
+		return nil, appErrors.ErrInviteInvalid
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO project_members (projectId, userId, role) VALUES ($1, $2, $3)
+	ON CONFLICT (projectId, userId) DO NOTHING`, invite.ProjectId, userId, invite.Role)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE project_invites SET acceptedAt = now() WHERE tokenHash = $1", tokenHash); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	member := models.ProjectMember{ProjectId: invite.ProjectId, User: &user.Model{Id: userId, Email: email}}
+
+	err = tx.QueryRowContext(ctx, "SELECT role, createdAt FROM project_members WHERE projectId = $1 AND userId = $2",
+		invite.ProjectId, userId).Scan(&member.Role, &member.JoinedAt)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// PurgeExpiredInvites deletes the expired and the accepted invites, it returns the count of deleted invites
+func (s *Storage) PurgeExpiredInvites(ctx context.Context) (int, error) {
+	execContext, err := s.db.ExecContext(ctx, "DELETE FROM project_invites WHERE expiresAt < now() OR acceptedAt IS NOT NULL")
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	return int(affected), err
+}
@@ -0,0 +1,207 @@
+package project
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/dbutil"
+	"sso_3.0/internal/storage/postgres/outbox"
+	"strings"
+)
+
+type Storage struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func New(db *sql.DB, log *slog.Logger) *Storage {
+	return &Storage{db: db, log: log}
+}
+
+// projectColumns are the columns read by scanProject
+const projectColumns = "id, name, description, creatorId, createdAt"
+
+// CreateProject creates a project with given params, the creator is its owner
+func (s *Storage) CreateProject(ctx context.Context, name, description, creatorId string) (*models.Project, error) {
+	op := "storage.CreateProject"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	project, err := scanProject(tx.QueryRowContext(ctx, fmt.Sprintf(
+		"INSERT INTO projects (name, description, creatorId) VALUES ($1, $2, $3) RETURNING %s", projectColumns), name, description, creatorId))
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO project_members (projectId, userId, role) VALUES ($1, $2, $3)", project.Id, creatorId, models.ProjectOwner)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// GetProjectById gets project by id
+func (s *Storage) GetProjectById(ctx context.Context, id int) (*models.Project, error) {
+	project, err := scanProject(s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT %s FROM projects WHERE id = $1", projectColumns), id))
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return nil, appErrors.ErrProjectNotExists
+		}
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// GetAllProjects gets all projects, the oldest first
+// with memberId only the projects of that member
+func (s *Storage) GetAllProjects(ctx context.Context, memberId string) ([]*models.Project, error) {
+	op := "storage.GetAllProjects"
+	log := s.log.With("op", op)
+	var projects []*models.Project
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+	SELECT %s FROM projects
+	WHERE $1 = '' OR id IN (SELECT projectId FROM project_members WHERE userId = $1)
+	ORDER BY id`, projectColumns), memberId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			log.Error("Error", "errors", err)
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+// UpdateProject updates project by id with given params where they are not default value
+func (s *Storage) UpdateProject(ctx context.Context, name, description string, id int) (*models.Project, error) {
+	op := "storage.UpdateProject"
+	log := s.log.With("op", op)
+	var fields []string
+	var values []interface{}
+	key := 1
+
+	if name != "" {
+		fields = append(fields, fmt.Sprintf("name = $%d", key))
+		values = append(values, name)
+		key++
+	}
+	if description != "" {
+		fields = append(fields, fmt.Sprintf("description = $%d", key))
+		values = append(values, description)
+		key++
+	}
+
+	// nothing to update
+	if len(fields) == 0 {
+		return s.GetProjectById(ctx, id)
+	}
+
+	values = append(values, id)
+
+	query := fmt.Sprintf("UPDATE projects SET %s WHERE id = $%d RETURNING %s", strings.Join(fields, ", "), key, projectColumns)
+	project, err := scanProject(s.db.QueryRowContext(ctx, query, values...))
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return nil, appErrors.ErrProjectNotExists
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// DeleteProject deletes project by id together with its statuses
+// projects with tasks can not be deleted, also not with tasks in the trash
+// a status.deleted event is added for each of the statuses in the transaction of the delete
+func (s *Storage) DeleteProject(ctx context.Context, id int, actorId string) error {
+	op := "storage.DeleteProject"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	statusIds, err := outbox.SelectIds(ctx, tx, "SELECT id FROM statuses WHERE projectId = $1 ORDER BY id", id)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	// the events have the last state of the statuses, so they are added before the delete
+	for _, statusId := range statusIds {
+		if err = outbox.AddStatusEvent(ctx, tx, models.EventStatusDeleted, int(statusId), nil, actorId); err != nil {
+			log.Error("Error", "errors", err)
+			return err
+		}
+	}
+
+	execContext, err := tx.ExecContext(ctx, "DELETE FROM projects WHERE id = $1", id)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		// if tasks still reference the project
+		if ok && pqErr.Code == "23503" {
+			return appErrors.ErrProjectNotEmpty
+		}
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return appErrors.NothingToDelete
+	}
+
+	return tx.Commit()
+}
+
+// scanProject scans the projectColumns of the row into a project
+func scanProject(row dbutil.Scanner) (*models.Project, error) {
+	var project models.Project
+	var creatorId sql.NullString
+
+	if err := row.Scan(&project.Id, &project.Name, &project.Description, &creatorId, &project.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	project.CreatorId = creatorId.String
+
+	return &project, nil
+}
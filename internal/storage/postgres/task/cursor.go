@@ -0,0 +1,44 @@
+package task
+
+import (
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/storage/postgres/dbutil"
+)
+
+// sortKey is a sql expression tasks are ordered by
+// cast is the sql type the cursor value is converted back to
+type sortKey struct {
+	expr string
+	cast string
+}
+
+// sortKeys are the keys of every sort field in order, ties are broken by the task id
+var sortKeys = map[models.TaskSortField][]sortKey{
+	models.SortById:     {{expr: "t.id", cast: "int"}},
+	models.SortByDue:    {{expr: "COALESCE(t.due, '-infinity'::timestamp)", cast: "timestamp"}},
+	models.SortByTitle:  {{expr: "t.title", cast: "text"}},
+	models.SortByStatus: {{expr: "COALESCE(s.title, '')", cast: "text"}},
+	// most urgent first, then the earliest due date
+	models.SortByPriority: {
+		{expr: "-t.priority", cast: "int"},
+		{expr: "COALESCE(t.due, '-infinity'::timestamp)", cast: "timestamp"},
+	},
+	// only for full text search, q is the search query
+	models.SortByRank: {{expr: "ts_rank(t.search, q)", cast: "real"}},
+	// only for the trash
+	models.SortByDeletedAt: {{expr: "t.deletedAt", cast: "timestamp"}},
+}
+
+// decodeCursor decodes the page token and checks that it was created for the same sort options
+func decodeCursor(token string, sortBy models.TaskSortField, sortDesc bool) (*dbutil.Cursor, error) {
+	return dbutil.DecodeCursor(token, sortBy, sortDesc, len(getSortKeys(sortBy)))
+}
+
+// getSortKeys returns the keys for the sort field, unknown fields are sorted by id
+func getSortKeys(sortBy models.TaskSortField) []sortKey {
+	keys, ok := sortKeys[sortBy]
+	if !ok {
+		return sortKeys[models.SortById]
+	}
+	return keys
+}
@@ -0,0 +1,167 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+)
+
+// AddDependency adds the dependency that blockerId blocks blockedId
+// a dependency creating a cycle is rejected
+func (s *Storage) AddDependency(ctx context.Context, blockerId, blockedId int) (*models.Dependency, error) {
+	op := "storage.AddDependency"
+	log := s.log.With("op", op)
+
+	if blockerId == blockedId {
+		return nil, appErrors.ErrDependencyCycle
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	// serialize the cycle checks, otherwise two concurrent dependencies could create a cycle
+	_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('task_dependencies'))")
+	if err != nil {
+		return nil, err
+	}
+
+	// there is a cycle if the blocked task already blocks the blocker
+	var cycle bool
+	err = tx.QueryRowContext(ctx, `
+	WITH RECURSIVE blocked(id) AS (
+		SELECT blockedId FROM task_dependencies WHERE blockerId = $1
+		UNION
+		SELECT d.blockedId FROM task_dependencies d JOIN blocked b ON d.blockerId = b.id
+	)
+	SELECT EXISTS (SELECT 1 FROM blocked WHERE id = $2)`, blockedId, blockerId).Scan(&cycle)
+	if err != nil {
+		log.Error("Error on checking for cycles", "errors", err)
+		return nil, err
+	}
+
+	if cycle {
+		return nil, appErrors.ErrDependencyCycle
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO task_dependencies (blockerId, blockedId) VALUES ($1, $2)", blockerId, blockedId)
+	if err != nil {
+		// get detailed error
+		pqErr, ok := err.(*pq.Error)
+		// if there is already a row with that blockerId and blockedId
+		if ok && pqErr.Code == "23505" {
+			return nil, appErrors.ErrDependencyExists
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.Dependency{BlockerId: blockerId, BlockedId: blockedId}, nil
+}
+
+// RemoveDependency removes the dependency that blockerId blocks blockedId
+func (s *Storage) RemoveDependency(ctx context.Context, blockerId, blockedId int) error {
+	execContext, err := s.db.ExecContext(ctx, "DELETE FROM task_dependencies WHERE blockerId = $1 AND blockedId = $2", blockerId, blockedId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+	// if no rows were deleted
+	if affected == 0 {
+		return appErrors.NothingToDelete
+	}
+
+	return nil
+}
+
+// GetDependencyGraph gets the task with all tasks blocking it and all tasks blocked by it
+// also the indirect ones, and the dependencies between them, tasks in the trash are left out
+func (s *Storage) GetDependencyGraph(ctx context.Context, taskId int) (*models.DependencyGraph, error) {
+	op := "storage.GetDependencyGraph"
+	log := s.log.With("op", op)
+	var ids []int64
+	var dependencies []*models.Dependency
+
+	rows, err := s.db.QueryContext(ctx, `
+	WITH RECURSIVE active AS (
+		SELECT d.blockerId, d.blockedId FROM task_dependencies d
+		JOIN tasks blocker ON blocker.id = d.blockerId AND blocker.deletedAt IS NULL
+		JOIN tasks blocked ON blocked.id = d.blockedId AND blocked.deletedAt IS NULL
+	), blockers(id) AS (
+		SELECT blockerId FROM active WHERE blockedId = $1
+		UNION
+		SELECT d.blockerId FROM active d JOIN blockers b ON d.blockedId = b.id
+	), blocked(id) AS (
+		SELECT blockedId FROM active WHERE blockerId = $1
+		UNION
+		SELECT d.blockedId FROM active d JOIN blocked b ON d.blockerId = b.id
+	)
+	SELECT id FROM blockers
+	UNION SELECT id FROM blocked
+	UNION SELECT $1`, taskId)
+	if err != nil {
+		log.Error("Error on getting graph", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE t.id = ANY($1) ORDER BY t.id", taskColumns, taskTables)
+	tasks, err := s.queryTasks(ctx, query, pq.Array(ids))
+	if err != nil {
+		log.Error("Error on getting tasks", "errors", err)
+		return nil, err
+	}
+
+	dependencyRows, err := s.db.QueryContext(ctx, `
+	SELECT blockerId, blockedId FROM task_dependencies
+	WHERE blockerId = ANY($1) AND blockedId = ANY($1)
+	ORDER BY id`, pq.Array(ids))
+	if err != nil {
+		log.Error("Error on getting dependencies", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer dependencyRows.Close()
+
+	for dependencyRows.Next() {
+		var dependency models.Dependency
+		if err = dependencyRows.Scan(&dependency.BlockerId, &dependency.BlockedId); err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, &dependency)
+	}
+
+	if err = dependencyRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &models.DependencyGraph{Tasks: tasks, Dependencies: dependencies}, nil
+}
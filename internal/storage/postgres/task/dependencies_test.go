@@ -0,0 +1,91 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/pgtest"
+	"sso_3.0/internal/storage/postgres/project"
+	"sso_3.0/internal/storage/postgres/user"
+	"testing"
+	"time"
+)
+
+// newTestProject creates a user and a project of the user with a status, it returns their ids
+func newTestProject(t *testing.T, s *Storage) (string, int, int) {
+	t.Helper()
+	ctx := context.Background()
+
+	creator, err := user.New(s.db, s.log).Register(ctx, pgtest.Email(t), "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := project.New(s.db, s.log).CreateProject(ctx, t.Name(), "", creator.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := s.CreateStatus(ctx, "todo", "", p.Id, creator.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return creator.Id, p.Id, status.Id
+}
+
+// newTestTasks creates a project with count tasks and returns their ids
+func newTestTasks(t *testing.T, s *Storage, count int) []int {
+	t.Helper()
+	creatorId, projectId, statusId := newTestProject(t, s)
+
+	var ids []int
+	for i := 0; i < count; i++ {
+		task, err := s.CreateTask(context.Background(), "task", "", creatorId, projectId, statusId, 0, models.PriorityNone, time.Time{}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.Id)
+	}
+
+	return ids
+}
+
+func TestAddDependencyRejectsCycles(t *testing.T) {
+	s := New(pgtest.Open(t), pgtest.Logger())
+	ctx := context.Background()
+	ids := newTestTasks(t, s, 4)
+	a, b, c, d := ids[0], ids[1], ids[2], ids[3]
+
+	// a blocks b blocks c
+	for _, dependency := range [][2]int{{a, b}, {b, c}} {
+		if _, err := s.AddDependency(ctx, dependency[0], dependency[1]); err != nil {
+			t.Fatalf("AddDependency(%d, %d) = %v", dependency[0], dependency[1], err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		blockerId int
+		blockedId int
+		err       error
+	}{
+		{name: "itself", blockerId: a, blockedId: a, err: appErrors.ErrDependencyCycle},
+		{name: "direct cycle", blockerId: b, blockedId: a, err: appErrors.ErrDependencyCycle},
+		{name: "cycle over the chain", blockerId: c, blockedId: a, err: appErrors.ErrDependencyCycle},
+		{name: "existing dependency", blockerId: a, blockedId: b, err: appErrors.ErrDependencyExists},
+		{name: "shortcut along the chain", blockerId: a, blockedId: c},
+		{name: "other task", blockerId: c, blockedId: d},
+		{name: "cycle over the new dependency", blockerId: d, blockedId: a, err: appErrors.ErrDependencyCycle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.AddDependency(ctx, tt.blockerId, tt.blockedId)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("AddDependency(%d, %d) = %v, want %v", tt.blockerId, tt.blockedId, err, tt.err)
+			}
+		})
+	}
+}
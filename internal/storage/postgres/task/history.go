@@ -0,0 +1,159 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/storage/postgres/dbutil"
+	"strconv"
+	"time"
+)
+
+// insertHistory writes the entries to the task history in the transaction of the change
+func insertHistory(ctx context.Context, tx *sql.Tx, actorId string, entries ...*models.TaskHistoryEntry) error {
+	for _, entry := range entries {
+		_, err := tx.ExecContext(ctx, "INSERT INTO task_events (taskId, actorId, type, field, oldValue, newValue) VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6)",
+			entry.TaskId, actorId, entry.Type, entry.Field, entry.OldValue, entry.NewValue)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetTaskHistory gets one page of the history of the task, the newest changes first
+// it returns the token of the next page, which is empty on the last page
+func (s *Storage) GetTaskHistory(ctx context.Context, taskId, pageSize int, pageToken string) ([]*models.TaskHistoryEntry, string, error) {
+	op := "storage.GetTaskHistory"
+	log := s.log.With("op", op)
+	var entries []*models.TaskHistoryEntry
+
+	pageSize = dbutil.PageSize(pageSize)
+	query := "SELECT id, taskId, COALESCE(actorId, ''), type, field, oldValue, newValue, createdAt FROM task_events WHERE taskId = $1"
+	values := []any{taskId}
+
+	// continue after the last entry of the previous page
+	if pageToken != "" {
+		c, err := decodeCursor(pageToken, models.SortById, true)
+		if err != nil {
+			return nil, "", err
+		}
+		query += " AND id < $2"
+		values = append(values, c.Id)
+	}
+
+	// fetch one more entry than needed to know if there is a next page
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(values)+1)
+	values = append(values, pageSize+1)
+
+	rows, err := s.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, "", err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.TaskHistoryEntry
+		if err = rows.Scan(&entry.Id, &entry.TaskId, &entry.ActorId, &entry.Type, &entry.Field, &entry.OldValue, &entry.NewValue, &entry.At); err != nil {
+			return nil, "", err
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if len(entries) <= pageSize {
+		return entries, "", nil
+	}
+
+	entries = entries[:pageSize]
+	last := entries[pageSize-1]
+	next := &dbutil.Cursor{SortBy: models.SortById, SortDesc: true, Values: []string{strconv.Itoa(last.Id)}, Id: last.Id}
+
+	return entries, next.Encode(), nil
+}
+
+// taskValues are the values of a task as they are stored in the history
+type taskValues struct {
+	title       string
+	description string
+	due         string
+	completed   string
+	status      string
+	priority    string
+	recurrence  string
+}
+
+// getTaskValues locks the active task for the transaction and returns its current values
+func getTaskValues(ctx context.Context, tx *sql.Tx, id int) (*taskValues, error) {
+	var values taskValues
+	var due sql.NullTime
+	var completed sql.NullBool
+	var statusId sql.NullInt64
+	var priority models.Priority
+
+	err := tx.QueryRowContext(ctx, "SELECT title, description, due, completed, statusId, priority, COALESCE(recurrence, '') FROM tasks WHERE id = $1 AND deletedAt IS NULL FOR UPDATE", id).
+		Scan(&values.title, &values.description, &due, &completed, &statusId, &priority, &values.recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	if due.Valid {
+		values.due = formatTime(due.Time)
+	}
+	if completed.Valid {
+		values.completed = strconv.FormatBool(completed.Bool)
+	}
+	if statusId.Valid {
+		values.status = strconv.FormatInt(statusId.Int64, 10)
+	}
+	values.priority = strconv.Itoa(int(priority))
+
+	return &values, nil
+}
+
+// getChanges returns an history entry for every field which differs between previous and updated
+// empty updated values are not changed by the update
+func getChanges(taskId int, previous, updated *taskValues) []*models.TaskHistoryEntry {
+	var changes []*models.TaskHistoryEntry
+
+	fields := []struct {
+		name              string
+		previous, updated string
+	}{
+		{models.FieldTitle, previous.title, updated.title},
+		{models.FieldDescription, previous.description, updated.description},
+		{models.FieldDue, previous.due, updated.due},
+		{models.FieldCompleted, previous.completed, updated.completed},
+		{models.FieldStatus, previous.status, updated.status},
+		{models.FieldPriority, previous.priority, updated.priority},
+		{models.FieldRecurrence, previous.recurrence, updated.recurrence},
+	}
+
+	for _, field := range fields {
+		if field.updated == "" || field.updated == field.previous {
+			continue
+		}
+		changes = append(changes, &models.TaskHistoryEntry{
+			TaskId:   taskId,
+			Type:     models.TaskUpdated,
+			Field:    field.name,
+			OldValue: field.previous,
+			NewValue: field.updated,
+		})
+	}
+
+	return changes
+}
+
+// formatTime formats a time as it is stored in the history
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
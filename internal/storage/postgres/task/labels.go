@@ -0,0 +1,313 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/outbox"
+	"strconv"
+	"strings"
+)
+
+// CreateLabel is creating label with given params
+func (s *Storage) CreateLabel(ctx context.Context, name, color string) (*models.Label, error) {
+	var id int
+
+	err := s.db.QueryRowContext(ctx, "INSERT INTO labels (name, color) VALUES ($1, $2) RETURNING id", name, color).Scan(&id)
+
+	if err != nil {
+		// get detailed error
+		pqErr, ok := err.(*pq.Error)
+		// if there is already a label with that name
+		if ok && pqErr.Code == "23505" {
+			return nil, appErrors.ErrLabelExists
+		}
+		return nil, err
+	}
+
+	return &models.Label{Id: id, Name: name, Color: color}, nil
+}
+
+// UpdateLabel updates label by id with given params where they are not default value
+func (s *Storage) UpdateLabel(ctx context.Context, name, color string, labelId int) (*models.Label, error) {
+	op := "storage.UpdateLabel"
+	log := s.log.With("op", op)
+	var fields []string
+	var values []interface{}
+	key := 1
+
+	if name != "" {
+		fields = append(fields, fmt.Sprintf("name = $%d", key))
+		values = append(values, name)
+		key++
+	}
+	if color != "" {
+		fields = append(fields, fmt.Sprintf("color = $%d", key))
+		values = append(values, color)
+		key++
+	}
+
+	values = append(values, labelId)
+
+	query := fmt.Sprintf("UPDATE labels SET %s WHERE id = $%d RETURNING name, color", strings.Join(fields, ", "), key)
+	err := s.db.QueryRowContext(ctx, query, values...).Scan(&name, &color)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return nil, appErrors.ErrLabelNotExists
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == "23505" {
+			return nil, appErrors.ErrLabelExists
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	return &models.Label{Id: labelId, Name: name, Color: color}, nil
+}
+
+// DeleteLabel deletes label by id
+// deletes label also from the tasks
+func (s *Storage) DeleteLabel(ctx context.Context, id int, actorId string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	taskIds, err := outbox.SelectIds(ctx, tx, "SELECT taskId FROM task_labels WHERE labelId = $1", id)
+	if err != nil {
+		return err
+	}
+
+	previous, err := outbox.SnapshotTasks(ctx, tx, taskIds)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO task_events (taskId, actorId, type, field, oldValue)
+	SELECT taskId, NULLIF($2, ''), $3, $4, labelId::text FROM task_labels WHERE labelId = $1`, id, actorId, models.TaskUpdated, models.FieldLabel)
+	if err != nil {
+		return err
+	}
+
+	execContext, err := tx.ExecContext(ctx, "DELETE FROM labels WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return appErrors.NothingToDelete
+	}
+
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskUpdated, taskIds, previous, actorId, ""); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetLabelById gets label by id
+func (s *Storage) GetLabelById(ctx context.Context, id int) (*models.Label, error) {
+	var name, color string
+	err := s.db.QueryRowContext(ctx, "SELECT name, color FROM labels WHERE id = $1", id).Scan(&name, &color)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return nil, appErrors.ErrLabelNotExists
+		}
+		return nil, err
+	}
+
+	return &models.Label{Id: id, Name: name, Color: color}, nil
+}
+
+// GetAllLabels this function gets all labels
+func (s *Storage) GetAllLabels(ctx context.Context) ([]*models.Label, error) {
+	op := "storage.GetAllLabels"
+	log := s.log.With("op", op)
+	var labels []*models.Label
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, color FROM labels ORDER BY name")
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	//close the rows on the end
+	defer rows.Close()
+
+	for rows.Next() {
+		var label models.Label
+		if err = rows.Scan(&label.Id, &label.Name, &label.Color); err != nil {
+			log.Error("Error", "errors", err)
+			return nil, err
+		}
+		labels = append(labels, &label)
+	}
+
+	return labels, rows.Err()
+}
+
+// AddLabel adds the label to the task
+func (s *Storage) AddLabel(ctx context.Context, taskId, labelId int, actorId string) (*models.Task, error) {
+	op := "storage.AddLabel"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	previous, err := outbox.SnapshotTasks(ctx, tx, []int64{int64(taskId)})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO task_labels (taskId, labelId) VALUES ($1, $2)", taskId, labelId)
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		// if the task has the label already
+		if ok && pqErr.Code == "23505" {
+			return nil, appErrors.TaskAlreadyLabeled
+		}
+		// if the label does not exist
+		if ok && pqErr.Code == "23503" {
+			return nil, appErrors.ErrLabelNotExists
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	err = insertHistory(ctx, tx, actorId, &models.TaskHistoryEntry{TaskId: taskId, Type: models.TaskUpdated, Field: models.FieldLabel, NewValue: strconv.Itoa(labelId)})
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskUpdated, []int64{int64(taskId)}, previous, actorId, ""); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// get updated task
+	return s.GetTaskById(ctx, taskId)
+}
+
+// RemoveLabel removes the label from the task
+func (s *Storage) RemoveLabel(ctx context.Context, taskId, labelId int, actorId string) (*models.Task, error) {
+	op := "storage.RemoveLabel"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	previous, err := outbox.SnapshotTasks(ctx, tx, []int64{int64(taskId)})
+	if err != nil {
+		return nil, err
+	}
+
+	execRows, err := tx.ExecContext(ctx, "DELETE FROM task_labels WHERE taskId = $1 AND labelId = $2", taskId, labelId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	rowsAffected, err := execRows.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected == 0 {
+		return nil, appErrors.TaskNotLabeled
+	}
+
+	err = insertHistory(ctx, tx, actorId, &models.TaskHistoryEntry{TaskId: taskId, Type: models.TaskUpdated, Field: models.FieldLabel, OldValue: strconv.Itoa(labelId)})
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskUpdated, []int64{int64(taskId)}, previous, actorId, ""); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// get updated task
+	return s.GetTaskById(ctx, taskId)
+}
+
+// addLabels gets the labels of given tasks in one query and adds them to the tasks
+func (s *Storage) addLabels(ctx context.Context, tasks []*models.Task) error {
+	var taskIds []int64
+
+	// map key -> taskId
+	// value -> labels array
+	labels := make(map[int][]*models.Label)
+
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	for _, task := range tasks {
+		taskIds = append(taskIds, int64(task.Id))
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT tl.taskId, l.id, l.name, l.color
+	FROM task_labels tl
+	JOIN labels l ON l.id = tl.labelId
+	WHERE tl.taskId = ANY($1)
+	ORDER BY l.name`, pq.Array(taskIds))
+	if err != nil {
+		return err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskId int
+		var label models.Label
+
+		if err = rows.Scan(&taskId, &label.Id, &label.Name, &label.Color); err != nil {
+			return err
+		}
+
+		labels[taskId] = append(labels[taskId], &label)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		task.Labels = labels[task.Id]
+	}
+
+	return nil
+}
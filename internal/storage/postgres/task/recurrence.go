@@ -0,0 +1,126 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/storage/postgres/outbox"
+	"time"
+)
+
+// GetRecurringTasks gets at most limit occurrences which are completed or due at now and have no next occurrence yet
+// the excluded tasks are skipped, they failed to recur in this run
+func (s *Storage) GetRecurringTasks(ctx context.Context, now time.Time, limit int, excluded []int) ([]*models.Task, error) {
+	op := "storage.GetRecurringTasks"
+	log := s.log.With("op", op)
+
+	query := fmt.Sprintf(`
+	SELECT %s FROM %s
+	WHERE t.recurrence IS NOT NULL AND t.recurredAt IS NULL AND t.deletedAt IS NULL AND (t.completed IS TRUE OR t.due <= $1)
+	AND t.id <> ALL(COALESCE($3::bigint[], '{}'))
+	ORDER BY t.due, t.id LIMIT $2`, taskColumns, taskTables)
+
+	tasks, err := s.queryTasks(ctx, query, now, limit, pq.Array(toInt64s(excluded)))
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// CreateNextOccurrence creates the next occurrence of the recurring task with the due
+// it copies the task with its assignees and labels, the recurrence has to be the one the due was computed with
+// it returns nil if the task recurred already, it was changed or it is in the trash meanwhile
+// the task is marked as recurred in the same transaction, so several replicas never create the same occurrence twice
+func (s *Storage) CreateNextOccurrence(ctx context.Context, id int, recurrence string, due time.Time) (*models.Task, error) {
+	op := "storage.CreateNextOccurrence"
+	log := s.log.With("op", op)
+	var nextId int
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	// the row lock lets a concurrent replica wait and then see recurredAt set
+	recurred, err := tx.ExecContext(ctx, `
+	UPDATE tasks SET recurredAt = now()
+	WHERE id = $1 AND recurrence = $2 AND recurredAt IS NULL AND deletedAt IS NULL`, id, recurrence)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	affected, err := recurred.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, nil
+	}
+
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO tasks (title, description, statusId, creatorId, due, parentId, priority, projectId, recurrence, recurrenceStart, seriesId)
+	SELECT title, description, statusId, creatorId, $2, parentId, priority, projectId, recurrence, recurrenceStart, seriesId
+	FROM tasks WHERE id = $1 RETURNING id`, id, due).Scan(&nextId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = insertHistory(ctx, tx, "", &models.TaskHistoryEntry{TaskId: nextId, Type: models.TaskCreated}); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	WITH assigned AS (
+		INSERT INTO task_assignees (taskId, role, userId) SELECT $2, role, userId FROM task_assignees WHERE taskId = $1 RETURNING userId
+	)
+	INSERT INTO task_events (taskId, type, field, newValue) SELECT $2, $3, $4, userId FROM assigned`, id, nextId, models.TaskAssigned, models.FieldAssignee)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	WITH labeled AS (
+		INSERT INTO task_labels (taskId, labelId) SELECT $2, labelId FROM task_labels WHERE taskId = $1 RETURNING labelId
+	)
+	INSERT INTO task_events (taskId, type, field, newValue) SELECT $2, $3, $4, labelId::text FROM labeled`, id, nextId, models.TaskUpdated, models.FieldLabel)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	// the event is added after the copy, so it has the assignees and the labels
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskCreated, []int64{int64(nextId)}, nil, "", ""); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTaskById(ctx, nextId)
+}
+
+// EndSeries marks the recurring task as recurred without a next occurrence, because its rule has no more occurrences
+func (s *Storage) EndSeries(ctx context.Context, id int, recurrence string) error {
+	op := "storage.EndSeries"
+	log := s.log.With("op", op)
+
+	_, err := s.db.ExecContext(ctx, "UPDATE tasks SET recurredAt = now() WHERE id = $1 AND recurrence = $2 AND recurredAt IS NULL", id, recurrence)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/domain/user"
+	"time"
+)
+
+// GetReminderSettings gets the reminder settings of the user, it returns nil if the user did not set them
+func (s *Storage) GetReminderSettings(ctx context.Context, userId string) (*models.ReminderSettings, error) {
+	op := "storage.GetReminderSettings"
+	log := s.log.With("op", op)
+	var settings models.ReminderSettings
+	var leadTimes []int64
+
+	err := s.db.QueryRowContext(ctx, "SELECT leadTimes, overdue FROM reminder_settings WHERE userId = $1", userId).Scan(pq.Array(&leadTimes), &settings.Overdue)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	for _, leadTime := range leadTimes {
+		settings.LeadTimes = append(settings.LeadTimes, time.Duration(leadTime)*time.Second)
+	}
+
+	return &settings, nil
+}
+
+// SetReminderSettings sets the reminder settings of the user
+func (s *Storage) SetReminderSettings(ctx context.Context, userId string, settings *models.ReminderSettings) error {
+	op := "storage.SetReminderSettings"
+	log := s.log.With("op", op)
+
+	_, err := s.db.ExecContext(ctx, `
+	INSERT INTO reminder_settings (userId, leadTimes, overdue) VALUES ($1, $2, $3)
+	ON CONFLICT (userId) DO UPDATE SET leadTimes = EXCLUDED.leadTimes, overdue = EXCLUDED.overdue`, userId, pq.Array(toSeconds(settings.LeadTimes)), settings.Overdue)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimReminders claims at most limit reminders which are due at now and returns them
+// the creators and the assignees of the open tasks are reminded if they are still members of the project
+// defaults are the lead times of the users without settings, tasks due before oldest get no overdue notification
+// only the shortest lead time which passed is reminded, so a task created shortly before its due is reminded once
+// a claimed reminder is never claimed again, also not by another replica, unless it is released
+func (s *Storage) ClaimReminders(ctx context.Context, now, oldest, newest time.Time, defaults []time.Duration, limit int) ([]*models.Reminder, error) {
+	op := "storage.ClaimReminders"
+	log := s.log.With("op", op)
+	var reminders []*models.Reminder
+
+	rows, err := s.db.QueryContext(ctx, `
+	WITH recipients AS (
+		SELECT t.id AS taskId, t.due, t.projectId, t.creatorId AS userId FROM tasks t
+		WHERE t.deletedAt IS NULL AND t.completed IS NOT TRUE AND t.due > $2 AND t.due <= $3 AND t.creatorId IS NOT NULL
+		UNION
+		SELECT t.id, t.due, t.projectId, ta.userId FROM tasks t JOIN task_assignees ta ON ta.taskId = t.id
+		WHERE t.deletedAt IS NULL AND t.completed IS NOT TRUE AND t.due > $2 AND t.due <= $3 AND ta.userId IS NOT NULL
+	),
+	settings AS (
+		SELECT r.taskId, r.userId, r.due, COALESCE(rs.leadTimes, $4::int[]) AS leadTimes, COALESCE(rs.overdue, true) AS overdue
+		FROM recipients r
+		JOIN project_members pm ON pm.projectId = r.projectId AND pm.userId = r.userId
+		LEFT JOIN reminder_settings rs ON rs.userId = r.userId
+	),
+	pending AS (
+		-- the overdue notification has the lead time 0, users who do not want it get null
+		SELECT st.taskId, st.userId, st.due, threshold FROM settings st, unnest(array_append(st.leadTimes, CASE WHEN st.overdue THEN 0 END)) AS threshold
+		WHERE ((threshold > 0 AND st.due - make_interval(secs => threshold) <= $1 AND st.due > $1
+		        AND NOT EXISTS (SELECT 1 FROM unnest(st.leadTimes) shorter WHERE shorter < threshold AND st.due - make_interval(secs => shorter) <= $1))
+		    OR (threshold = 0 AND st.due <= $1))
+		AND NOT EXISTS (SELECT 1 FROM task_reminders tr WHERE tr.taskId = st.taskId AND tr.userId = st.userId AND tr.leadTime = threshold AND tr.due = st.due)
+		ORDER BY st.due LIMIT $5
+	),
+	claimed AS (
+		INSERT INTO task_reminders (taskId, userId, leadTime, due) SELECT taskId, userId, threshold, due FROM pending
+		ON CONFLICT DO NOTHING RETURNING taskId, userId, leadTime, due
+	)
+	SELECT c.taskId, t.title, c.due, c.leadTime, u.id, u.email FROM claimed c
+	JOIN tasks t ON t.id = c.taskId
+	JOIN users u ON u.id = c.userId
+	ORDER BY c.due`, now, oldest, newest, pq.Array(toSeconds(defaults)), limit)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var reminder models.Reminder
+		var leadTime int64
+		reminder.User = &user.Model{}
+
+		if err = rows.Scan(&reminder.TaskId, &reminder.Title, &reminder.Due, &leadTime, &reminder.User.Id, &reminder.User.Email); err != nil {
+			return nil, err
+		}
+
+		reminder.LeadTime = time.Duration(leadTime) * time.Second
+		reminders = append(reminders, &reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+// ReleaseReminder releases the claim of a reminder which could not be delivered, so it is claimed again
+func (s *Storage) ReleaseReminder(ctx context.Context, reminder *models.Reminder) error {
+	op := "storage.ReleaseReminder"
+	log := s.log.With("op", op)
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM task_reminders WHERE taskId = $1 AND userId = $2 AND leadTime = $3 AND due = $4",
+		reminder.TaskId, reminder.User.Id, int64(reminder.LeadTime.Seconds()), reminder.Due)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// toSeconds converts the durations to whole seconds as they are stored
+func toSeconds(durations []time.Duration) []int64 {
+	seconds := make([]int64, len(durations))
+	for i, duration := range durations {
+		seconds[i] = int64(duration.Seconds())
+	}
+	return seconds
+}
@@ -0,0 +1,90 @@
+package task
+
+import (
+	"context"
+	"slices"
+	"sso_3.0/internal/domain/models"
+	"sso_3.0/internal/storage/postgres/pgtest"
+	"testing"
+	"time"
+)
+
+func TestClaimRemindersOncePerThreshold(t *testing.T) {
+	s := New(pgtest.Open(t), pgtest.Logger())
+	ctx := context.Background()
+	creatorId, projectId, statusId := newTestProject(t, s)
+	defaults := []time.Duration{24 * time.Hour, time.Hour}
+
+	// a due of its own, so the reminders of other tests are not in the window
+	due := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(time.Now().UnixNano()%1e6) * time.Minute)
+	task, err := s.CreateTask(ctx, "remind me", "", creatorId, projectId, statusId, 0, models.PriorityNone, due, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// claim gets the lead times of the reminders of the task claimed at now
+	claim := func(now time.Time) []time.Duration {
+		t.Helper()
+		reminders, err := s.ClaimReminders(ctx, now, due.Add(-time.Second), due.Add(time.Second), defaults, 100)
+		if err != nil {
+			t.Fatalf("ClaimReminders() = %v", err)
+		}
+
+		var leadTimes []time.Duration
+		for _, reminder := range reminders {
+			if reminder.TaskId == task.Id && reminder.User.Id == creatorId {
+				leadTimes = append(leadTimes, reminder.LeadTime)
+			}
+		}
+		return leadTimes
+	}
+
+	steps := []struct {
+		name string
+		now  time.Time
+		want []time.Duration
+	}{
+		{name: "before the first lead time", now: due.Add(-25 * time.Hour)},
+		{name: "a day before", now: due.Add(-23 * time.Hour), want: []time.Duration{24 * time.Hour}},
+		{name: "a day before again", now: due.Add(-22 * time.Hour)},
+		{name: "an hour before", now: due.Add(-30 * time.Minute), want: []time.Duration{time.Hour}},
+		{name: "an hour before again", now: due.Add(-10 * time.Minute)},
+		{name: "overdue", now: due.Add(time.Minute), want: []time.Duration{0}},
+		{name: "overdue again", now: due.Add(time.Hour)},
+	}
+
+	for _, step := range steps {
+		if got := claim(step.now); !slices.Equal(got, step.want) {
+			t.Errorf("%s: claimed %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestClaimRemindersOnlyTheShortestPassedLeadTime(t *testing.T) {
+	s := New(pgtest.Open(t), pgtest.Logger())
+	ctx := context.Background()
+	creatorId, projectId, statusId := newTestProject(t, s)
+
+	due := time.Date(2101, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(time.Now().UnixNano()%1e6) * time.Minute)
+	task, err := s.CreateTask(ctx, "due soon", "", creatorId, projectId, statusId, 0, models.PriorityNone, due, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the task is seen for the first time half an hour before its due, the day before is not sent late
+	reminders, err := s.ClaimReminders(ctx, due.Add(-30*time.Minute), due.Add(-time.Second), due.Add(time.Second), []time.Duration{24 * time.Hour, time.Hour}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var leadTimes []time.Duration
+	for _, reminder := range reminders {
+		if reminder.TaskId == task.Id {
+			leadTimes = append(leadTimes, reminder.LeadTime)
+		}
+	}
+
+	if want := []time.Duration{time.Hour}; !slices.Equal(leadTimes, want) {
+		t.Errorf("claimed %v, want %v", leadTimes, want)
+	}
+}
@@ -8,9 +8,13 @@ import (
 	"github.com/lib/pq"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 	"log/slog"
+	"maps"
 	"sso_3.0/internal/domain/models"
 	"sso_3.0/internal/domain/user"
 	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/dbutil"
+	"sso_3.0/internal/storage/postgres/outbox"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -25,22 +29,117 @@ func New(db *sql.DB, log *slog.Logger) *Storage {
 }
 
 // CreateTask is creating a new tasm with given params
-func (s *Storage) CreateTask(ctx context.Context, title, description, creatorId string, statusId int, due time.Time) (*models.Task, error) {
+// parentId 0 creates a top level task, with a recurrence it is the first occurrence of a series
+func (s *Storage) CreateTask(ctx context.Context, title, description, creatorId string, projectId, statusId, parentId int, priority models.Priority, due time.Time, recurrence string) (*models.Task, error) {
 	var id int
 
-	err := s.db.QueryRowContext(ctx, "INSERT INTO tasks (title, description, statusid, creatorId, due) VALUES ($1, $2, $3, $4, $5) RETURNING id", title, description, statusId, creatorId, due).Scan(&id)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO tasks (title, description, statusid, creatorId, due, parentId, priority, projectId, recurrence, recurrenceStart) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id",
+		title, description, statusId, creatorId, due, nullInt(parentId), priority, projectId, nullString(recurrence), sql.NullTime{Time: due, Valid: recurrence != ""}).Scan(&id)
 
 	if err != nil {
 		fmt.Println(err)
 		return nil, err
 	}
 
+	// the first occurrence starts the series
+	if recurrence != "" {
+		if _, err = tx.ExecContext(ctx, "UPDATE tasks SET seriesId = id WHERE id = $1", id); err != nil {
+			return nil, err
+		}
+	}
+
+	err = insertHistory(ctx, tx, creatorId, &models.TaskHistoryEntry{TaskId: id, Type: models.TaskCreated})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskCreated, []int64{int64(id)}, nil, creatorId, ""); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return s.GetTaskById(ctx, id)
 }
 
-// DeleteTask is deleting task by taskId
-func (s *Storage) DeleteTask(ctx context.Context, id int) error {
-	execContext, err := s.db.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)
+// DeleteTask is moving task by taskId to the trash
+// the policy decides what happens to the subtasks of the task
+func (s *Storage) DeleteTask(ctx context.Context, id int, policy models.SubtaskPolicy, actorId string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	ids := []int64{int64(id)}
+
+	switch policy {
+	case models.DeleteSubtasks:
+		subtaskIds, err := getSubtaskIds(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, subtaskIds...)
+	case models.DetachSubtasks:
+		detachedIds, err := outbox.SelectIds(ctx, tx, "SELECT id FROM tasks WHERE parentId = $1 AND deletedAt IS NULL", id)
+		if err != nil {
+			return err
+		}
+
+		detached, err := outbox.SnapshotTasks(ctx, tx, detachedIds)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO task_events (taskId, actorId, type, field, oldValue, newValue)
+		SELECT id, NULLIF($2, ''), $3, $4, parentId::text, COALESCE((SELECT parentId FROM tasks WHERE id = $1)::text, '')
+		FROM tasks WHERE parentId = $1 AND deletedAt IS NULL`, id, actorId, models.TaskUpdated, models.FieldParent)
+		if err != nil {
+			return err
+		}
+
+		// move the subtasks to the parent of the deleted task
+		_, err = tx.ExecContext(ctx, "UPDATE tasks SET parentId = (SELECT parentId FROM tasks WHERE id = $1) WHERE parentId = $1 AND deletedAt IS NULL", id)
+		if err != nil {
+			return err
+		}
+
+		if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskUpdated, detachedIds, detached, actorId, ""); err != nil {
+			return err
+		}
+	default:
+		var hasSubtasks bool
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tasks WHERE parentId = $1 AND deletedAt IS NULL)", id).Scan(&hasSubtasks)
+		if err != nil {
+			return err
+		}
+		if hasSubtasks {
+			return appErrors.ErrTaskHasSubtasks
+		}
+	}
+
+	previous, err := outbox.SnapshotTasks(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	// trash the tasks and record it in the history
+	execContext, err := tx.ExecContext(ctx, `
+	WITH deleted AS (
+		UPDATE tasks SET deletedAt = now() WHERE id = ANY($1) AND deletedAt IS NULL RETURNING id
+	)
+	INSERT INTO task_events (taskId, actorId, type) SELECT id, NULLIF($2, ''), $3 FROM deleted`, pq.Array(ids), actorId, models.TaskDeleted)
 	if err != nil {
 		return err
 	}
@@ -54,11 +153,19 @@ func (s *Storage) DeleteTask(ctx context.Context, id int) error {
 		return appErrors.NothingToDelete
 	}
 
-	return nil
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskDeleted, ids, previous, actorId, ""); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // UpdateTask is updating task by given params where they are not default value
-func (s *Storage) UpdateTask(ctx context.Context, title, description string, due time.Time, status *models.Status, completed *wrapperspb.BoolValue, id int) (*models.Task, error) {
+// a task can only be completed when all subtasks and all blocking tasks are completed
+// with cascade all subtasks are completed together with the task
+// a new recurrence starts the series again from the due of the task, an empty one ends the series with this occurrence
+func (s *Storage) UpdateTask(ctx context.Context, title, description string, due time.Time, status *models.Status, completed *wrapperspb.BoolValue, priority *models.Priority, recurrence *string, cascade bool, id int, actorId string) (*models.Task, error) {
+	var updated taskValues
 	var fields []string
 	var values []interface{}
 	key := 2
@@ -69,76 +176,293 @@ func (s *Storage) UpdateTask(ctx context.Context, title, description string, due
 	if title != "" {
 		fields = append(fields, fmt.Sprintf("title = $%d", key))
 		values = append(values, title)
+		updated.title = title
 		key++
 	}
 
 	if description != "" {
 		fields = append(fields, fmt.Sprintf("description = $%d", key))
 		values = append(values, description)
+		updated.description = description
 		key++
 	}
 
 	if !due.IsZero() {
 		fields = append(fields, fmt.Sprintf("due = $%d", key))
 		values = append(values, due)
+		updated.due = formatTime(due)
 		key++
 	}
 
 	if completed != nil {
 		fields = append(fields, fmt.Sprintf("completed = $%d", key))
 		values = append(values, completed.Value)
+		updated.completed = strconv.FormatBool(completed.Value)
 		key++
 	}
 
 	if status != nil {
 		fields = append(fields, fmt.Sprintf("statusId = $%d", key))
 		values = append(values, status.Id)
+		updated.status = strconv.Itoa(status.Id)
+		key++
+	}
+
+	if priority != nil {
+		fields = append(fields, fmt.Sprintf("priority = $%d", key))
+		values = append(values, *priority)
+		updated.priority = strconv.Itoa(int(*priority))
 		key++
 	}
 
+	if recurrence != nil && *recurrence == "" {
+		fields = append(fields, "recurrence = NULL", "recurrenceStart = NULL")
+	}
+
+	if recurrence != nil && *recurrence != "" {
+		fields = append(fields, fmt.Sprintf("recurrence = $%d", key), fmt.Sprintf("recurrenceStart = COALESCE($%d, due)", key+1), "seriesId = COALESCE(seriesId, id)")
+		values = append(values, *recurrence, sql.NullTime{Time: due, Valid: !due.IsZero()})
+		updated.recurrence = *recurrence
+		key += 2
+	}
+
+	// nothing to update
+	if len(fields) == 0 {
+		return s.GetTaskById(ctx, id)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	previous, err := getTaskValues(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, appErrors.ErrTaskNotExists
+		}
+		return nil, err
+	}
+
+	snapshots, err := outbox.SnapshotTasks(ctx, tx, []int64{int64(id)})
+	if err != nil {
+		return nil, err
+	}
+
+	// the subtasks completed together with the task
+	var cascadedIds []int64
+
+	if completed != nil && completed.Value {
+		subtaskIds, err := getSubtaskIds(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// the tasks which are completed by this update
+		completedIds := []int64{int64(id)}
+
+		if cascade {
+			completedIds = append(completedIds, subtaskIds...)
+		} else {
+			var hasOpenSubtasks bool
+			err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ANY($1) AND completed IS NOT TRUE)", pq.Array(subtaskIds)).Scan(&hasOpenSubtasks)
+			if err != nil {
+				return nil, err
+			}
+			if hasOpenSubtasks {
+				return nil, appErrors.ErrTaskHasOpenSubtasks
+			}
+		}
+
+		// blockers completed by the same update do not count as open
+		var blocked bool
+		err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM task_dependencies d
+			JOIN tasks t ON t.id = d.blockerId
+			WHERE d.blockedId = ANY($1) AND NOT d.blockerId = ANY($1) AND t.completed IS NOT TRUE AND t.deletedAt IS NULL
+		)`, pq.Array(completedIds)).Scan(&blocked)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, appErrors.ErrTaskBlocked
+		}
+
+		if cascade {
+			cascadedIds, err = outbox.SelectIds(ctx, tx, "SELECT id FROM tasks WHERE id = ANY($1) AND completed IS NOT TRUE", pq.Array(subtaskIds))
+			if err != nil {
+				return nil, err
+			}
+
+			cascaded, err := outbox.SnapshotTasks(ctx, tx, cascadedIds)
+			if err != nil {
+				return nil, err
+			}
+			maps.Copy(snapshots, cascaded)
+
+			_, err = tx.ExecContext(ctx, `
+			INSERT INTO task_events (taskId, actorId, type, field, oldValue, newValue)
+			SELECT id, NULLIF($2, ''), $3, $4, COALESCE(completed::text, ''), 'true'
+			FROM tasks WHERE id = ANY($1) AND completed IS NOT TRUE`, pq.Array(subtaskIds), actorId, models.TaskUpdated, models.FieldCompleted)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = tx.ExecContext(ctx, "UPDATE tasks SET completed = true WHERE id = ANY($1)", pq.Array(subtaskIds))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	query := fmt.Sprintf("UPDATE tasks SET %s WHERE id = $1", strings.Join(fields, ", "))
 
 	//execute the update and get new values
-	_, err := s.db.ExecContext(ctx, query, values...)
+	_, err = tx.ExecContext(ctx, query, values...)
 	if err != nil {
 		fmt.Println(err)
 		return nil, err
 	}
 
+	changes := getChanges(id, previous, &updated)
+
+	// the empty value of the ended series is not found by getChanges
+	if recurrence != nil && *recurrence == "" && previous.recurrence != "" {
+		changes = append(changes, &models.TaskHistoryEntry{TaskId: id, Type: models.TaskUpdated, Field: models.FieldRecurrence, OldValue: previous.recurrence})
+	}
+
+	if err = insertHistory(ctx, tx, actorId, changes...); err != nil {
+		return nil, err
+	}
+
+	// the task gets no event if the update did not change it
+	updatedIds := cascadedIds
+	if len(changes) > 0 {
+		updatedIds = append(updatedIds, int64(id))
+	}
+
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskUpdated, updatedIds, snapshots, actorId, ""); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return s.GetTaskById(ctx, id)
 }
 
+// getSubtaskIds gets the ids of all active subtasks of the task, also the nested ones
+func getSubtaskIds(ctx context.Context, tx *sql.Tx, id int) ([]int64, error) {
+	var ids []int64
+
+	rows, err := tx.QueryContext(ctx, `
+	WITH RECURSIVE subtasks AS (
+		SELECT id FROM tasks WHERE parentId = $1 AND deletedAt IS NULL
+		UNION ALL
+		SELECT t.id FROM tasks t JOIN subtasks st ON t.parentId = st.id WHERE t.deletedAt IS NULL
+	)
+	SELECT id FROM subtasks`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var subtaskId int64
+		if err = rows.Scan(&subtaskId); err != nil {
+			return nil, err
+		}
+		ids = append(ids, subtaskId)
+	}
+
+	return ids, rows.Err()
+}
+
 // CreateStatus is creating status with given params
-func (s *Storage) CreateStatus(ctx context.Context, title, description string) (*models.Status, error) {
+// projectId 0 creates a status shared by all projects
+func (s *Storage) CreateStatus(ctx context.Context, title, description string, projectId int, actorId string) (*models.Status, error) {
 	var id int
 
-	err := s.db.QueryRowContext(ctx, "INSERT INTO statuses (title, description) VALUES ($1,$2) RETURNING id", title, description).Scan(&id)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO statuses (title, description, projectId) VALUES ($1,$2,$3) RETURNING id", title, description, nullInt(projectId)).Scan(&id)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if err = outbox.AddStatusEvent(ctx, tx, models.EventStatusCreated, id, nil, actorId); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return &models.Status{
 		Id:          id,
 		Description: description,
 		Title:       title,
+		ProjectId:   projectId,
 	}, nil
 }
 
 // DeleteStatus deletes status by id
 // deletes status also from the tasks
-func (s *Storage) DeleteStatus(ctx context.Context, id int) error {
+func (s *Storage) DeleteStatus(ctx context.Context, id int, actorId string) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
 
+	taskIds, err := outbox.SelectIds(ctx, tx, "SELECT id FROM tasks WHERE statusId = $1", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	previous, err := outbox.SnapshotTasks(ctx, tx, taskIds)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO task_events (taskId, actorId, type, field, oldValue)
+	SELECT id, NULLIF($2, ''), $3, $4, statusId::text FROM tasks WHERE statusId = $1`, id, actorId, models.TaskUpdated, models.FieldStatus)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	_, err = tx.ExecContext(ctx, "UPDATE tasks SET statusId=null WHERE statusId=$1 ", id)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskUpdated, taskIds, previous, actorId, ""); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// the event has the last state of the status, so it is added before the delete
+	if err = outbox.AddStatusEvent(ctx, tx, models.EventStatusDeleted, id, nil, actorId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	execContext, err := tx.ExecContext(ctx, "DELETE FROM statuses WHERE id = $1", id)
 	if err != nil {
 		tx.Rollback()
@@ -166,7 +490,8 @@ func (s *Storage) DeleteStatus(ctx context.Context, id int) error {
 // GetStatusById gets status by id
 func (s *Storage) GetStatusById(ctx context.Context, id int) (*models.Status, error) {
 	var title, description string
-	err := s.db.QueryRowContext(ctx, "SELECT title, description FROM statuses WHERE id = $1", id).Scan(&title, &description)
+	var projectId sql.NullInt64
+	err := s.db.QueryRowContext(ctx, "SELECT title, description, projectId FROM statuses WHERE id = $1", id).Scan(&title, &description, &projectId)
 	if err != nil {
 		fmt.Println(err)
 		if errors.Is(sql.ErrNoRows, err) {
@@ -175,105 +500,99 @@ func (s *Storage) GetStatusById(ctx context.Context, id int) (*models.Status, er
 		return nil, err
 	}
 
-	return &models.Status{Id: id, Title: title, Description: description}, nil
+	return &models.Status{Id: id, Title: title, Description: description, ProjectId: int(projectId.Int64)}, nil
 }
 
-// GetTaskById gets task by id
+// GetTaskById gets task by id, tasks in the trash do not exist
 func (s *Storage) GetTaskById(ctx context.Context, id int) (*models.Task, error) {
-	var found bool
-	var assignees []*models.Assignee
-	op := "storage.GetTaskById"
-	log := s.log.With(op)
-	var title, creatorId, description string
-	var due time.Time
-	//completed
-	var completed *sql.NullBool
-	var completedWrapper *wrapperspb.BoolValue
-	//status
-	var status *models.Status
-	//query the tasks where user is part of assignees
-	rows, err := s.db.QueryContext(ctx, `
-	SELECT t.id, t.title, t.description, t.creatorId,
-		   t.statusId, t.due, t.completed, ta.role, 
-		   ta.userId as assigneeId, u.email as assigneeEmail,
-		   ta.id as taskId
-	FROM tasks t 
-	LEFT JOIN task_assignees ta ON ta.taskId = t.id 
-	LEFT JOIN users u ON ta.userId = u.id
-	WHERE t.id = $1
-	`, id)
+	return s.getTask(ctx, id, false)
+}
+
+// GetDeletedTaskById gets task by id, only if it is in the trash
+func (s *Storage) GetDeletedTaskById(ctx context.Context, id int) (*models.Task, error) {
+	return s.getTask(ctx, id, true)
+}
+
+// getTask gets an active or a deleted task by id
+func (s *Storage) getTask(ctx context.Context, id int, deleted bool) (*models.Task, error) {
+	op := "storage.getTask"
+	log := s.log.With("op", op)
 
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE t.id = $1 AND (t.deletedAt IS NOT NULL) = $2", taskColumns, taskTables)
+
+	task, err := scanTask(s.db.QueryRowContext(ctx, query, id, deleted))
 	if err != nil {
-		log.Error("Error: ", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, appErrors.ErrTaskNotExists
+		}
+		log.Error("Error", "errors", err)
 		return nil, err
 	}
-	if err = rows.Err(); err != nil {
+
+	if err = s.addDetails(ctx, []*models.Task{task}); err != nil {
+		log.Error("Error on getting task details", "errors", err)
 		return nil, err
 	}
 
-	//close rows on end
-	defer rows.Close()
+	return task, nil
+}
 
-	//get every task by
-	for rows.Next() {
-		found = true
+// GetSubtasks gets the subtasks of the task
+// with recursive also the subtasks of the subtasks are returned
+func (s *Storage) GetSubtasks(ctx context.Context, id int, recursive bool) ([]*models.Task, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE t.parentId = $1 AND t.deletedAt IS NULL ORDER BY t.id", taskColumns, taskTables)
+
+	if recursive {
+		query = fmt.Sprintf(`
+		WITH RECURSIVE subtasks AS (
+			SELECT id FROM tasks WHERE parentId = $1 AND deletedAt IS NULL
+			UNION ALL
+			SELECT t.id FROM tasks t JOIN subtasks st ON t.parentId = st.id WHERE t.deletedAt IS NULL
+		)
+		SELECT %s FROM %s WHERE t.id IN (SELECT id FROM subtasks) ORDER BY t.id`, taskColumns, taskTables)
+	}
 
-		// define all needed vars
+	return s.queryTasks(ctx, query, id)
+}
 
-		var statusId *sql.NullInt64
-		// assignee
-		var assigneeUserId *sql.NullString
-		var assigneeId *sql.NullInt64
-		var assigneeRole *sql.NullString
-		var assigneeEmail *sql.NullString
+// queryTasks runs a query selecting taskColumns and returns the tasks with their assignees
+func (s *Storage) queryTasks(ctx context.Context, query string, values ...any) ([]*models.Task, error) {
+	var tasks []*models.Task
 
-		// get values from row
-		err = rows.Scan(&id, &title, &description, &creatorId, &statusId, &due, &completed, &assigneeRole, &assigneeUserId, &assigneeEmail, &assigneeId)
+	rows, err := s.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
 
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
+		tasks = append(tasks, task)
+	}
 
-		// get status if it is not null
-		if statusId != nil && statusId.Valid {
-			status, err = s.GetStatusById(ctx, int(statusId.Int64))
-			if err != nil {
-				return nil, err
-			}
-		}
-
-		// if completed != null
-		if completed != nil && completed.Valid {
-			completedWrapper = wrapperspb.Bool(completed.Bool)
-		}
-
-		if assigneeId != nil && assigneeId.Valid {
-			assignees = append(assignees, &models.Assignee{Id: int(assigneeId.Int64), TaskId: id, Role: assigneeRole.String, User: &user.Model{Id: assigneeUserId.String, Email: assigneeEmail.String}})
-		}
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if !found {
-		return nil, appErrors.ErrTaskNotExists
+	if err = s.addDetails(ctx, tasks); err != nil {
+		return nil, err
 	}
 
-	return &models.Task{
-		Id:          id,
-		Title:       title,
-		Description: description,
-		Due:         due,
-		Completed:   completedWrapper,
-		Status:      status,
-		CreatorId:   creatorId,
-		Assignees:   assignees,
-	}, nil
+	return tasks, nil
 }
 
 // UpdateStatus updates status by id with given params
-func (s *Storage) UpdateStatus(ctx context.Context, title, description string, statusId int) (*models.Status, error) {
+func (s *Storage) UpdateStatus(ctx context.Context, title, description string, statusId int, actorId string) (*models.Status, error) {
 	op := "storage.updateStatus"
-	log := s.log.With(op)
+	log := s.log.With("op", op)
 	var fields []string
 	var values []interface{}
+	var projectId sql.NullInt64
 	key := 1
 
 	if title != "" {
@@ -289,185 +608,471 @@ func (s *Storage) UpdateStatus(ctx context.Context, title, description string, s
 
 	values = append(values, statusId)
 
-	query := fmt.Sprintf("UPDATE statuses SET %s WHERE id = $%d RETURNING title, description", strings.Join(fields, ", "), key)
-	err := s.db.QueryRowContext(ctx, query, values...).Scan(&title, &description)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Error("Error: ", err)
 		return nil, err
 	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
 
-	return &models.Status{Id: statusId, Title: title, Description: description}, nil
+	previous, err := outbox.SnapshotStatus(ctx, tx, statusId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("UPDATE statuses SET %s WHERE id = $%d RETURNING title, description, projectId", strings.Join(fields, ", "), key)
+	err = tx.QueryRowContext(ctx, query, values...).Scan(&title, &description, &projectId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = outbox.AddStatusEvent(ctx, tx, models.EventStatusUpdated, statusId, previous, actorId); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.Status{Id: statusId, Title: title, Description: description, ProjectId: int(projectId.Int64)}, nil
+}
+
+// taskColumns are the columns read by scanTask
+// the queries have to select from tasks t joined with statuses s
+const taskColumns = `t.id, t.title, t.description, t.creatorId,
+			   t.due, t.completed, t.parentId, t.priority, t.deletedAt, t.projectId, t.recurrence, t.recurrenceStart, t.seriesId,
+			   s.id, s.title, s.description, s.projectId`
+
+const taskTables = `tasks t
+			LEFT JOIN statuses s ON s.id = t.statusId`
+
+// scanTask scans the taskColumns of the row into a task
+// extra are the destinations of the columns selected after taskColumns
+func scanTask(row dbutil.Scanner, extra ...any) (*models.Task, error) {
+	var task models.Task
+	var due, deletedAt, recurrenceStart sql.NullTime
+	var completed sql.NullBool
+	var parentId, seriesId, statusId, statusProjectId sql.NullInt64
+	var recurrence, statusTitle, statusDescription sql.NullString
+
+	dest := []any{&task.Id, &task.Title, &task.Description, &task.CreatorId,
+		&due, &completed, &parentId, &task.Priority, &deletedAt, &task.ProjectId, &recurrence, &recurrenceStart, &seriesId,
+		&statusId, &statusTitle, &statusDescription, &statusProjectId}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if due.Valid {
+		task.Due = due.Time
+	}
+
+	// if the task is in the trash
+	if deletedAt.Valid {
+		task.DeletedAt = deletedAt.Time
+	}
+
+	// if parentId != null
+	if parentId.Valid {
+		task.ParentId = int(parentId.Int64)
+	}
+
+	// if the task is recurring, the series id stays after the recurrence ended
+	task.Recurrence = recurrence.String
+	task.RecurrenceStart = recurrenceStart.Time
+	task.SeriesId = int(seriesId.Int64)
+
+	// if completed != null
+	if completed.Valid {
+		task.Completed = wrapperspb.Bool(completed.Bool)
+	}
+
+	// if status != null
+	if statusId.Valid {
+		task.Status = &models.Status{Id: int(statusId.Int64), Title: statusTitle.String, Description: statusDescription.String, ProjectId: int(statusProjectId.Int64)}
+	}
+
+	return &task, nil
 }
 
-// GetCreatedTasksByFilter gets tasks by given filters
-func (s *Storage) GetCreatedTasksByFilter(ctx context.Context, filters *models.TaskFilters, userId string) ([]*models.Task, error) {
-	op := "storage.GetCreatedTasksByUID"
-	log := s.log.With(op)
+// GetCreatedTasksByFilter gets one page of tasks by given filters
+// it returns the token of the next page, which is empty on the last page
+func (s *Storage) GetCreatedTasksByFilter(ctx context.Context, filters *models.TaskFilters, userId string) ([]*models.Task, string, error) {
+	op := "storage.GetCreatedTasksByFilter"
+	log := s.log.With("op", op)
 	var tasks []*models.Task
+	// sort values of every task, needed for the next page token
+	var sortValues [][]string
+	// sort expressions to select, order by and compare with the cursor
+	var selectKeys, orderKeys, cursorKeys, cursorValues []string
+
+	pageSize := dbutil.PageSize(filters.PageSize)
+	keys := getSortKeys(filters.SortBy)
+
+	filterQueries, values := getFilterQueries(filters, userId)
+	keyCount := len(values) + 1
+
+	order := "ASC"
+	operator := ">"
+	if filters.SortDesc {
+		order = "DESC"
+		operator = "<"
+	}
 
-	// task assignees
-	// map key -> taskId
-	// value -> assignees array
-	assignees := make(map[int][]*models.Assignee)
+	// continue after the last task of the previous page
+	if filters.PageToken != "" {
+		c, err := decodeCursor(filters.PageToken, filters.SortBy, filters.SortDesc)
+		if err != nil {
+			return nil, "", err
+		}
 
-	//filter values
-	var values []any
-	// filter string queries
-	var filerQueries []string
+		for i, key := range keys {
+			cursorKeys = append(cursorKeys, key.expr)
+			cursorValues = append(cursorValues, fmt.Sprintf("$%d::%s", keyCount, key.cast))
+			keyCount += 1
+			values = append(values, c.Values[i])
+		}
+
+		cursorKeys = append(cursorKeys, "t.id")
+		cursorValues = append(cursorValues, fmt.Sprintf("$%d", keyCount))
+		keyCount += 1
+		values = append(values, c.Id)
 
-	// if there are no filters keyCount will be 1
+		filterQueries = append(filterQueries, fmt.Sprintf("(%s) %s (%s)", strings.Join(cursorKeys, ", "), operator, strings.Join(cursorValues, ", ")))
+	}
+
+	for _, key := range keys {
+		selectKeys = append(selectKeys, fmt.Sprintf("(%s)::text", key.expr))
+		orderKeys = append(orderKeys, fmt.Sprintf("%s %s", key.expr, order))
+	}
+
+	query := fmt.Sprintf(`SELECT %s, %s FROM %s`, taskColumns, strings.Join(selectKeys, ", "), taskTables)
+
+	if len(filterQueries) > 0 {
+		query += " WHERE " + strings.Join(filterQueries, " AND ")
+	}
+
+	// fetch one more task than needed to know if there is a next page
+	query += fmt.Sprintf(" ORDER BY %s, t.id %s LIMIT $%d", strings.Join(orderKeys, ", "), order, keyCount)
+	values = append(values, pageSize+1)
+
+	rows, err := s.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		log.Error("Error on getting tasks", "errors", err)
+		return nil, "", err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		taskSortValues := make([]string, len(keys))
+		var dest []any
+		for i := range taskSortValues {
+			dest = append(dest, &taskSortValues[i])
+		}
+
+		task, err := scanTask(rows, dest...)
+		if err != nil {
+			return nil, "", err
+		}
+
+		tasks = append(tasks, task)
+		sortValues = append(sortValues, taskSortValues)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextPageToken string
+	if len(tasks) > pageSize {
+		tasks = tasks[:pageSize]
+		nextPageToken = (&dbutil.Cursor{
+			SortBy:   filters.SortBy,
+			SortDesc: filters.SortDesc,
+			Values:   sortValues[pageSize-1],
+			Id:       tasks[pageSize-1].Id,
+		}).Encode()
+	}
+
+	if err = s.addDetails(ctx, tasks); err != nil {
+		log.Error("Error on getting task details", "errors", err)
+		return nil, "", err
+	}
+
+	return tasks, nextPageToken, nil
+}
+
+// SearchTasks gets one page of tasks matching the full text query and given filters
+// results are ordered by rank, the sort options of the filters are ignored
+func (s *Storage) SearchTasks(ctx context.Context, search string, filters *models.TaskFilters, userId string) ([]*models.TaskSearchResult, string, error) {
+	op := "storage.SearchTasks"
+	log := s.log.With("op", op)
+	var results []*models.TaskSearchResult
+	var tasks []*models.Task
+
+	pageSize := dbutil.PageSize(filters.PageSize)
+
+	filterQueries, values := getFilterQueries(filters, userId)
+	keyCount := len(values) + 1
+
+	// the search query is always the first value after the filters
+	searchKey := keyCount
+	keyCount += 1
+	values = append(values, search)
+	filterQueries = append(filterQueries, "t.search @@ q")
+
+	// continue after the last result of the previous page
+	if filters.PageToken != "" {
+		c, err := decodeCursor(filters.PageToken, models.SortByRank, true)
+		if err != nil {
+			return nil, "", err
+		}
+
+		filterQueries = append(filterQueries, fmt.Sprintf("(ts_rank(t.search, q), t.id) < ($%d::real, $%d)", keyCount, keyCount+1))
+		keyCount += 2
+		values = append(values, c.Values[0], c.Id)
+	}
+
+	query := fmt.Sprintf(`SELECT %s, ts_rank(t.search, q),
+			   ts_headline('english', t.title, q, 'HighlightAll=true'),
+			   ts_headline('english', t.description, q, 'MaxFragments=2')
+			FROM %s, websearch_to_tsquery('english', $%d) q
+			WHERE %s
+			ORDER BY ts_rank(t.search, q) DESC, t.id DESC
+			LIMIT $%d`, taskColumns, taskTables, searchKey, strings.Join(filterQueries, " AND "), keyCount)
+	values = append(values, pageSize+1)
+
+	rows, err := s.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		log.Error("Error on searching tasks", "errors", err)
+		return nil, "", err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.TaskSearchResult
+
+		result.Task, err = scanTask(rows, &result.Rank, &result.TitleHighlight, &result.DescriptionHighlight)
+		if err != nil {
+			return nil, "", err
+		}
+
+		results = append(results, &result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextPageToken string
+	if len(results) > pageSize {
+		results = results[:pageSize]
+		last := results[pageSize-1]
+		nextPageToken = (&dbutil.Cursor{
+			SortBy:   models.SortByRank,
+			SortDesc: true,
+			Values:   []string{strconv.FormatFloat(float64(last.Rank), 'g', -1, 32)},
+			Id:       last.Task.Id,
+		}).Encode()
+	}
+
+	for _, result := range results {
+		tasks = append(tasks, result.Task)
+	}
+
+	if err = s.addDetails(ctx, tasks); err != nil {
+		log.Error("Error on getting task details", "errors", err)
+		return nil, "", err
+	}
+
+	return results, nextPageToken, nil
+}
+
+// getFilterQueries generates the sql conditions and their values for given filters
+// the conditions use the tasks table as t
+func getFilterQueries(filters *models.TaskFilters, userId string) ([]string, []any) {
+	var filterQueries []string
+	var values []any
 	keyCount := 1
 
-	// main query without filters
-	mainQuery := `SELECT t.id, t.title, t.description, t.creatorId,
-			   t.statusId, t.due, t.completed, ta.role, 
-			   ta.userId as assigneeId, u.email as assigneeEmail,
-			   ta.id as taskId
+	if filters.Deleted {
+		filterQueries = append(filterQueries, "t.deletedAt IS NOT NULL")
+	} else {
+		filterQueries = append(filterQueries, "t.deletedAt IS NULL")
+	}
 
-			FROM tasks t
-			LEFT JOIN task_assignees ta ON ta.taskId = t.id 
-			LEFT JOIN users u ON ta.userId = u.id`
+	if filters.ProjectId != 0 {
+		filterQueries = append(filterQueries, fmt.Sprintf("t.projectId = $%d", keyCount))
+		keyCount += 1
+		values = append(values, filters.ProjectId)
+	}
 
-	//generate query
+	if filters.MemberId != "" {
+		filterQueries = append(filterQueries, fmt.Sprintf("t.projectId IN (SELECT projectId FROM project_members WHERE userId = $%d)", keyCount))
+		keyCount += 1
+		values = append(values, filters.MemberId)
+	}
 
 	if filters.CreatedByMe {
-		filerQueries = append(filerQueries, fmt.Sprintf("t.creatorId = $%d", keyCount))
+		filterQueries = append(filterQueries, fmt.Sprintf("t.creatorId = $%d", keyCount))
 		keyCount += 1
 		values = append(values, userId)
 	}
 
 	if filters.Completed {
-		filerQueries = append(filerQueries, fmt.Sprintf("t.completed = $%d", keyCount))
-		keyCount += 1
-		values = append(values, true)
+		filterQueries = append(filterQueries, "t.completed IS TRUE")
 	}
 
+	// tasks without a completed value are open too, before only the tasks with completed = false were returned
 	if filters.UnCompleted {
-		filerQueries = append(filerQueries, fmt.Sprintf("t.completed != $%d", keyCount))
-		keyCount += 1
-		values = append(values, true)
+		filterQueries = append(filterQueries, "t.completed IS NOT TRUE")
 	}
 
 	if filters.AssigneeId != "" {
-		filerQueries = append(filerQueries, fmt.Sprintf("ta.userId = $%d", keyCount))
+		filterQueries = append(filterQueries, fmt.Sprintf("EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.taskId = t.id AND ta.userId = $%d)", keyCount))
 		keyCount += 1
 		values = append(values, filters.AssigneeId)
 	}
 
 	if filters.AssignedToMe {
-		filerQueries = append(filerQueries, fmt.Sprintf("ta.userId = $%d", keyCount))
+		filterQueries = append(filterQueries, fmt.Sprintf("EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.taskId = t.id AND ta.userId = $%d)", keyCount))
 		keyCount += 1
 		values = append(values, userId)
 	}
 
 	if filters.StatusId != 0 {
-		filerQueries = append(filerQueries, fmt.Sprintf("t.statusId = $%d", keyCount))
+		filterQueries = append(filterQueries, fmt.Sprintf("t.statusId = $%d", keyCount))
 		keyCount += 1
 		values = append(values, filters.StatusId)
 	}
 
-	// if there are filters
-	if keyCount >= 2 {
-		mainQuery += " WHERE "
+	if filters.MinPriority != nil {
+		filterQueries = append(filterQueries, fmt.Sprintf("t.priority >= $%d", keyCount))
+		keyCount += 1
+		values = append(values, *filters.MinPriority)
 	}
 
-	// build query
-	query := fmt.Sprintf(`%s %s`, mainQuery, strings.Join(filerQueries, " AND "))
+	if filters.MaxPriority != nil {
+		filterQueries = append(filterQueries, fmt.Sprintf("t.priority <= $%d", keyCount))
+		keyCount += 1
+		values = append(values, *filters.MaxPriority)
+	}
 
-	rows, err := s.db.QueryContext(ctx, query, values...)
+	// task has at least one of the labels
+	if len(filters.AnyLabelIds) > 0 {
+		filterQueries = append(filterQueries, fmt.Sprintf("EXISTS (SELECT 1 FROM task_labels tl WHERE tl.taskId = t.id AND tl.labelId = ANY($%d))", keyCount))
+		keyCount += 1
+		values = append(values, pq.Array(toInt64s(filters.AnyLabelIds)))
+	}
 
-	//close rows on end
-	defer rows.Close()
+	// task has all of the labels
+	if len(filters.AllLabelIds) > 0 {
+		labelIds := toInt64s(filters.AllLabelIds)
+		filterQueries = append(filterQueries, fmt.Sprintf("(SELECT count(*) FROM task_labels tl WHERE tl.taskId = t.id AND tl.labelId = ANY($%d)) = $%d", keyCount, keyCount+1))
+		keyCount += 2
+		values = append(values, pq.Array(labelIds), len(labelIds))
+	}
 
-	if err != nil {
-		log.Error("Error: ", err)
-		return nil, err
+	return filterQueries, values
+}
+
+// addDetails adds the assignees and the labels to given tasks
+func (s *Storage) addDetails(ctx context.Context, tasks []*models.Task) error {
+	if err := s.addAssignees(ctx, tasks); err != nil {
+		return err
 	}
 
-	//get every task by
-	for rows != nil && rows.Next() {
-		// define all needed vars
+	return s.addLabels(ctx, tasks)
+}
 
-		//task
-		var id int
-		var title, creatorId, description string
-		var due time.Time
-		//completed
-		var completed *sql.NullBool
-		var completedWrapper *wrapperspb.BoolValue
-		//status
-		var status *models.Status
-		var statusId *sql.NullInt64
-		// assignee
-		var assigneeUserId *sql.NullString
-		var assigneeId *sql.NullInt64
-		var assigneeRole *sql.NullString
-		var assigneeEmail *sql.NullString
-
-		// get values from row
-		err = rows.Scan(&id, &title, &description, &creatorId, &statusId, &due, &completed, &assigneeRole, &assigneeUserId, &assigneeEmail, &assigneeId)
-		if err != nil {
-			return nil, err
-		}
+// addAssignees gets the assignees of given tasks in one query and adds them to the tasks
+func (s *Storage) addAssignees(ctx context.Context, tasks []*models.Task) error {
+	var taskIds []int64
 
-		// get status if it is not null
-		if statusId != nil && statusId.Valid {
-			status, err = s.GetStatusById(ctx, int(statusId.Int64))
-			if err != nil {
-				return nil, err
-			}
-		}
+	// task assignees
+	// map key -> taskId
+	// value -> assignees array
+	assignees := make(map[int][]*models.Assignee)
 
-		// if completed != null
-		if completed != nil && completed.Valid {
-			completedWrapper = wrapperspb.Bool(completed.Bool)
-		}
+	if len(tasks) == 0 {
+		return nil
+	}
 
-		// if there is an assignee
-		if assigneeId != nil && assigneeId.Valid {
-			assignees[id] = append(assignees[id],
-				&models.Assignee{
-					Id:     int(assigneeId.Int64),
-					TaskId: id,
-					Role:   assigneeRole.String,
-					User: &user.Model{
-						Id:    assigneeUserId.String,
-						Email: assigneeEmail.String}})
-		}
+	for _, task := range tasks {
+		taskIds = append(taskIds, int64(task.Id))
+	}
 
-		// if task with that id is not in the slice
-		// because if there are two rows with another assignee
-		//we only need to add one task
-		if !taskContainsId(tasks, id) {
-			// generate task model
-			tasks = append(tasks, &models.Task{
-				Id:          id,
-				Title:       title,
-				Description: description,
-				Due:         due,
-				Completed:   completedWrapper,
-				Status:      status,
-				CreatorId:   creatorId,
-			})
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT ta.id, ta.taskId, ta.role, ta.userId, u.email
+	FROM task_assignees ta
+	LEFT JOIN users u ON ta.userId = u.id
+	WHERE ta.taskId = ANY($1)
+	ORDER BY ta.id`, pq.Array(taskIds))
+	if err != nil {
+		return err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, taskId int
+		var role string
+		var userId, email sql.NullString
+
+		if err = rows.Scan(&id, &taskId, &role, &userId, &email); err != nil {
+			return err
 		}
+
+		assignees[taskId] = append(assignees[taskId], &models.Assignee{
+			Id:     id,
+			TaskId: taskId,
+			Role:   role,
+			User: &user.Model{
+				Id:    userId.String,
+				Email: email.String,
+			},
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
 	}
 
 	// loop throw tasks and add assignees to struct
 	for _, task := range tasks {
-		if assignees[task.Id] != nil {
-			task.Assignees = assignees[task.Id]
-		}
+		task.Assignees = assignees[task.Id]
 	}
-	return tasks, nil
+
+	return nil
 }
 
 // AssignTask this function assigns task to propped user and propped role
-func (s *Storage) AssignTask(ctx context.Context, userId, role string, taskId int) (*models.Task, error) {
+func (s *Storage) AssignTask(ctx context.Context, userId, role string, taskId int, actorId string) (*models.Task, error) {
 	op := "storage.AssignTask"
-	log := s.log.With(op)
+	log := s.log.With("op", op)
 	var id int
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
 	// exec
-	err := s.db.QueryRowContext(ctx, "INSERT INTO task_assignees (taskId, role,userId) VALUES ($1, $2, $3) RETURNING id", taskId, role, userId).Scan(&id)
+	previous, err := outbox.SnapshotTasks(ctx, tx, []int64{int64(taskId)})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, "INSERT INTO task_assignees (taskId, role,userId) VALUES ($1, $2, $3) RETURNING id", taskId, role, userId).Scan(&id)
 
 	if err != nil {
 		// get detailed error
@@ -476,34 +1081,77 @@ func (s *Storage) AssignTask(ctx context.Context, userId, role string, taskId in
 		if ok && pqErr.Code == "23505" {
 			return nil, appErrors.TaskAlreadyAssigned
 		}
-		log.Error("Error: ", err)
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	err = insertHistory(ctx, tx, actorId, &models.TaskHistoryEntry{TaskId: taskId, Type: models.TaskAssigned, Field: models.FieldAssignee, NewValue: userId})
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskAssigned, []int64{int64(taskId)}, previous, actorId, userId); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
+
 	// get updated task
 	return s.GetTaskById(ctx, taskId)
 }
 
 // UnAssignTask this function un assigns task
-func (s *Storage) UnAssignTask(ctx context.Context, userId string, taskId int) (*models.Task, error) {
+func (s *Storage) UnAssignTask(ctx context.Context, userId string, taskId int, actorId string) (*models.Task, error) {
 	op := "storage.UnAssignTask"
-	log := s.log.With(op)
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
 
 	// exec
-	execRows, err := s.db.ExecContext(ctx, "DELETE FROM task_assignees ta WHERE ta.userid = $1 AND ta.taskid = $2", userId, taskId)
+	previous, err := outbox.SnapshotTasks(ctx, tx, []int64{int64(taskId)})
+	if err != nil {
+		return nil, err
+	}
+
+	execRows, err := tx.ExecContext(ctx, "DELETE FROM task_assignees ta WHERE ta.userid = $1 AND ta.taskid = $2", userId, taskId)
 
 	if err != nil {
-		log.Error("Error: ", err)
+		log.Error("Error", "errors", err)
 		return nil, err
 	}
 
 	rowsAffected, err := execRows.RowsAffected()
 
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
 	if rowsAffected == 0 {
 		return nil, appErrors.TaskNotAssigned
 	}
 
+	err = insertHistory(ctx, tx, actorId, &models.TaskHistoryEntry{TaskId: taskId, Type: models.TaskUnassigned, Field: models.FieldAssignee, OldValue: userId})
 	if err != nil {
-		log.Error("Error: ", err)
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskUnassigned, []int64{int64(taskId)}, previous, actorId, userId); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
 
@@ -512,34 +1160,37 @@ func (s *Storage) UnAssignTask(ctx context.Context, userId string, taskId int) (
 }
 
 // GetAllStatuses this function gets all statuses
-func (s *Storage) GetAllStatuses(ctx context.Context) ([]*models.Status, error) {
+// with projectId only the shared statuses and the ones of that project
+func (s *Storage) GetAllStatuses(ctx context.Context, projectId int) ([]*models.Status, error) {
 	op := "storage.GetAllStatuses"
-	log := s.log.With(op)
+	log := s.log.With("op", op)
 	var statuses []*models.Status
 
 	// exec query
-	rows, err := s.db.QueryContext(ctx, "SELECT title, description, id FROM statuses")
+	rows, err := s.db.QueryContext(ctx, "SELECT title, description, id, projectId FROM statuses WHERE $1 = 0 OR projectId IS NULL OR projectId = $1 ORDER BY id", projectId)
 
 	//close the rows on the end
 	defer rows.Close()
 
 	if err != nil {
-		log.Error("Error: ", err)
+		log.Error("Error", "errors", err)
 		return nil, err
 	}
 
 	for rows.Next() {
 		var id int
 		var title, description string
+		var statusProjectId sql.NullInt64
 		//get values from row
-		err = rows.Scan(&title, &description, &id)
+		err = rows.Scan(&title, &description, &id, &statusProjectId)
 		statuses = append(statuses, &models.Status{
 			Id:          id,
 			Description: description,
 			Title:       title,
+			ProjectId:   int(statusProjectId.Int64),
 		})
 		if err != nil {
-			log.Error("Error: ", err)
+			log.Error("Error", "errors", err)
 			return nil, err
 		}
 
@@ -548,12 +1199,27 @@ func (s *Storage) GetAllStatuses(ctx context.Context) ([]*models.Status, error)
 	return statuses, nil
 }
 
-// taskContainsId this function checks if given slice of tasks contains given id
-func taskContainsId(tasks []*models.Task, id int) bool {
-	for _, task := range tasks {
-		if task.Id == id {
-			return true
+// nullInt converts the default value 0 to null
+func nullInt(value int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(value), Valid: value != 0}
+}
+
+// nullString converts the default value "" to null
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// toInt64s converts the ids for pq.Array and removes duplicates
+func toInt64s(ids []int) []int64 {
+	var value []int64
+	seen := make(map[int]bool)
+
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			value = append(value, int64(id))
 		}
 	}
-	return false
+
+	return value
 }
@@ -0,0 +1,136 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/outbox"
+	"time"
+)
+
+// RestoreTask moves the task out of the trash
+// the subtasks deleted together with the task are restored too, they are returned after the task
+func (s *Storage) RestoreTask(ctx context.Context, id int, actorId string) (*models.Task, []*models.Task, error) {
+	op := "storage.RestoreTask"
+	log := s.log.With("op", op)
+	var deletedAt sql.NullTime
+	var parentDeleted bool
+	var ids []int64
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+	SELECT t.deletedAt, p.deletedAt IS NOT NULL
+	FROM tasks t LEFT JOIN tasks p ON p.id = t.parentId
+	WHERE t.id = $1 FOR UPDATE OF t`, id).Scan(&deletedAt, &parentDeleted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, appErrors.ErrTaskNotExists
+		}
+		log.Error("Error", "errors", err)
+		return nil, nil, err
+	}
+
+	if !deletedAt.Valid {
+		return nil, nil, appErrors.ErrTaskNotExists
+	}
+
+	// the parent has to be restored first
+	if parentDeleted {
+		return nil, nil, appErrors.ErrParentTaskDeleted
+	}
+
+	// the subtasks deleted by the same delete have the same deletedAt
+	restoredIds, err := outbox.SelectIds(ctx, tx, `
+	WITH RECURSIVE subtasks AS (
+		SELECT id FROM tasks WHERE id = $1
+		UNION ALL
+		SELECT t.id FROM tasks t JOIN subtasks st ON t.parentId = st.id
+		WHERE t.deletedAt = (SELECT deletedAt FROM tasks WHERE id = $1)
+	)
+	SELECT id FROM subtasks`, id)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, nil, err
+	}
+
+	previous, err := outbox.SnapshotTasks(ctx, tx, restoredIds)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	WITH restored AS (
+		UPDATE tasks SET deletedAt = NULL WHERE id = ANY($1) RETURNING id
+	)
+	INSERT INTO task_events (taskId, actorId, type) SELECT id, NULLIF($2, ''), $3 FROM restored`, pq.Array(restoredIds), actorId, models.TaskRestored)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, nil, err
+	}
+
+	if err = outbox.AddTaskEvents(ctx, tx, models.EventTaskRestored, restoredIds, previous, actorId, ""); err != nil {
+		log.Error("Error", "errors", err)
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	for _, restoredId := range restoredIds {
+		if restoredId != int64(id) {
+			ids = append(ids, restoredId)
+		}
+	}
+
+	task, err := s.GetTaskById(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE t.id = ANY($1) ORDER BY t.id", taskColumns, taskTables)
+	subtasks, err := s.queryTasks(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return task, subtasks, nil
+}
+
+// PurgeDeletedTasks permanently deletes at most limit tasks which are longer than the retention in the trash
+// it returns the count of purged tasks
+func (s *Storage) PurgeDeletedTasks(ctx context.Context, retention time.Duration, limit int) (int, error) {
+	op := "storage.PurgeDeletedTasks"
+	log := s.log.With("op", op)
+
+	// SKIP LOCKED lets several instances purge at the same time
+	execContext, err := s.db.ExecContext(ctx, `
+	WITH purged AS (
+		DELETE FROM tasks WHERE id IN (
+			SELECT id FROM tasks WHERE deletedAt < now() - make_interval(secs => $1) ORDER BY deletedAt, id LIMIT $2 FOR UPDATE SKIP LOCKED
+		) RETURNING id
+	)
+	INSERT INTO task_events (taskId, type) SELECT id, $3 FROM purged`, retention.Seconds(), limit, models.TaskPurged)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return 0, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
@@ -0,0 +1,132 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/dbutil"
+	"time"
+)
+
+// lastUsedPrecision is how often the last use of an api key is written, not on every request
+const lastUsedPrecision = time.Minute
+
+// CreateApiKey stores the hash of the api key, a zero expiresAt never expires
+func (s *Storage) CreateApiKey(ctx context.Context, key *user.ApiKey, tokenHash string) (*user.ApiKey, error) {
+	op := "storage.token.CreateApiKey"
+	log := s.log.With("op", op)
+	var expiresAt sql.NullInt64
+
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = sql.NullInt64{Int64: key.ExpiresAt.Unix(), Valid: true}
+	}
+
+	created := *key
+	err := s.db.QueryRowContext(ctx, `
+	INSERT INTO api_keys (userId, name, prefix, tokenHash, scopes, expiresAt) VALUES ($1, $2, $3, $4, $5, to_timestamp($6))
+	RETURNING id, createdAt`, key.UserId, key.Name, key.Prefix, tokenHash, pq.Array(key.Scopes), expiresAt).Scan(&created.Id, &created.CreatedAt)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// GetApiKeys gets the api keys of the user which are not revoked, the newest first
+func (s *Storage) GetApiKeys(ctx context.Context, userId string) ([]*user.ApiKey, error) {
+	op := "storage.token.GetApiKeys"
+	log := s.log.With("op", op)
+	var keys []*user.ApiKey
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT id, userId, name, prefix, scopes, createdAt, expiresAt, lastUsedAt
+	FROM api_keys WHERE userId = $1 AND revokedAt IS NULL ORDER BY id DESC`, userId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// UseApiKey gets the valid api key with the hash and updates its last use
+func (s *Storage) UseApiKey(ctx context.Context, tokenHash string) (*user.ApiKey, error) {
+	op := "storage.token.UseApiKey"
+	log := s.log.With("op", op)
+
+	row := s.db.QueryRowContext(ctx, `
+	SELECT id, userId, name, prefix, scopes, createdAt, expiresAt, lastUsedAt
+	FROM api_keys WHERE tokenHash = $1 AND revokedAt IS NULL AND (expiresAt IS NULL OR expiresAt > now())`, tokenHash)
+
+	key, err := scanApiKey(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, appErrors.ErrApiKeyInvalid
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+	UPDATE api_keys SET lastUsedAt = now()
+	WHERE id = $1 AND (lastUsedAt IS NULL OR lastUsedAt < now() - make_interval(secs => $2))`, key.Id, lastUsedPrecision.Seconds())
+	if err != nil {
+		log.Error("Error on updating last use", "errors", err)
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// RevokeApiKey revokes the api key of the user
+func (s *Storage) RevokeApiKey(ctx context.Context, id int, userId string) error {
+	op := "storage.token.RevokeApiKey"
+	log := s.log.With("op", op)
+
+	execContext, err := s.db.ExecContext(ctx, "UPDATE api_keys SET revokedAt = now() WHERE id = $1 AND userId = $2 AND revokedAt IS NULL", id, userId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return appErrors.ErrApiKeyNotExists
+	}
+
+	return nil
+}
+
+func scanApiKey(row dbutil.Scanner) (*user.ApiKey, error) {
+	var key user.ApiKey
+	var expiresAt, lastUsedAt sql.NullTime
+
+	err := row.Scan(&key.Id, &key.UserId, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedAt, &expiresAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	key.ExpiresAt = expiresAt.Time
+	key.LastUsedAt = lastUsedAt.Time
+
+	return &key, nil
+}
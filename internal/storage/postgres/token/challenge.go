@@ -0,0 +1,84 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+	"time"
+)
+
+// CreateLoginChallenge stores the hash of a login challenge of the user which expires after ttl
+func (s *Storage) CreateLoginChallenge(ctx context.Context, userId, tokenHash string, ttl time.Duration) error {
+	op := "storage.token.CreateLoginChallenge"
+	log := s.log.With("op", op)
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO login_challenges (userId, tokenHash, expiresAt) VALUES ($1, $2, now() + make_interval(secs => $3))", userId, tokenHash, ttl.Seconds())
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetLoginChallenge returns the user id of an open login challenge
+func (s *Storage) GetLoginChallenge(ctx context.Context, tokenHash string) (string, error) {
+	op := "storage.token.GetLoginChallenge"
+	log := s.log.With("op", op)
+	var userId string
+
+	err := s.db.QueryRowContext(ctx, `
+	SELECT userId FROM login_challenges
+	WHERE tokenHash = $1 AND usedAt IS NULL AND expiresAt > now() AND attempts < $2`, tokenHash, user.MaxCodeAttempts).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", appErrors.ErrChallengeInvalid
+		}
+		log.Error("Error", "errors", err)
+		return "", err
+	}
+
+	return userId, nil
+}
+
+// FailLoginChallenge counts a wrong code for the login challenge
+func (s *Storage) FailLoginChallenge(ctx context.Context, tokenHash string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE login_challenges SET attempts = attempts + 1 WHERE tokenHash = $1", tokenHash)
+	return err
+}
+
+// UseLoginChallenge marks the login challenge as used, it can only be used once
+func (s *Storage) UseLoginChallenge(ctx context.Context, tokenHash string) error {
+	execContext, err := s.db.ExecContext(ctx, "UPDATE login_challenges SET usedAt = now() WHERE tokenHash = $1 AND usedAt IS NULL AND expiresAt > now()", tokenHash)
+	if err != nil {
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return appErrors.ErrChallengeInvalid
+	}
+
+	return nil
+}
+
+// PurgeExpiredChallenges deletes the expired login challenges and returns their count
+func (s *Storage) PurgeExpiredChallenges(ctx context.Context) (int, error) {
+	execContext, err := s.db.ExecContext(ctx, "DELETE FROM login_challenges WHERE expiresAt < now()")
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
@@ -0,0 +1,107 @@
+package token
+
+import (
+	"context"
+	"sso_3.0/internal/pkg/jwt"
+	"time"
+)
+
+// signingKeysLock is the advisory lock which lets only one replica rotate the keys at a time
+const signingKeysLock = 7117
+
+// currentKeyQuery checks if the signing key is younger than the rotation
+const currentKeyQuery = "SELECT EXISTS (SELECT 1 FROM signing_keys WHERE retiresAt IS NULL AND createdAt > now() - make_interval(secs => $1))"
+
+// GetSigningKeys gets the keys which are not retired yet, the newest first
+func (s *Storage) GetSigningKeys(ctx context.Context) ([]*jwt.Key, error) {
+	op := "storage.token.GetSigningKeys"
+	log := s.log.With("op", op)
+	var keys []*jwt.Key
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT kid, privateKey, retiresAt IS NOT NULL FROM signing_keys
+	WHERE retiresAt IS NULL OR retiresAt > now() ORDER BY createdAt DESC`)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var key jwt.Key
+		var privateKey string
+		if err = rows.Scan(&key.Id, &privateKey, &key.Retired); err != nil {
+			return nil, err
+		}
+		if key.PrivateKey, err = jwt.ParsePrivateKey(privateKey); err != nil {
+			log.Error("Error on parsing key", "kid", key.Id, "errors", err)
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+
+	return keys, rows.Err()
+}
+
+// IsSigningKeyCurrent checks if there is a signing key which is younger than rotation
+func (s *Storage) IsSigningKeyCurrent(ctx context.Context, rotation time.Duration) (bool, error) {
+	var current bool
+	err := s.db.QueryRowContext(ctx, currentKeyQuery, rotation.Seconds()).Scan(&current)
+	return current, err
+}
+
+// RotateSigningKey stores the key as the new signing key if there is no signing key younger than rotation
+// the previous signing key is retired after retention, so the tokens signed with it can be checked until they expire
+// it returns false if the key was not stored, because another replica rotated already
+func (s *Storage) RotateSigningKey(ctx context.Context, key *jwt.Key, rotation, retention time.Duration) (bool, error) {
+	op := "storage.token.RotateSigningKey"
+	log := s.log.With("op", op)
+	var current bool
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", signingKeysLock); err != nil {
+		log.Error("Error", "errors", err)
+		return false, err
+	}
+
+	err = tx.QueryRowContext(ctx, currentKeyQuery, rotation.Seconds()).Scan(&current)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return false, err
+	}
+
+	if current {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE signing_keys SET retiresAt = now() + make_interval(secs => $1) WHERE retiresAt IS NULL", retention.Seconds())
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO signing_keys (kid, algorithm, privateKey) VALUES ($1, $2, $3)", key.Id, jwt.Algorithm, key.EncodePrivateKey())
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return false, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM signing_keys WHERE retiresAt < now()"); err != nil {
+		log.Error("Error", "errors", err)
+		return false, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,265 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	appErrors "sso_3.0/internal/errors"
+	"time"
+)
+
+type Storage struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func New(db *sql.DB, log *slog.Logger) *Storage {
+	return &Storage{db: db, log: log}
+}
+
+// CreateRefreshToken stores the hash of a refresh token of the family
+// the access token issued together with it is revoked when the family is revoked
+func (s *Storage) CreateRefreshToken(ctx context.Context, userId, familyId, tokenHash, accessJti string, accessExpiresAt time.Time, ttl time.Duration) error {
+	op := "storage.token.CreateRefreshToken"
+	log := s.log.With("op", op)
+
+	err := insertRefreshToken(ctx, s.db, userId, familyId, tokenHash, accessJti, accessExpiresAt, ttl)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// RotateRefreshToken replaces the refresh token with a new one of the same family and returns the user id
+// using a refresh token twice means it was stolen, then the whole family is revoked
+func (s *Storage) RotateRefreshToken(ctx context.Context, tokenHash, newTokenHash, accessJti string, accessExpiresAt time.Time, ttl time.Duration) (string, error) {
+	op := "storage.token.RotateRefreshToken"
+	log := s.log.With("op", op)
+	var id int
+	var userId, familyId string
+	var expired, used, revoked bool
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+	SELECT id, userId, familyId, expiresAt < now(), usedAt IS NOT NULL, revokedAt IS NOT NULL
+	FROM refresh_tokens WHERE tokenHash = $1 FOR UPDATE`, tokenHash).Scan(&id, &userId, &familyId, &expired, &used, &revoked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", appErrors.ErrRefreshTokenInvalid
+		}
+		log.Error("Error", "errors", err)
+		return "", err
+	}
+
+	if revoked || expired {
+		return "", appErrors.ErrRefreshTokenInvalid
+	}
+
+	if used {
+		if err = revokeFamily(ctx, tx, familyId); err != nil {
+			log.Error("Error on revoking family", "errors", err)
+			return "", err
+		}
+		if err = tx.Commit(); err != nil {
+			return "", err
+		}
+		return "", appErrors.ErrRefreshTokenReused
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE refresh_tokens SET usedAt = now() WHERE id = $1", id)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return "", err
+	}
+
+	err = insertRefreshToken(ctx, tx, userId, familyId, newTokenHash, accessJti, accessExpiresAt, ttl)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", err
+	}
+
+	return userId, nil
+}
+
+// RevokeFamily revokes all refresh tokens of the family of the token and their access tokens
+// only the user of the token can revoke it
+func (s *Storage) RevokeFamily(ctx context.Context, tokenHash, userId string) error {
+	op := "storage.token.RevokeFamily"
+	log := s.log.With("op", op)
+	var familyId string
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "SELECT familyId FROM refresh_tokens WHERE tokenHash = $1 AND userId = $2", tokenHash, userId).Scan(&familyId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return appErrors.ErrRefreshTokenInvalid
+		}
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	if err = revokeFamily(ctx, tx, familyId); err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeUserTokens revokes all refresh tokens of the user, their access tokens and the api keys of the user
+func (s *Storage) RevokeUserTokens(ctx context.Context, userId string) error {
+	op := "storage.token.RevokeUserTokens"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	if err = revokeUserTokens(ctx, tx, userId); err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdatePassword sets the password hash of the user and revokes all tokens and api keys of the user
+// both happen in one transaction, so no session can outlive the old password
+func (s *Storage) UpdatePassword(ctx context.Context, userId, hash string) error {
+	op := "storage.token.UpdatePassword"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	execContext, err := tx.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2", hash, userId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return appErrors.ErrUserNotExists
+	}
+
+	if err = revokeUserTokens(ctx, tx, userId); err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeAccessToken adds the jti to the denylist until the token expires
+func (s *Storage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO revoked_tokens (jti, expiresAt) VALUES ($1, to_timestamp($2)) ON CONFLICT DO NOTHING", jti, expiresAt.Unix())
+	return err
+}
+
+// IsRevoked checks if the jti is on the denylist
+func (s *Storage) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti).Scan(&revoked)
+	return revoked, err
+}
+
+// PurgeExpired deletes the expired refresh tokens and the denylist entries of expired access tokens
+// it returns the count of deleted rows
+func (s *Storage) PurgeExpired(ctx context.Context) (int, error) {
+	var purged int64
+
+	for _, query := range []string{
+		"DELETE FROM revoked_tokens WHERE expiresAt < now()",
+		"DELETE FROM refresh_tokens WHERE expiresAt < now()",
+	} {
+		execContext, err := s.db.ExecContext(ctx, query)
+		if err != nil {
+			return int(purged), err
+		}
+
+		affected, err := execContext.RowsAffected()
+		if err != nil {
+			return int(purged), err
+		}
+		purged += affected
+	}
+
+	return int(purged), nil
+}
+
+// execer is a sql.DB or sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertRefreshToken(ctx context.Context, db execer, userId, familyId, tokenHash, accessJti string, accessExpiresAt time.Time, ttl time.Duration) error {
+	_, err := db.ExecContext(ctx, `
+	INSERT INTO refresh_tokens (userId, familyId, tokenHash, accessJti, accessExpiresAt, expiresAt)
+	VALUES ($1, $2, $3, $4, to_timestamp($5), now() + make_interval(secs => $6))`,
+		userId, familyId, tokenHash, accessJti, accessExpiresAt.Unix(), ttl.Seconds())
+	return err
+}
+
+// revokeFamily revokes the refresh tokens of the family and adds their unexpired access tokens to the denylist
+func revokeFamily(ctx context.Context, tx *sql.Tx, familyId string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revokedAt = now() WHERE familyId = $1 AND revokedAt IS NULL", familyId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO revoked_tokens (jti, expiresAt)
+	SELECT accessJti, accessExpiresAt FROM refresh_tokens WHERE familyId = $1 AND accessExpiresAt > now()
+	ON CONFLICT DO NOTHING`, familyId)
+	return err
+}
+
+// revokeUserTokens revokes the refresh tokens of the user, adds their unexpired access tokens to the denylist and revokes the api keys
+func revokeUserTokens(ctx context.Context, tx *sql.Tx, userId string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revokedAt = now() WHERE userId = $1 AND revokedAt IS NULL", userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO revoked_tokens (jti, expiresAt)
+	SELECT accessJti, accessExpiresAt FROM refresh_tokens WHERE userId = $1 AND accessExpiresAt > now()
+	ON CONFLICT DO NOTHING`, userId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE api_keys SET revokedAt = now() WHERE userId = $1 AND revokedAt IS NULL", userId)
+	return err
+}
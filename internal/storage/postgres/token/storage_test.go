@@ -0,0 +1,60 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/pgtest"
+	"sso_3.0/internal/storage/postgres/user"
+	"testing"
+	"time"
+)
+
+func TestRotateRefreshTokenDetectsReuse(t *testing.T) {
+	db := pgtest.Open(t)
+	s := New(db, pgtest.Logger())
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Minute)
+
+	owner, err := user.New(db, s.log).Register(ctx, pgtest.Email(t), "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first token of the stolen session and of another session of the user
+	first, second, other := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	firstJti, secondJti := uuid.NewString(), uuid.NewString()
+	if err = s.CreateRefreshToken(ctx, owner.Id, uuid.NewString(), first, firstJti, expiresAt, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CreateRefreshToken(ctx, owner.Id, uuid.NewString(), other, uuid.NewString(), expiresAt, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	userId, err := s.RotateRefreshToken(ctx, first, second, secondJti, expiresAt, time.Hour)
+	if err != nil || userId != owner.Id {
+		t.Fatalf("first RotateRefreshToken() = %q, %v, want %q, nil", userId, err, owner.Id)
+	}
+
+	if _, err = s.RotateRefreshToken(ctx, first, uuid.NewString(), uuid.NewString(), expiresAt, time.Hour); !errors.Is(err, appErrors.ErrRefreshTokenReused) {
+		t.Fatalf("reusing the token = %v, want %v", err, appErrors.ErrRefreshTokenReused)
+	}
+
+	// the reuse revokes the whole family, also the token the thief or the user got from the rotation
+	if _, err = s.RotateRefreshToken(ctx, second, uuid.NewString(), uuid.NewString(), expiresAt, time.Hour); !errors.Is(err, appErrors.ErrRefreshTokenInvalid) {
+		t.Errorf("rotating the newer token of the family = %v, want %v", err, appErrors.ErrRefreshTokenInvalid)
+	}
+
+	for _, jti := range []string{firstJti, secondJti} {
+		revoked, err := s.IsRevoked(ctx, jti)
+		if err != nil || !revoked {
+			t.Errorf("IsRevoked(access token of the family) = %t, %v, want true", revoked, err)
+		}
+	}
+
+	// the other sessions of the user stay valid
+	if _, err = s.RotateRefreshToken(ctx, other, uuid.NewString(), uuid.NewString(), expiresAt, time.Hour); err != nil {
+		t.Errorf("rotating the token of another session = %v, want nil", err)
+	}
+}
@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	appErrors "sso_3.0/internal/errors"
+	"time"
+)
+
+// CreatePasswordReset stores the hash of a password reset token of the user which expires after ttl
+func (s *Storage) CreatePasswordReset(ctx context.Context, userId, tokenHash string, ttl time.Duration) error {
+	op := "storage.auth.CreatePasswordReset"
+	log := s.log.With("op", op)
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO password_resets (userId, tokenHash, expiresAt) VALUES ($1, $2, now() + make_interval(secs => $3))", userId, tokenHash, ttl.Seconds())
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return nil
+}
+
+// ResetPassword sets the password hash of the user of the reset token and returns the user id
+// the token and all other open reset tokens of the user can not be used again
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash, hash string) (string, error) {
+	op := "storage.auth.ResetPassword"
+	log := s.log.With("op", op)
+	var userId string
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "SELECT userId FROM password_resets WHERE tokenHash = $1 AND usedAt IS NULL AND expiresAt > now() FOR UPDATE", tokenHash).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", appErrors.ErrResetTokenInvalid
+		}
+		log.Error("Error", "errors", err)
+		return "", err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2", hash, userId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return "", err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE password_resets SET usedAt = now() WHERE userId = $1 AND usedAt IS NULL", userId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", err
+	}
+
+	return userId, nil
+}
+
+// PurgeExpiredPasswordResets deletes the expired password reset tokens and returns their count
+func (s *Storage) PurgeExpiredPasswordResets(ctx context.Context) (int, error) {
+	execContext, err := s.db.ExecContext(ctx, "DELETE FROM password_resets WHERE expiresAt < now()")
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
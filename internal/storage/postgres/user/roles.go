@@ -0,0 +1,90 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+)
+
+// GrantRole sets the role of the user with the email
+func (s *Storage) GrantRole(ctx context.Context, email string, role user.Role) error {
+	op := "storage.auth.GrantRole"
+	log := s.log.With("op", op)
+
+	execContext, err := s.db.ExecContext(ctx, "UPDATE users SET role = $2 WHERE email = $1", email, role)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return appErrors.ErrUserNotExists
+	}
+
+	return nil
+}
+
+// RevokeRole makes the user with the email a member again if the user has the role
+// the last admin can not be revoked, so there is always someone who can grant roles
+func (s *Storage) RevokeRole(ctx context.Context, email string, role user.Role) error {
+	op := "storage.auth.RevokeRole"
+	log := s.log.With("op", op)
+	var current sql.NullString
+	var admins int
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	// the admins are locked, so two admins can not revoke each other at the same time
+	err = tx.QueryRowContext(ctx, `
+	SELECT (SELECT role FROM users WHERE email = $1),
+	       (SELECT count(*) FROM (SELECT id FROM users WHERE role = $2 FOR UPDATE) admins)`, email, user.RoleAdmin).Scan(&current, &admins)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	if !current.Valid {
+		return appErrors.ErrUserNotExists
+	}
+
+	if user.Role(current.String) != role || role == user.RoleMember {
+		return appErrors.ErrRoleNotGranted
+	}
+
+	if role == user.RoleAdmin && admins <= 1 {
+		return appErrors.ErrLastAdmin
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE users SET role = $2 WHERE email = $1", email, user.RoleMember); err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GrantAdmins grants the admin role to the verified users with the lower case emails
+func (s *Storage) GrantAdmins(ctx context.Context, emails []string) error {
+	op := "storage.auth.GrantAdmins"
+	log := s.log.With("op", op)
+
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET role = $2 WHERE lower(email) = ANY($1) AND verified", pq.Array(emails), user.RoleAdmin)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return nil
+}
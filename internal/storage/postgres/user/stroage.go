@@ -48,6 +48,7 @@ func (s *Storage) Register(ctx context.Context, email, hash string) (*user.Model
 	return &user.Model{
 		Id:    userId,
 		Email: email,
+		Role:  user.RoleMember,
 	}, nil
 }
 
@@ -57,7 +58,9 @@ func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*user.Model
 
 	var userId string
 	var hash string
-	err := s.db.QueryRowContext(ctx, "SELECT id, password FROM users WHERE email=$1", email).Scan(&userId, &hash)
+	var verified bool
+	var role user.Role
+	err := s.db.QueryRowContext(ctx, "SELECT id, password, verified, role FROM users WHERE email=$1", email).Scan(&userId, &hash, &verified, &role)
 
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
@@ -69,9 +72,11 @@ func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*user.Model
 	}
 
 	return &user.Model{
-		Id:    userId,
-		Email: email,
-		Hash:  hash,
+		Id:       userId,
+		Email:    email,
+		Hash:     hash,
+		Verified: verified,
+		Role:     role,
 	}, nil
 }
 
@@ -80,7 +85,9 @@ func (s *Storage) GetUserById(ctx context.Context, userId string) (*user.Model,
 	log := s.log.With("op", op)
 
 	var hash, email string
-	err := s.db.QueryRowContext(ctx, "SELECT password, email FROM users WHERE id=$1", userId).Scan(&hash, &email)
+	var verified bool
+	var role user.Role
+	err := s.db.QueryRowContext(ctx, "SELECT password, email, verified, role FROM users WHERE id=$1", userId).Scan(&hash, &email, &verified, &role)
 
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
@@ -92,8 +99,10 @@ func (s *Storage) GetUserById(ctx context.Context, userId string) (*user.Model,
 	}
 
 	return &user.Model{
-		Id:    userId,
-		Email: email,
-		Hash:  hash,
+		Id:       userId,
+		Email:    email,
+		Hash:     hash,
+		Verified: verified,
+		Role:     role,
 	}, nil
 }
@@ -0,0 +1,157 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sso_3.0/internal/domain/user"
+	appErrors "sso_3.0/internal/errors"
+)
+
+// CreateTotp stores the new secret of the user, a secret which was not confirmed yet is replaced
+func (s *Storage) CreateTotp(ctx context.Context, userId, secret string) error {
+	op := "storage.auth.CreateTotp"
+	log := s.log.With("op", op)
+
+	execContext, err := s.db.ExecContext(ctx, `
+	INSERT INTO user_totp (userId, secret) VALUES ($1, $2)
+	ON CONFLICT (userId) DO UPDATE SET secret = excluded.secret, lastStep = 0, attempts = 0, createdAt = now()
+	WHERE user_totp.enabled = false`, userId, secret)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return appErrors.ErrTotpEnabled
+	}
+
+	return nil
+}
+
+// GetTotp gets the two factor auth of the user
+func (s *Storage) GetTotp(ctx context.Context, userId string) (*user.Totp, error) {
+	op := "storage.auth.GetTotp"
+	log := s.log.With("op", op)
+	var totp user.Totp
+
+	err := s.db.QueryRowContext(ctx, "SELECT secret, enabled, lastStep, attempts FROM user_totp WHERE userId = $1", userId).Scan(&totp.Secret, &totp.Enabled, &totp.LastStep, &totp.Attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, appErrors.ErrTotpNotEnabled
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	return &totp, nil
+}
+
+// FailTotpConfirm counts a wrong code sent to confirm the secret
+func (s *Storage) FailTotpConfirm(ctx context.Context, userId string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE user_totp SET attempts = attempts + 1 WHERE userId = $1 AND enabled = false", userId)
+	return err
+}
+
+// UseTotpStep marks the time step as used, it returns false if the step or a later one was used already
+func (s *Storage) UseTotpStep(ctx context.Context, userId string, step int64) (bool, error) {
+	execContext, err := s.db.ExecContext(ctx, "UPDATE user_totp SET lastStep = $2 WHERE userId = $1 AND lastStep < $2", userId, step)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// EnableTotp enables the two factor auth of the user after the code of the step was confirmed
+// the recovery codes replace the previous ones
+func (s *Storage) EnableTotp(ctx context.Context, userId string, step int64, recoveryCodeHashes []string) error {
+	op := "storage.auth.EnableTotp"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	execContext, err := tx.ExecContext(ctx, "UPDATE user_totp SET enabled = true, lastStep = $2 WHERE userId = $1 AND enabled = false", userId, step)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return appErrors.ErrTotpEnabled
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE userId = $1", userId); err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		if _, err = tx.ExecContext(ctx, "INSERT INTO recovery_codes (userId, codeHash) VALUES ($1, $2)", userId, hash); err != nil {
+			log.Error("Error", "errors", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DisableTotp removes the two factor auth and the recovery codes of the user
+func (s *Storage) DisableTotp(ctx context.Context, userId string) error {
+	op := "storage.auth.DisableTotp"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM user_totp WHERE userId = $1",
+		"DELETE FROM recovery_codes WHERE userId = $1",
+	} {
+		if _, err = tx.ExecContext(ctx, query, userId); err != nil {
+			log.Error("Error", "errors", err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode marks the recovery code as used, it returns false if the code is unknown or was used already
+func (s *Storage) UseRecoveryCode(ctx context.Context, userId, codeHash string) (bool, error) {
+	execContext, err := s.db.ExecContext(ctx, "UPDATE recovery_codes SET usedAt = now() WHERE userId = $1 AND codeHash = $2 AND usedAt IS NULL", userId, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
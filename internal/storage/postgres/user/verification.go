@@ -0,0 +1,106 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	appErrors "sso_3.0/internal/errors"
+	"time"
+)
+
+// maxVerificationAttempts is how often a wrong code can be tried before a new code is needed
+const maxVerificationAttempts = 5
+
+// CreateVerification stores the hash of the verification code of the user, it replaces the previous code
+// if throttle is set the code is only replaced if the previous one is older than throttle
+// it returns false if the code was not stored because of the throttle
+func (s *Storage) CreateVerification(ctx context.Context, userId, codeHash string, ttl, throttle time.Duration) (bool, error) {
+	op := "storage.auth.CreateVerification"
+	log := s.log.With("op", op)
+
+	execContext, err := s.db.ExecContext(ctx, `
+	INSERT INTO email_verifications (userId, codeHash, expiresAt) VALUES ($1, $2, now() + make_interval(secs => $3))
+	ON CONFLICT (userId) DO UPDATE SET codeHash = excluded.codeHash, attempts = 0, createdAt = now(), expiresAt = excluded.expiresAt
+	WHERE email_verifications.createdAt < now() - make_interval(secs => $4)`, userId, codeHash, ttl.Seconds(), throttle.Seconds())
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return false, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// VerifyEmail marks the email of the user as verified if the code is correct
+// every wrong code counts as an attempt, after too many attempts the code can not be used anymore
+func (s *Storage) VerifyEmail(ctx context.Context, userId, codeHash string) error {
+	op := "storage.auth.VerifyEmail"
+	log := s.log.With("op", op)
+	var storedHash string
+	var attempts int
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "SELECT codeHash, attempts FROM email_verifications WHERE userId = $1 AND expiresAt > now() FOR UPDATE", userId).Scan(&storedHash, &attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return appErrors.ErrVerificationCodeInvalid
+		}
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	if attempts >= maxVerificationAttempts {
+		return appErrors.ErrVerificationCodeInvalid
+	}
+
+	if storedHash != codeHash {
+		_, err = tx.ExecContext(ctx, "UPDATE email_verifications SET attempts = attempts + 1 WHERE userId = $1", userId)
+		if err != nil {
+			log.Error("Error", "errors", err)
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		return appErrors.ErrVerificationCodeInvalid
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET verified = true WHERE id = $1", userId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM email_verifications WHERE userId = $1", userId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeExpiredVerifications deletes the expired verification codes and returns their count
+func (s *Storage) PurgeExpiredVerifications(ctx context.Context) (int, error) {
+	execContext, err := s.db.ExecContext(ctx, "DELETE FROM email_verifications WHERE expiresAt < now()")
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
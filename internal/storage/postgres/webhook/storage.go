@@ -0,0 +1,240 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+	"sso_3.0/internal/domain/models"
+	appErrors "sso_3.0/internal/errors"
+	"sso_3.0/internal/storage/postgres/dbutil"
+	"time"
+)
+
+type Storage struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+func New(db *sql.DB, log *slog.Logger) *Storage {
+	return &Storage{db: db, log: log}
+}
+
+// webhookColumns are the columns read by scanWebhook
+const webhookColumns = "id, projectId, url, eventTypes, creatorId, createdAt"
+
+// CreateWebhook creates a webhook of the project, the secret is stored as it is because the deliveries are signed with it
+func (s *Storage) CreateWebhook(ctx context.Context, projectId int, url, secret string, eventTypes []string, creatorId string) (*models.Webhook, error) {
+	op := "storage.CreateWebhook"
+	log := s.log.With("op", op)
+
+	webhook, err := scanWebhook(s.db.QueryRowContext(ctx, fmt.Sprintf(
+		"INSERT INTO webhooks (projectId, url, secret, eventTypes, creatorId) VALUES ($1, $2, $3, $4, $5) RETURNING %s", webhookColumns),
+		projectId, url, secret, pq.Array(eventTypes), creatorId))
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		// if the project was deleted meanwhile
+		if ok && pqErr.Code == "23503" {
+			return nil, appErrors.ErrProjectNotExists
+		}
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// GetWebhookById gets webhook by id
+func (s *Storage) GetWebhookById(ctx context.Context, id int) (*models.Webhook, error) {
+	webhook, err := scanWebhook(s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT %s FROM webhooks WHERE id = $1", webhookColumns), id))
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return nil, appErrors.ErrWebhookNotExists
+		}
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// GetWebhooks gets the webhooks of the project, the oldest first
+func (s *Storage) GetWebhooks(ctx context.Context, projectId int) ([]*models.Webhook, error) {
+	op := "storage.GetWebhooks"
+	log := s.log.With("op", op)
+	var webhooks []*models.Webhook
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM webhooks WHERE projectId = $1 ORDER BY id", webhookColumns), projectId)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			log.Error("Error", "errors", err)
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook deletes webhook by id together with its deliveries
+func (s *Storage) DeleteWebhook(ctx context.Context, id int) error {
+	op := "storage.DeleteWebhook"
+	log := s.log.With("op", op)
+
+	execContext, err := s.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return appErrors.ErrWebhookNotExists
+	}
+
+	return nil
+}
+
+// EnqueueDeliveries adds a delivery of the payload for every webhook of the project which posts the event type
+// it runs in the transaction of the relay, so the deliveries are added together with the event being published
+// it returns the count of added deliveries
+func EnqueueDeliveries(ctx context.Context, tx *sql.Tx, projectId int, eventType string, payload []byte) (int, error) {
+	execContext, err := tx.ExecContext(ctx, `
+	INSERT INTO webhook_deliveries (webhookId, eventType, payload)
+	SELECT id, $2, $3 FROM webhooks WHERE projectId = $1 AND $2 = ANY(eventTypes)`, projectId, eventType, string(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+// ClaimDeliveries claims at most limit pending deliveries which are due and counts their attempt
+// a claimed delivery is not claimed again before lease passed, also not by another replica,
+// so a delivery whose attempt was never recorded is retried after lease
+func (s *Storage) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	op := "storage.ClaimDeliveries"
+	log := s.log.With("op", op)
+	var deliveries []*models.WebhookDelivery
+
+	rows, err := s.db.QueryContext(ctx, `
+	WITH claimed AS (
+		UPDATE webhook_deliveries SET attempts = attempts + 1, nextAttemptAt = now() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries WHERE status = $3 AND nextAttemptAt <= now()
+			ORDER BY nextAttemptAt, id LIMIT $1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, webhookId, eventType, payload, attempts
+	)
+	SELECT c.id, c.webhookId, w.url, w.secret, c.eventType, c.payload, c.attempts FROM claimed c
+	JOIN webhooks w ON w.id = c.webhookId
+	ORDER BY c.id`, limit, lease.Seconds(), models.WebhookPending)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return nil, err
+	}
+
+	//close rows on end
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var payload string
+
+		if err = rows.Scan(&delivery.Id, &delivery.WebhookId, &delivery.URL, &delivery.Secret, &delivery.EventType, &payload, &delivery.Attempt); err != nil {
+			log.Error("Error", "errors", err)
+			return nil, err
+		}
+
+		delivery.Payload = []byte(payload)
+		deliveries = append(deliveries, &delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt logs the attempt of the delivery and sets its new status
+// a delivery which stays pending is attempted again after retryIn
+func (s *Storage) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery, attempt *models.WebhookAttempt, status models.WebhookDeliveryStatus, retryIn time.Duration) error {
+	op := "storage.RecordAttempt"
+	log := s.log.With("op", op)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// rollback does nothing after commit
+	defer tx.Rollback()
+
+	statusCode := sql.NullInt64{Int64: int64(attempt.StatusCode), Valid: attempt.StatusCode != 0}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO webhook_delivery_attempts (deliveryId, attempt, statusCode, error, durationMs) VALUES ($1, $2, $3, $4, $5)",
+		delivery.Id, delivery.Attempt, statusCode, attempt.Error, attempt.Duration.Milliseconds())
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE webhook_deliveries SET status = $2, nextAttemptAt = now() + make_interval(secs => $3),
+	deliveredAt = CASE WHEN $4 THEN now() END
+	WHERE id = $1`, delivery.Id, status, retryIn.Seconds(), status == models.WebhookDelivered)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeDeliveries deletes the delivered and failed deliveries created before olderThan together with their attempts
+// it returns the count of deleted deliveries
+func (s *Storage) PurgeDeliveries(ctx context.Context, olderThan time.Time) (int, error) {
+	op := "storage.PurgeDeliveries"
+	log := s.log.With("op", op)
+
+	execContext, err := s.db.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE status <> $1 AND createdAt < $2", models.WebhookPending, olderThan)
+	if err != nil {
+		log.Error("Error", "errors", err)
+		return 0, err
+	}
+
+	affected, err := execContext.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+func scanWebhook(row dbutil.Scanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var creatorId sql.NullString
+
+	if err := row.Scan(&webhook.Id, &webhook.ProjectId, &webhook.URL, pq.Array(&webhook.EventTypes), &creatorId, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	webhook.CreatorId = creatorId.String
+
+	return &webhook, nil
+}
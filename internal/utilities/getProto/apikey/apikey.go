@@ -0,0 +1,43 @@
+package apikey
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sso_3.0/internal/domain/user"
+	api "sso_3.0/proto/gen"
+)
+
+func GetProtoApiKey(key *user.ApiKey) *api.ApiKey {
+	var expiresAt, lastUsedAt *timestamppb.Timestamp
+
+	if key == nil {
+		return nil
+	}
+
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = timestamppb.New(key.ExpiresAt)
+	}
+
+	if !key.LastUsedAt.IsZero() {
+		lastUsedAt = timestamppb.New(key.LastUsedAt)
+	}
+
+	return &api.ApiKey{
+		Id:         int64(key.Id),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  timestamppb.New(key.CreatedAt),
+		ExpiresAt:  expiresAt,
+		LastUsedAt: lastUsedAt,
+	}
+}
+
+func GetProtoApiKeys(keys []*user.ApiKey) []*api.ApiKey {
+	var value []*api.ApiKey
+
+	for _, key := range keys {
+		value = append(value, GetProtoApiKey(key))
+	}
+
+	return value
+}
@@ -0,0 +1,44 @@
+package comment
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sso_3.0/internal/domain/models"
+	api "sso_3.0/proto/gen"
+)
+
+func GetProtoComment(comment *models.Comment) *api.Comment {
+	var updatedAt *timestamppb.Timestamp
+	var mentions []*api.User
+
+	if comment == nil {
+		return nil
+	}
+
+	if !comment.UpdatedAt.IsZero() {
+		updatedAt = timestamppb.New(comment.UpdatedAt)
+	}
+
+	for _, mentioned := range comment.Mentions {
+		mentions = append(mentions, &api.User{Id: mentioned.Id, Email: mentioned.Email})
+	}
+
+	return &api.Comment{
+		Id:        int64(comment.Id),
+		TaskId:    int64(comment.TaskId),
+		Author:    &api.User{Id: comment.Author.Id, Email: comment.Author.Email},
+		Body:      comment.Body,
+		Mentions:  mentions,
+		CreatedAt: timestamppb.New(comment.CreatedAt),
+		UpdatedAt: updatedAt,
+	}
+}
+
+func GetProtoComments(comments []*models.Comment) []*api.Comment {
+	var value []*api.Comment
+
+	for _, comment := range comments {
+		value = append(value, GetProtoComment(comment))
+	}
+
+	return value
+}
@@ -0,0 +1,27 @@
+package dependency
+
+import (
+	"sso_3.0/internal/domain/models"
+	api "sso_3.0/proto/gen"
+)
+
+func GetProtoDependency(dependency *models.Dependency) *api.TaskDependency {
+	if dependency == nil {
+		return nil
+	}
+
+	return &api.TaskDependency{
+		BlockerId: int64(dependency.BlockerId),
+		BlockedId: int64(dependency.BlockedId),
+	}
+}
+
+func GetProtoDependencies(dependencies []*models.Dependency) []*api.TaskDependency {
+	var value []*api.TaskDependency
+
+	for _, dependency := range dependencies {
+		value = append(value, GetProtoDependency(dependency))
+	}
+
+	return value
+}
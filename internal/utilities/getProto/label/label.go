@@ -0,0 +1,27 @@
+package label
+
+import (
+	"sso_3.0/internal/domain/models"
+	api "sso_3.0/proto/gen"
+)
+
+func GetLabel(label *models.Label) *api.Label {
+	if label != nil {
+		return &api.Label{
+			Id:    int64(label.Id),
+			Name:  label.Name,
+			Color: label.Color,
+		}
+	}
+	return nil
+}
+
+func GetLabels(labels []*models.Label) []*api.Label {
+	var protoLabels []*api.Label
+
+	for _, label := range labels {
+		protoLabels = append(protoLabels, GetLabel(label))
+	}
+
+	return protoLabels
+}
@@ -0,0 +1,65 @@
+package project
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sso_3.0/internal/domain/models"
+	api "sso_3.0/proto/gen"
+)
+
+func GetProject(project *models.Project) *api.Project {
+	if project != nil {
+		return &api.Project{
+			Id:          int64(project.Id),
+			Name:        project.Name,
+			Description: project.Description,
+			CreatorId:   project.CreatorId,
+			CreatedAt:   timestamppb.New(project.CreatedAt),
+		}
+	}
+	return nil
+}
+
+func GetProjectMember(member *models.ProjectMember) *api.ProjectMember {
+	if member != nil {
+		return &api.ProjectMember{
+			ProjectId: int64(member.ProjectId),
+			User:      &api.User{Id: member.User.Id, Email: member.User.Email},
+			Role:      GetProjectRole(member.Role),
+			JoinedAt:  timestamppb.New(member.JoinedAt),
+		}
+	}
+	return nil
+}
+
+func GetProjectMembers(members []*models.ProjectMember) []*api.ProjectMember {
+	var protoMembers []*api.ProjectMember
+
+	for _, member := range members {
+		protoMembers = append(protoMembers, GetProjectMember(member))
+	}
+
+	return protoMembers
+}
+
+func GetProjectRole(role models.ProjectRole) api.ProjectRole {
+	switch role {
+	case models.ProjectViewer:
+		return api.ProjectRole_PROJECT_ROLE_VIEWER
+	case models.ProjectEditor:
+		return api.ProjectRole_PROJECT_ROLE_EDITOR
+	case models.ProjectOwner:
+		return api.ProjectRole_PROJECT_ROLE_OWNER
+	default:
+		return api.ProjectRole_PROJECT_ROLE_UNSPECIFIED
+	}
+}
+
+func GetProjects(projects []*models.Project) []*api.Project {
+	var protoProjects []*api.Project
+
+	for _, project := range projects {
+		protoProjects = append(protoProjects, GetProject(project))
+	}
+
+	return protoProjects
+}
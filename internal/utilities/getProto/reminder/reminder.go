@@ -0,0 +1,33 @@
+package reminder
+
+import (
+	"google.golang.org/protobuf/types/known/durationpb"
+	"sso_3.0/internal/domain/models"
+	api "sso_3.0/proto/gen"
+	"time"
+)
+
+func GetReminderSettings(settings *models.ReminderSettings) *api.ReminderSettings {
+	var leadTimes []*durationpb.Duration
+
+	if settings == nil {
+		return nil
+	}
+
+	for _, leadTime := range settings.LeadTimes {
+		leadTimes = append(leadTimes, durationpb.New(leadTime))
+	}
+
+	return &api.ReminderSettings{LeadTimes: leadTimes, Overdue: settings.Overdue}
+}
+
+// GetReminderSettingsModel converts the settings of a request, a missing settings message turns the reminders off
+func GetReminderSettingsModel(settings *api.ReminderSettings) *models.ReminderSettings {
+	var leadTimes []time.Duration
+
+	for _, leadTime := range settings.GetLeadTimes() {
+		leadTimes = append(leadTimes, leadTime.AsDuration())
+	}
+
+	return &models.ReminderSettings{LeadTimes: leadTimes, Overdue: settings.GetOverdue()}
+}
@@ -11,6 +11,7 @@ func GetStatus(status *models.Status) *api.Status {
 			Title:       status.Title,
 			Description: status.Description,
 			Id:          int64(status.Id),
+			ProjectId:   int64(status.ProjectId),
 		}
 	}
 	return nil
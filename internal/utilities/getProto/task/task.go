@@ -3,6 +3,7 @@ package task
 import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"sso_3.0/internal/domain/models"
+	protoLabel "sso_3.0/internal/utilities/getProto/label"
 	protoStatus "sso_3.0/internal/utilities/getProto/status"
 	api "sso_3.0/proto/gen"
 )
@@ -11,6 +12,7 @@ func GetProtoTask(task *models.Task) *api.Task {
 	var status *api.Status
 	var completed bool
 	var assignees []*api.TaskAssignee
+	var deletedAt *timestamppb.Timestamp
 
 	if task == nil {
 		return nil
@@ -27,6 +29,10 @@ func GetProtoTask(task *models.Task) *api.Task {
 	if task.Assignees != nil {
 		assignees = GetProtoAssignees(task.Assignees)
 	}
+
+	if !task.DeletedAt.IsZero() {
+		deletedAt = timestamppb.New(task.DeletedAt)
+	}
 	return &api.Task{
 		Id:          int64(task.Id),
 		Title:       task.Title,
@@ -36,6 +42,13 @@ func GetProtoTask(task *models.Task) *api.Task {
 		CreatorId:   task.CreatorId,
 		Completed:   completed,
 		Assignees:   assignees,
+		ParentId:    int64(task.ParentId),
+		Labels:      protoLabel.GetLabels(task.Labels),
+		Priority:    api.TaskPriority(task.Priority),
+		DeletedAt:   deletedAt,
+		ProjectId:   int64(task.ProjectId),
+		Recurrence:  task.Recurrence,
+		SeriesId:    int64(task.SeriesId),
 	}
 }
 
@@ -50,6 +63,71 @@ func GetProtoTasks(tasks []*models.Task) []*api.Task {
 	return value
 }
 
+func GetProtoSearchResults(results []*models.TaskSearchResult) []*api.SearchTaskResult {
+	var value []*api.SearchTaskResult
+
+	for _, result := range results {
+		value = append(value, &api.SearchTaskResult{
+			Task:                 GetProtoTask(result.Task),
+			Rank:                 result.Rank,
+			TitleHighlight:       result.TitleHighlight,
+			DescriptionHighlight: result.DescriptionHighlight,
+		})
+	}
+
+	return value
+}
+
+func GetProtoTaskEvent(event *models.TaskEvent) *api.TaskEvent {
+	return &api.TaskEvent{
+		Type:    getProtoTaskEventType(event.Type),
+		Task:    GetProtoTask(event.Task),
+		ActorId: event.ActorId,
+		UserId:  event.UserId,
+		At:      timestamppb.New(event.At),
+	}
+}
+
+func GetProtoHistory(entries []*models.TaskHistoryEntry) []*api.TaskHistoryEntry {
+	var value []*api.TaskHistoryEntry
+
+	for _, entry := range entries {
+		value = append(value, &api.TaskHistoryEntry{
+			Id:       int64(entry.Id),
+			TaskId:   int64(entry.TaskId),
+			ActorId:  entry.ActorId,
+			Type:     getProtoTaskEventType(entry.Type),
+			Field:    entry.Field,
+			OldValue: entry.OldValue,
+			NewValue: entry.NewValue,
+			At:       timestamppb.New(entry.At),
+		})
+	}
+
+	return value
+}
+
+func getProtoTaskEventType(eventType models.TaskEventType) api.TaskEventType {
+	switch eventType {
+	case models.TaskCreated:
+		return api.TaskEventType_TASK_EVENT_CREATED
+	case models.TaskUpdated:
+		return api.TaskEventType_TASK_EVENT_UPDATED
+	case models.TaskDeleted:
+		return api.TaskEventType_TASK_EVENT_DELETED
+	case models.TaskAssigned:
+		return api.TaskEventType_TASK_EVENT_ASSIGNED
+	case models.TaskUnassigned:
+		return api.TaskEventType_TASK_EVENT_UNASSIGNED
+	case models.TaskRestored:
+		return api.TaskEventType_TASK_EVENT_RESTORED
+	case models.TaskPurged:
+		return api.TaskEventType_TASK_EVENT_PURGED
+	default:
+		return api.TaskEventType_TASK_EVENT_UNSPECIFIED
+	}
+}
+
 func GetProtoAssignees(assignees []*models.Assignee) []*api.TaskAssignee {
 	var value []*api.TaskAssignee
 	if assignees != nil {
@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sso_3.0/internal/domain/models"
+	api "sso_3.0/proto/gen"
+)
+
+func GetWebhook(webhook *models.Webhook) *api.Webhook {
+	if webhook != nil {
+		return &api.Webhook{
+			Id:         int64(webhook.Id),
+			ProjectId:  int64(webhook.ProjectId),
+			Url:        webhook.URL,
+			EventTypes: webhook.EventTypes,
+			CreatorId:  webhook.CreatorId,
+			CreatedAt:  timestamppb.New(webhook.CreatedAt),
+		}
+	}
+	return nil
+}
+
+func GetWebhooks(webhooks []*models.Webhook) []*api.Webhook {
+	var protoWebhooks []*api.Webhook
+
+	for _, webhook := range webhooks {
+		protoWebhooks = append(protoWebhooks, GetWebhook(webhook))
+	}
+
+	return protoWebhooks
+}
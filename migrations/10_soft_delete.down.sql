@@ -0,0 +1,12 @@
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_parentid_fkey;
+ALTER TABLE tasks ADD CONSTRAINT tasks_parentid_fkey FOREIGN KEY (parentId) REFERENCES tasks(id);
+
+ALTER TABLE task_assignees DROP CONSTRAINT IF EXISTS task_assignees_taskid_fkey;
+ALTER TABLE task_assignees ADD CONSTRAINT task_assignees_taskid_fkey FOREIGN KEY (taskId) REFERENCES tasks(id);
+
+DROP INDEX IF EXISTS tasks_deleted_at_idx;
+
+-- trashed tasks would become active again
+DELETE FROM tasks WHERE deletedAt IS NOT NULL;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS deletedAt;
@@ -0,0 +1,11 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deletedAt TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS tasks_deleted_at_idx ON tasks (deletedAt, id) WHERE deletedAt IS NOT NULL;
+
+-- deleting a task failed while it had assignees
+ALTER TABLE task_assignees DROP CONSTRAINT IF EXISTS task_assignees_taskid_fkey;
+ALTER TABLE task_assignees ADD CONSTRAINT task_assignees_taskid_fkey FOREIGN KEY (taskId) REFERENCES tasks(id) ON DELETE CASCADE;
+
+-- purging a parent from the trash must not purge or block its subtasks
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_parentid_fkey;
+ALTER TABLE tasks ADD CONSTRAINT tasks_parentid_fkey FOREIGN KEY (parentId) REFERENCES tasks(id) ON DELETE SET NULL;
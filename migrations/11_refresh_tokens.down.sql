@@ -0,0 +1,3 @@
+DROP TABlE IF EXISTS revoked_tokens CASCADE;
+
+DROP TABlE IF EXISTS refresh_tokens CASCADE;
@@ -0,0 +1,26 @@
+-- every login starts a new family, refreshing replaces the token with a new one of the same family
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+        id SERIAL PRIMARY KEY,
+        userId TEXT NOT NULL,
+        familyId TEXT NOT NULL,
+        tokenHash TEXT NOT NULL UNIQUE,
+        -- jti of the access token issued together with the refresh token
+        accessJti TEXT NOT NULL,
+        accessExpiresAt TIMESTAMP NOT NULL,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        expiresAt TIMESTAMP NOT NULL,
+        usedAt TIMESTAMP,
+        revokedAt TIMESTAMP,
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (familyId);
+CREATE INDEX IF NOT EXISTS refresh_tokens_expires_at_idx ON refresh_tokens (expiresAt);
+
+-- jti denylist of revoked access tokens, rows are useless after the token expired
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+        jti TEXT PRIMARY KEY,
+        expiresAt TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at_idx ON revoked_tokens (expiresAt);
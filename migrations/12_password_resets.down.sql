@@ -0,0 +1 @@
+DROP TABlE IF EXISTS password_resets CASCADE;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS password_resets (
+        id SERIAL PRIMARY KEY,
+        userId TEXT NOT NULL,
+        tokenHash TEXT NOT NULL UNIQUE,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        expiresAt TIMESTAMP NOT NULL,
+        usedAt TIMESTAMP,
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS password_resets_user_id_idx ON password_resets (userId);
@@ -0,0 +1,2 @@
+DROP TABlE IF EXISTS email_verifications CASCADE;
+ALTER TABLE users DROP COLUMN IF EXISTS verified;
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT false;
+
+-- the users registered before the verification can keep using the api
+UPDATE users SET verified = true;
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+        userId TEXT PRIMARY KEY,
+        codeHash TEXT NOT NULL,
+        attempts INT NOT NULL DEFAULT 0,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        expiresAt TIMESTAMP NOT NULL,
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE
+);
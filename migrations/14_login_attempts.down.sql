@@ -0,0 +1 @@
+DROP TABlE IF EXISTS login_attempts CASCADE;
@@ -0,0 +1,7 @@
+-- failed logins per email or ip, the key is like email:<email> or ip:<ip>
+CREATE TABLE IF NOT EXISTS login_attempts (
+        key TEXT PRIMARY KEY,
+        failures INT NOT NULL DEFAULT 0,
+        lastFailureAt TIMESTAMP NOT NULL DEFAULT now(),
+        lockedUntil TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP TABlE IF EXISTS login_challenges CASCADE;
+DROP TABlE IF EXISTS recovery_codes CASCADE;
+DROP TABlE IF EXISTS user_totp CASCADE;
@@ -0,0 +1,32 @@
+-- enabled is false until the user confirmed the secret with a code
+-- lastStep is the time step of the last used code, a code can only be used once
+CREATE TABLE IF NOT EXISTS user_totp (
+        userId TEXT PRIMARY KEY,
+        secret TEXT NOT NULL,
+        enabled BOOLEAN NOT NULL DEFAULT false,
+        lastStep BIGINT NOT NULL DEFAULT 0,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+        id SERIAL PRIMARY KEY,
+        userId TEXT NOT NULL,
+        codeHash TEXT NOT NULL,
+        usedAt TIMESTAMP,
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS recovery_codes_user_id_idx ON recovery_codes (userId);
+
+-- issued by the login of users with two factor auth, exchanged with a code for the tokens
+CREATE TABLE IF NOT EXISTS login_challenges (
+        id SERIAL PRIMARY KEY,
+        userId TEXT NOT NULL,
+        tokenHash TEXT NOT NULL UNIQUE,
+        attempts INT NOT NULL DEFAULT 0,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        expiresAt TIMESTAMP NOT NULL,
+        usedAt TIMESTAMP,
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+DROP TABlE IF EXISTS api_keys CASCADE;
@@ -0,0 +1,16 @@
+-- prefix is the start of the key, so the users can tell their keys apart
+CREATE TABLE IF NOT EXISTS api_keys (
+        id SERIAL PRIMARY KEY,
+        userId TEXT NOT NULL,
+        name VARCHAR(255) NOT NULL,
+        prefix VARCHAR(32) NOT NULL,
+        tokenHash TEXT NOT NULL UNIQUE,
+        scopes TEXT[] NOT NULL,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        expiresAt TIMESTAMP,
+        lastUsedAt TIMESTAMP,
+        revokedAt TIMESTAMP,
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (userId);
@@ -0,0 +1 @@
+DROP TABlE IF EXISTS signing_keys CASCADE;
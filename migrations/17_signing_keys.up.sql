@@ -0,0 +1,8 @@
+-- the newest key without retiresAt signs the tokens, the retired keys only check them until retiresAt
+CREATE TABLE IF NOT EXISTS signing_keys (
+        kid TEXT PRIMARY KEY,
+        algorithm VARCHAR(16) NOT NULL,
+        privateKey TEXT NOT NULL,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        retiresAt TIMESTAMP
+);
@@ -0,0 +1,5 @@
+ALTER TABLE statuses DROP COLUMN IF EXISTS projectId;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS projectId;
+
+DROP TABlE IF EXISTS projects CASCADE;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS projects (
+        id SERIAL PRIMARY KEY,
+        name VARCHAR(255) NOT NULL,
+        description TEXT NOT NULL DEFAULT '',
+        -- null for the default project
+        creatorId TEXT,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        FOREIGN KEY(creatorId) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- the existing tasks are moved to the default project
+INSERT INTO projects (name, description) VALUES ('Default', 'tasks created before the projects');
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS projectId INT;
+UPDATE tasks SET projectId = (SELECT min(id) FROM projects) WHERE projectId IS NULL;
+ALTER TABLE tasks ALTER COLUMN projectId SET NOT NULL;
+ALTER TABLE tasks ADD CONSTRAINT tasks_projectid_fkey FOREIGN KEY (projectId) REFERENCES projects(id);
+
+CREATE INDEX IF NOT EXISTS tasks_project_id_idx ON tasks (projectId, id);
+
+-- statuses without a project are shared by all projects, the existing ones stay shared
+ALTER TABLE statuses ADD COLUMN IF NOT EXISTS projectId INT REFERENCES projects(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS statuses_project_id_idx ON statuses (projectId);
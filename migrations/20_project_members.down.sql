@@ -0,0 +1,3 @@
+DROP TABlE IF EXISTS project_invites CASCADE;
+
+DROP TABlE IF EXISTS project_members CASCADE;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS project_members (
+        projectId INT NOT NULL,
+        userId TEXT NOT NULL,
+        role VARCHAR(16) NOT NULL,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        FOREIGN KEY(projectId) REFERENCES projects(id) ON DELETE CASCADE,
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE,
+        PRIMARY KEY (projectId, userId)
+);
+
+CREATE INDEX IF NOT EXISTS project_members_user_id_idx ON project_members (userId);
+
+-- the creators own their projects
+INSERT INTO project_members (projectId, userId, role)
+SELECT id, creatorId, 'owner' FROM projects WHERE creatorId IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- the tasks of the default project were visible to all users before
+INSERT INTO project_members (projectId, userId, role)
+SELECT p.id, u.id, 'editor' FROM projects p CROSS JOIN users u WHERE p.creatorId IS NULL AND p.name = 'Default'
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS project_invites (
+        id SERIAL PRIMARY KEY,
+        projectId INT NOT NULL,
+        email VARCHAR(255) NOT NULL,
+        role VARCHAR(16) NOT NULL,
+        tokenHash TEXT NOT NULL UNIQUE,
+        invitedBy TEXT,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        expiresAt TIMESTAMP NOT NULL,
+        acceptedAt TIMESTAMP,
+        FOREIGN KEY(projectId) REFERENCES projects(id) ON DELETE CASCADE,
+        FOREIGN KEY(invitedBy) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS project_invites_expires_at_idx ON project_invites (expiresAt);
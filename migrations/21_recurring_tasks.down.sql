@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS tasks_recurrence_pending_idx;
+
+DROP INDEX IF EXISTS tasks_series_id_idx;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurredAt;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS seriesId;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurrenceStart;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurrence;
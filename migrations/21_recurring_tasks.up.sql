@@ -0,0 +1,12 @@
+-- the RRULE of a recurring task, every occurrence is a task which carries the rule of its series
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrence TEXT;
+-- the due of the first occurrence, the rule is counted from there
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurrenceStart TIMESTAMP;
+-- the id of the first occurrence, it is no foreign key so the series stays after that task is purged
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS seriesId INT;
+-- the time the next occurrence was created or the series ended, only one replica can set it
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurredAt TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS tasks_series_id_idx ON tasks (seriesId);
+-- the occurrences the generator still has to recur
+CREATE INDEX IF NOT EXISTS tasks_recurrence_pending_idx ON tasks (due) WHERE recurrence IS NOT NULL AND recurredAt IS NULL AND deletedAt IS NULL;
@@ -0,0 +1,3 @@
+DROP TABlE IF EXISTS task_reminders CASCADE;
+
+DROP TABlE IF EXISTS reminder_settings CASCADE;
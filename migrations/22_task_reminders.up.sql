@@ -0,0 +1,23 @@
+-- the reminder lead times of a user in seconds, users without settings get the configured ones
+CREATE TABLE IF NOT EXISTS reminder_settings (
+        userId TEXT PRIMARY KEY,
+        leadTimes INT[] NOT NULL,
+        -- notify when the due of the task passed
+        overdue BOOLEAN NOT NULL DEFAULT true,
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- the sent reminders, one per task, user, lead time and due, the lead time 0 is the overdue notification
+-- a changed due gets its own reminders
+CREATE TABLE IF NOT EXISTS task_reminders (
+        taskId INT NOT NULL,
+        userId TEXT NOT NULL,
+        leadTime INT NOT NULL,
+        due TIMESTAMP NOT NULL,
+        sentAt TIMESTAMP NOT NULL DEFAULT now(),
+        FOREIGN KEY(taskId) REFERENCES tasks(id) ON DELETE CASCADE,
+        FOREIGN KEY(userId) REFERENCES users(id) ON DELETE CASCADE,
+        PRIMARY KEY (taskId, userId, leadTime, due)
+);
+
+CREATE INDEX IF NOT EXISTS task_reminders_user_id_idx ON task_reminders (userId);
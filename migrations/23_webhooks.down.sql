@@ -0,0 +1,5 @@
+DROP TABlE IF EXISTS webhook_delivery_attempts CASCADE;
+
+DROP TABlE IF EXISTS webhook_deliveries CASCADE;
+
+DROP TABlE IF EXISTS webhooks CASCADE;
@@ -0,0 +1,45 @@
+-- the urls which are posted the task events of a project, the secret signs the deliveries
+CREATE TABLE IF NOT EXISTS webhooks (
+        id SERIAL PRIMARY KEY,
+        projectId INT NOT NULL,
+        url TEXT NOT NULL,
+        secret TEXT NOT NULL,
+        eventTypes TEXT[] NOT NULL,
+        creatorId TEXT,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        FOREIGN KEY(projectId) REFERENCES projects(id) ON DELETE CASCADE,
+        FOREIGN KEY(creatorId) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_project_id_idx ON webhooks (projectId);
+
+-- the outbox of the webhooks, every event is delivered until the webhook answers or the attempts run out
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+        id BIGSERIAL PRIMARY KEY,
+        webhookId INT NOT NULL,
+        eventType VARCHAR(32) NOT NULL,
+        payload TEXT NOT NULL,
+        status VARCHAR(16) NOT NULL DEFAULT 'pending',
+        attempts INT NOT NULL DEFAULT 0,
+        nextAttemptAt TIMESTAMP NOT NULL DEFAULT now(),
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        deliveredAt TIMESTAMP,
+        FOREIGN KEY(webhookId) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhookId);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (nextAttemptAt) WHERE status = 'pending';
+
+-- every attempt of a delivery, statusCode is null if the webhook did not answer
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+        id BIGSERIAL PRIMARY KEY,
+        deliveryId BIGINT NOT NULL,
+        attempt INT NOT NULL,
+        statusCode INT,
+        error TEXT NOT NULL DEFAULT '',
+        durationMs INT NOT NULL,
+        attemptedAt TIMESTAMP NOT NULL DEFAULT now(),
+        FOREIGN KEY(deliveryId) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_attempts_delivery_id_idx ON webhook_delivery_attempts (deliveryId);
@@ -0,0 +1 @@
+DROP TABlE IF EXISTS outbox CASCADE;
@@ -0,0 +1,17 @@
+-- the domain events, written in the transaction of the change and published by the relay in the order of the ids
+-- data is the json of the task or status after the change, previous before it
+CREATE TABLE IF NOT EXISTS outbox (
+        id BIGSERIAL PRIMARY KEY,
+        aggregateType VARCHAR(16) NOT NULL,
+        aggregateId INT NOT NULL,
+        projectId INT NOT NULL,
+        eventType VARCHAR(32) NOT NULL,
+        actorId TEXT NOT NULL DEFAULT '',
+        userId TEXT NOT NULL DEFAULT '',
+        data JSONB NOT NULL,
+        previous JSONB,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        publishedAt TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (id) WHERE publishedAt IS NULL;
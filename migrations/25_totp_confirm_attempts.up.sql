@@ -0,0 +1,2 @@
+-- attempts is the count of wrong codes sent to confirm the secret, it is reset by a new enrollment
+ALTER TABLE user_totp ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
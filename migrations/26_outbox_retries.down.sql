@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS outbox_waiting_idx;
+
+DROP INDEX IF EXISTS outbox_pending_idx;
+
+-- the failed events would be published again
+DELETE FROM outbox WHERE failedAt IS NOT NULL;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS failedAt;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS nextAttemptAt;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS attempts;
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (id) WHERE publishedAt IS NULL;
@@ -0,0 +1,12 @@
+-- attempts is the count of failed publishes of an event, it waits until nextAttemptAt before the next one
+-- failedAt is set when the event ran out of attempts, it is not published anymore and no longer holds up its aggregate
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS nextAttemptAt TIMESTAMP;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS failedAt TIMESTAMP;
+
+DROP INDEX IF EXISTS outbox_pending_idx;
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (id) WHERE publishedAt IS NULL AND failedAt IS NULL;
+
+-- the waiting events hold up the later events of their aggregate
+CREATE INDEX IF NOT EXISTS outbox_waiting_idx ON outbox (aggregateType, aggregateId, id)
+    WHERE publishedAt IS NULL AND failedAt IS NULL AND nextAttemptAt IS NOT NULL;
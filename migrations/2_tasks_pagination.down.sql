@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS tasks_due_id_idx;
+
+DROP INDEX IF EXISTS tasks_title_id_idx;
+
+DROP INDEX IF EXISTS tasks_creator_id_idx;
+
+DROP INDEX IF EXISTS task_assignees_task_id_idx;
+
+DROP INDEX IF EXISTS task_assignees_user_id_idx;
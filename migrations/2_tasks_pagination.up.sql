@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS tasks_due_id_idx ON tasks (due, id);
+CREATE INDEX IF NOT EXISTS tasks_title_id_idx ON tasks (title, id);
+CREATE INDEX IF NOT EXISTS tasks_creator_id_idx ON tasks (creatorId, id);
+CREATE INDEX IF NOT EXISTS task_assignees_task_id_idx ON task_assignees (taskId);
+CREATE INDEX IF NOT EXISTS task_assignees_user_id_idx ON task_assignees (userId);
@@ -0,0 +1,7 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search tsvector
+        GENERATED ALWAYS AS (
+                setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+                setweight(to_tsvector('english', coalesce(description, '')), 'B')
+        ) STORED;
+
+CREATE INDEX IF NOT EXISTS tasks_search_idx ON tasks USING GIN (search);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parentId INT REFERENCES tasks(id);
+
+CREATE INDEX IF NOT EXISTS tasks_parent_id_idx ON tasks (parentId);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS task_dependencies (
+        id SERIAL PRIMARY KEY,
+        blockerId INT NOT NULL,
+        blockedId INT NOT NULL,
+        FOREIGN KEY(blockerId) REFERENCES tasks(id) ON DELETE CASCADE,
+        FOREIGN KEY(blockedId) REFERENCES tasks(id) ON DELETE CASCADE,
+        UNIQUE (blockerId, blockedId),
+        CHECK (blockerId <> blockedId)
+);
+
+CREATE INDEX IF NOT EXISTS task_dependencies_blocked_id_idx ON task_dependencies (blockedId);
@@ -0,0 +1,3 @@
+DROP TABlE IF EXISTS comment_mentions CASCADE;
+
+DROP TABlE IF EXISTS task_comments CASCADE;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS task_comments (
+        id SERIAL PRIMARY KEY,
+        taskId INT NOT NULL,
+        authorId TEXT NOT NULL,
+        body TEXT NOT NULL,
+        createdAt TIMESTAMP NOT NULL DEFAULT now(),
+        updatedAt TIMESTAMP,
+        FOREIGN KEY(taskId) REFERENCES tasks(id) ON DELETE CASCADE,
+        FOREIGN KEY(authorId) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS task_comments_task_id_idx ON task_comments (taskId, id);
+
+CREATE TABLE IF NOT EXISTS comment_mentions (
+        commentId INT NOT NULL,
+        userId TEXT NOT NULL,
+        FOREIGN KEY(commentId) REFERENCES task_comments(id) ON DELETE CASCADE,
+        FOREIGN KEY(userId) REFERENCES users(id),
+        PRIMARY KEY (commentId, userId)
+);
+
+CREATE INDEX IF NOT EXISTS comment_mentions_user_id_idx ON comment_mentions (userId);
@@ -0,0 +1,3 @@
+DROP TABlE IF EXISTS task_labels CASCADE;
+
+DROP TABlE IF EXISTS labels CASCADE;
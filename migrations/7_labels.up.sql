@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS labels (
+        id SERIAL PRIMARY KEY,
+        name VARCHAR(255) NOT NULL UNIQUE,
+        color VARCHAR(7) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_labels (
+        taskId INT NOT NULL,
+        labelId INT NOT NULL,
+        FOREIGN KEY(taskId) REFERENCES tasks(id) ON DELETE CASCADE,
+        FOREIGN KEY(labelId) REFERENCES labels(id) ON DELETE CASCADE,
+        PRIMARY KEY (taskId, labelId)
+);
+
+CREATE INDEX IF NOT EXISTS task_labels_label_id_idx ON task_labels (labelId);
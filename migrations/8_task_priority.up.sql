@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0
+        CHECK (priority BETWEEN 0 AND 4);
+
+CREATE INDEX IF NOT EXISTS tasks_priority_due_id_idx ON tasks ((-priority), COALESCE(due, '-infinity'::timestamp), id);
@@ -0,0 +1 @@
+DROP TABlE IF EXISTS task_events CASCADE;
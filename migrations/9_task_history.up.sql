@@ -0,0 +1,13 @@
+-- no foreign key on taskId, the history of deleted tasks is kept
+CREATE TABLE IF NOT EXISTS task_events (
+        id BIGSERIAL PRIMARY KEY,
+        taskId INT NOT NULL,
+        actorId TEXT,
+        type VARCHAR(32) NOT NULL,
+        field VARCHAR(64) NOT NULL DEFAULT '',
+        oldValue TEXT NOT NULL DEFAULT '',
+        newValue TEXT NOT NULL DEFAULT '',
+        createdAt TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_events_task_id_idx ON task_events (taskId, id);
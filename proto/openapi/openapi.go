@@ -0,0 +1,8 @@
+package openapi
+
+import _ "embed"
+
+// Document is the openapi document of the http gateway, api.swagger.json is generated from api.proto by protoc-gen-openapiv2
+//
+//go:embed api.swagger.json
+var Document []byte